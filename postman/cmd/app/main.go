@@ -1,26 +1,153 @@
 package main
 
 import (
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"postman/internal/app"
+	"postman/internal/cli"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/certs"
+	"postman/internal/lib/har"
+	collectionsservice "postman/internal/service/collections"
+	cookiesservice "postman/internal/service/cookies"
+	environmentsservice "postman/internal/service/environments"
+	historyservice "postman/internal/service/history"
+	oauth2service "postman/internal/service/oauth2"
+	requestsservice "postman/internal/service/requests"
+	runnerservice "postman/internal/service/runner"
+	scriptsservice "postman/internal/service/scripts"
+	"postman/internal/storage/jsonfile"
+	"postman/internal/tui"
+	"postman/pkg/config"
 	"postman/pkg/lib/logger"
+	"postman/pkg/lib/logger/sl"
 	"syscall"
+
+	"golang.org/x/term"
 )
 
 func main() {
-	log := logger.SetupLogger("local")
+	cfg := config.MustLoad()
+	log := logger.SetupLogger(cfg.Env)
+
+	httpClient := client.New(log, transportOptions(cfg), clientCertificates(log, cfg))
+	collectionsStorage := jsonfile.NewCollectionsStorage(log, filepath.Join(cfg.StoragePath, "collections"))
+	collectionsService := collectionsservice.New(log, collectionsStorage)
+	environmentsStorage := jsonfile.NewEnvironmentsStorage(log, filepath.Join(cfg.StoragePath, "environments"))
+	environmentsService := environmentsservice.New(log, environmentsStorage)
+	tokensStorage := jsonfile.NewTokensStorage(log, filepath.Join(cfg.StoragePath, "tokens"))
+	oauth2Service := oauth2service.New(log, tokensStorage, environmentsService, httpClient, os.Stderr)
+	cookiesStorage := jsonfile.NewCookiesStorage(log, filepath.Join(cfg.StoragePath, "cookies"))
+	cookiesService := cookiesservice.New(log, cookiesStorage, environmentsService)
+	requestsService := requestsservice.New(log, httpClient, environmentsService, oauth2Service, cookiesService)
+	var harRecorder *har.Recorder
+	if cfg.HARPath != "" {
+		harRecorder = har.NewRecorder(cfg.HARPath)
+		requestsService.AddRecorder(harRecorder)
+	}
+	historyStorage := jsonfile.NewHistoryStorage(log, filepath.Join(cfg.StoragePath, "history.jsonl"))
+	historyService := historyservice.New(log, historyStorage, environmentsService, cfg.HistoryLimit, cfg.HistoryBodyLimit)
+	if !cfg.DisableHistory {
+		requestsService.AddRecorder(historyService)
+	}
+	scriptsService := scriptsservice.New(log, requestsService, environmentsService)
+	runnerService := runnerservice.New(log, requestsService, collectionsService, scriptsService)
+	commands := cli.New(log, requestsService, collectionsService, environmentsService, runnerService, scriptsService, oauth2Service, cookiesService, historyService)
+
+	if args := flag.Args(); len(args) > 0 {
+		code := commands.Run(args)
+		writeHAR(log, harRecorder)
+		os.Exit(code)
+	}
 
-	application := app.New(log)
+	if cfg.UI == config.UIFullScreen && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		screen := tui.New(log, requestsService, collectionsService, environmentsService, scriptsService, historyService)
+		oauth2Service.SetOutput(screen.Output())
+		err := screen.Run()
+		writeHAR(log, harRecorder)
+		if err != nil {
+			log.Error("Error running the UI", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
+	application := app.New(log, requestsService, commands, collectionsService, environmentsService)
+
+	done := make(chan struct{})
 	go func() {
 		application.Run()
+		close(done)
 	}()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	<-stop
+	select {
+	case <-stop:
+	case <-done:
+	}
+	writeHAR(log, harRecorder)
 
 	log.Info("application stopped")
 }
+
+// writeHAR appends the exchanges of the session to the HAR file of the
+// config, if any.
+func writeHAR(log *slog.Logger, recorder *har.Recorder) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		log.Error("Error writing HAR file", sl.Err(err))
+	}
+}
+
+// transportOptions converts the transport settings of the config into
+// the defaults of every request.
+func transportOptions(cfg *config.Config) models.Options {
+	follow := !cfg.NoFollowRedirects
+	http2 := !cfg.DisableHTTP2
+
+	return models.Options{
+		Timeout:            cfg.Timeout,
+		FollowRedirects:    &follow,
+		MaxRedirects:       cfg.MaxRedirects,
+		Proxy:              cfg.Proxy,
+		NoProxy:            cfg.NoProxy,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		CABundle:           cfg.CABundle,
+		HTTP2:              &http2,
+	}
+}
+
+// clientCertificates converts the client certificates of the config,
+// reading passphrases from the environment when configured so. Invalid
+// entries are logged and skipped.
+func clientCertificates(log *slog.Logger, cfg *config.Config) []models.ClientCertificate {
+	list := make([]models.ClientCertificate, 0, len(cfg.ClientCertificates))
+	for _, c := range cfg.ClientCertificates {
+		passphrase := c.Passphrase
+		if c.PassphraseEnv != "" {
+			passphrase = os.Getenv(c.PassphraseEnv)
+		}
+		cert := models.ClientCertificate{
+			Hosts:      c.Hosts,
+			CertFile:   c.Cert,
+			KeyFile:    c.Key,
+			PKCS12File: c.PKCS12,
+			Passphrase: passphrase,
+		}
+		if err := certs.Validate(cert); err != nil {
+			log.Warn("Skipping client certificate", "hosts", c.Hosts, sl.Err(err))
+			continue
+		}
+		list = append(list, cert)
+	}
+
+	return list
+}
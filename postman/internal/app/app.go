@@ -2,226 +2,353 @@ package app
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
-	"postman/internal/lib/httperrors"
+	"postman/internal/cli"
+	"postman/internal/client"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/curl"
+	"postman/internal/lib/headers"
+	"postman/internal/lib/jq"
+	"postman/internal/lib/shellwords"
+	"postman/internal/render"
+	"strconv"
 	"strings"
-	"time"
 )
 
+// maxLineSize is the longest line the prompt accepts.
+const maxLineSize = 16 << 20
+
 type App struct {
-	log *slog.Logger
+	log          *slog.Logger
+	requests     service.IRequestsService
+	commands     *cli.CLI
+	collections  service.ICollectionsService
+	environments service.IEnvironmentsService
+	renderer     *render.Renderer
+	scanner      *bufio.Scanner
+	out          io.Writer
+
+	// headers are kept between requests so that per-session headers
+	// (tenant, correlation id) don't have to be typed every time.
+	headers []models.Header
+	// raw prints response bodies as received, toggled by ":raw".
+	raw bool
+	// last is the last response printed, which ":jq" filters.
+	last *models.Response
 }
 
-func New(log *slog.Logger) *App {
+func New(
+	log *slog.Logger,
+	requests service.IRequestsService,
+	commands *cli.CLI,
+	collections service.ICollectionsService,
+	environments service.IEnvironmentsService,
+) *App {
+	// pasted bodies can be longer than the default 64 KiB line limit
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
 	return &App{
-		log: log,
+		log:          log,
+		requests:     requests,
+		commands:     commands,
+		collections:  collections,
+		environments: environments,
+		renderer:     render.New(os.Stdout),
+		scanner:      scanner,
+		out:          os.Stdout,
 	}
 }
 
+// Run starts the prompt loop. It returns when stdin is closed.
+// A line starting with ":" at the method prompt runs a command of the
+// non-interactive mode, e.g. ":collection list". ":raw" turns
+// pretty-printing of response bodies off and back on and ":jq FILTER"
+// filters the last response.
 func (a *App) Run() {
-	var method string
-	var url string
-	scanner := bufio.NewScanner(os.Stdin)
-	client := http.Client{
-		Timeout: time.Duration(5 * time.Second),
-	}
-
 	for {
-		fmt.Print("Enter method: ")
-		_ = scanner.Scan()
-		method = scanner.Text()
-
-		fmt.Print("Enter url: ")
-		_ = scanner.Scan()
-		url = scanner.Text()
-
-		switch strings.ToUpper(method) {
-		case "GET":
-			resp, err := client.Get(url)
-			if err != nil {
-				fmt.Println("Error sending request")
-				fmt.Println("Error:", err.Error())
-
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+		line, ok := a.prompt(a.methodPrompt())
+		if !ok {
+			return
+		}
 
-			if resp.StatusCode >= 399 {
-				httperrors.ErrorHandler(resp.StatusCode)
+		if command, ok := strings.CutPrefix(strings.TrimSpace(line), ":"); ok {
+			a.runCommand(command)
+			continue
+		}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
+		var request models.Request
+		if strings.HasPrefix(strings.TrimSpace(line), "curl ") {
+			request, ok = a.parseCurl(line)
+			if !ok {
+				continue
 			}
+		} else {
+			request, ok = a.readRequest(line)
+			if !ok {
+				return
+			}
+		}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading response body")
-				fmt.Println("Error:", err.Error())
+		a.send(request)
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+		path, ok := a.prompt("Save as collection/[folder/]name (empty to continue): ")
+		if !ok {
+			return
+		}
+		if path = strings.TrimSpace(path); path != "" {
+			a.save(path, request)
+		}
+	}
+}
 
-			var jsonData interface{}
-			if err := json.Unmarshal(body, &jsonData); err != nil {
-				fmt.Println("Error parsing JSON:", err)
-			} else {
-				formattedJSON, err := json.MarshalIndent(jsonData, "", "  ")
-				if err != nil {
-					fmt.Println("Error formatting JSON:", err)
-				} else {
-					fmt.Println(string(formattedJSON))
-				}
-			}
+func (a *App) methodPrompt() string {
+	environment, err := a.environments.ActiveEnvironment(context.Background())
+	if err != nil || environment.Name == "" {
+		return "Enter method (or :command, :help): "
+	}
 
-			fmt.Println("Press Enter to exit...")
-			bufio.NewReader(os.Stdin).ReadString('\n')
+	return fmt.Sprintf("[%s] Enter method (or :command, :help): ", environment.Name)
+}
 
-		case "POST":
-			fmt.Println("Enter request body")
-			_ = scanner.Scan()
-			req_body := scanner.Text()
-			resp, err := client.Post(url, "application/json", bytes.NewBuffer([]byte(req_body)))
-			if err != nil {
-				fmt.Println("Error sending request")
-				fmt.Println("Error:", err.Error())
+func (a *App) runCommand(line string) {
+	// the filter is taken as typed, its quotes being part of it
+	if filter, ok := strings.CutPrefix(line, "jq"); ok && (filter == "" || filter[0] == ' ') {
+		a.filter(filter)
+		return
+	}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+	args, err := shellwords.Split(line)
+	if err != nil {
+		fmt.Fprintln(a.out, "Error:", err.Error())
+		return
+	}
 
-			if resp.StatusCode >= 399 {
-				httperrors.ErrorHandler(resp.StatusCode)
+	if len(args) == 1 && args[0] == "raw" {
+		a.raw = !a.raw
+		a.renderer.SetRaw(a.raw)
+		a.commands.SetRaw(a.raw)
+		if a.raw {
+			fmt.Fprintln(a.out, "Bodies are printed as received")
+		} else {
+			fmt.Fprintln(a.out, "Bodies are pretty-printed")
+		}
+		return
+	}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+	a.commands.Run(args)
+	if resp, ok := a.commands.LastResponse(); ok {
+		a.last = &resp
+	}
+}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading response body")
-				fmt.Println("Error:", err.Error())
+// filter prints the results of a jq filter applied to the last response.
+// Quotes around the whole filter, as in a shell, are dropped.
+func (a *App) filter(filter string) {
+	filter = strings.TrimSpace(filter)
+	if len(filter) >= 2 && filter[0] == '\'' && filter[len(filter)-1] == '\'' {
+		filter = filter[1 : len(filter)-1]
+	}
+	if filter == "" {
+		fmt.Fprintln(a.out, "Usage: :jq FILTER, e.g. :jq '.[] | select(.id > 10) | .login'")
+		return
+	}
+	if a.last == nil {
+		fmt.Fprintln(a.out, "Error: no response to filter yet")
+		return
+	}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+	query, err := jq.Compile(filter)
+	if err != nil {
+		fmt.Fprintln(a.out, "Error:", err.Error())
+		return
+	}
+	if err := a.renderer.Filter(query, a.last.Body); err != nil {
+		fmt.Fprintln(a.out, "Error:", err.Error())
+	}
+}
 
-			var jsonData interface{}
-			if err := json.Unmarshal(body, &jsonData); err != nil {
-				fmt.Println("Error parsing JSON:", err)
-			} else {
-				formattedJSON, err := json.MarshalIndent(jsonData, "", "  ")
-				if err != nil {
-					fmt.Println("Error formatting JSON:", err)
-				} else {
-					fmt.Println(string(formattedJSON))
-				}
-			}
+// parseCurl reads a pasted curl command, including backslash continued lines.
+func (a *App) parseCurl(line string) (models.Request, bool) {
+	command := line
+	for strings.HasSuffix(strings.TrimSpace(command), "\\") {
+		next, ok := a.prompt("> ")
+		if !ok {
+			break
+		}
+		command += "\n" + next
+	}
 
-			fmt.Println("Press Enter to exit...")
-			bufio.NewReader(os.Stdin).ReadString('\n')
-
-		case "PUT":
-			fmt.Println("Enter request body")
-			_ = scanner.Scan()
-			req_body := scanner.Text()
-			req, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer([]byte(req_body)))
-			resp, err := client.Do(req)
-			if err != nil {
-				fmt.Println("Error sending request")
-				fmt.Println("Error:", err.Error())
-
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+	request, warnings, err := curl.Parse(strings.TrimSpace(command))
+	for _, w := range warnings {
+		fmt.Fprintln(a.out, "Warning:", w)
+	}
+	if err != nil {
+		fmt.Fprintln(a.out, "Error:", err.Error())
+		return models.Request{}, false
+	}
 
-			if resp.StatusCode >= 399 {
-				httperrors.ErrorHandler(resp.StatusCode)
+	return request, true
+}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+func (a *App) save(path string, request models.Request) {
+	saved := models.SavedRequest{Request: request}
+	if err := a.collections.SaveRequest(context.Background(), path, saved); err != nil {
+		fmt.Fprintln(a.out, "Error saving request:", err.Error())
+		return
+	}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading response body")
-				fmt.Println("Error:", err.Error())
+	fmt.Fprintln(a.out, "Saved as", path)
+}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+func (a *App) readRequest(method string) (models.Request, bool) {
+	var request models.Request
+	request.Method = client.NormalizeMethod(method)
 
-			var jsonData interface{}
-			if err := json.Unmarshal(body, &jsonData); err != nil {
-				fmt.Println("Error parsing JSON:", err)
-			} else {
-				formattedJSON, err := json.MarshalIndent(jsonData, "", "  ")
-				if err != nil {
-					fmt.Println("Error formatting JSON:", err)
-				} else {
-					fmt.Println(string(formattedJSON))
-				}
-			}
+	url, ok := a.prompt("Enter url: ")
+	if !ok {
+		return request, false
+	}
+	request.URL, request.Query = client.SplitQuery(strings.TrimSpace(url))
+	if !a.editParams(&request) {
+		return request, false
+	}
 
-			fmt.Println("Press Enter to exit...")
-			bufio.NewReader(os.Stdin).ReadString('\n')
+	if !a.editHeaders() {
+		return request, false
+	}
+	request.Headers = append([]models.Header(nil), a.headers...)
 
-		case "DELETE":
-			fmt.Println("DELETE request")
+	if hasBody(request.Method) && !a.readBody(&request) {
+		return request, false
+	}
 
-			req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	return request, true
+}
 
-			resp, err := client.Do(req)
-			if err != nil {
-				fmt.Println("Error sending request")
-				fmt.Println("Error:", err.Error())
+// editHeaders lets the user add, edit and remove request headers.
+// It returns false when stdin is closed.
+func (a *App) editHeaders() bool {
+	for {
+		a.printHeaders()
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+		line, ok := a.prompt(`Enter header ("Key: Value" to add, "edit N Key: Value", "del N", empty to finish): `)
+		if !ok {
+			return false
+		}
 
-			if resp.StatusCode >= 399 {
-				httperrors.ErrorHandler(resp.StatusCode)
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return true
+		}
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+		if err := a.applyHeaderCommand(line); err != nil {
+			fmt.Fprintln(a.out, "Error:", err.Error())
+		}
+	}
+}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading response body")
-				fmt.Println("Error:", err.Error())
+func (a *App) applyHeaderCommand(line string) error {
+	command, rest, _ := strings.Cut(line, " ")
 
-				fmt.Println("Press Enter to exit...")
-				bufio.NewReader(os.Stdin).ReadString('\n')
-			}
+	switch command {
+	case "del":
+		i, err := a.headerIndex(rest)
+		if err != nil {
+			return err
+		}
+		a.headers = append(a.headers[:i], a.headers[i+1:]...)
 
-			var jsonData interface{}
-			if err := json.Unmarshal(body, &jsonData); err != nil {
-				fmt.Println("Error parsing JSON:", err)
-			} else {
-				formattedJSON, err := json.MarshalIndent(jsonData, "", "  ")
-				if err != nil {
-					fmt.Println("Error formatting JSON:", err)
-				} else {
-					fmt.Println(string(formattedJSON))
-				}
-			}
+	case "edit":
+		n, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		i, err := a.headerIndex(n)
+		if err != nil {
+			return err
+		}
+		header, err := headers.Parse(value)
+		if err != nil {
+			return err
+		}
+		a.headers[i] = header
 
-			fmt.Println("Press Enter to exit...")
-			bufio.NewReader(os.Stdin).ReadString('\n')
+	default:
+		header, err := headers.Parse(line)
+		if err != nil {
+			return err
+		}
+		a.headers = append(a.headers, header)
+	}
 
-		default:
-			fmt.Println("Method not allowed")
+	return nil
+}
+
+func (a *App) headerIndex(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > len(a.headers) {
+		return 0, fmt.Errorf("no header with number %q", s)
+	}
 
-			fmt.Println("Press Enter to exit...")
-			bufio.NewReader(os.Stdin).ReadString('\n')
+	return n - 1, nil
+}
+
+func (a *App) printHeaders() {
+	if len(a.headers) == 0 {
+		fmt.Fprintln(a.out, "Headers: none")
+		return
+	}
+
+	fmt.Fprintln(a.out, "Headers:")
+	for i, h := range a.headers {
+		fmt.Fprintf(a.out, "  %d. %s\n", i+1, headers.String(h))
+	}
+}
+
+// send sends the request as typed, so that Send substitutes the
+// variables once and keeps the placeholders, not the secrets, in the
+// history and HAR. The URL printed is the one sent, dynamic variables
+// such as {{$guid}} included.
+func (a *App) send(request models.Request) {
+	exchange, err := a.requests.Send(context.Background(), request, models.Scope{})
+	if len(exchange.Unresolved) > 0 {
+		fmt.Fprintln(a.out, "Warning: unresolved variables:", strings.Join(exchange.Unresolved, ", "))
+	}
+	if exchange.Request.URL != "" {
+		if u, err := client.URL(exchange.Request); err == nil {
+			fmt.Fprintln(a.out, "Sent", exchange.Request.Method, u.String())
 		}
 	}
+	if err != nil {
+		fmt.Fprintln(a.out, "Error sending request")
+		fmt.Fprintln(a.out, "Error:", err.Error())
+		return
+	}
+
+	a.renderer.Response(exchange.Response)
+	a.last = &exchange.Response
+}
+
+func (a *App) prompt(text string) (string, bool) {
+	fmt.Fprint(a.out, text)
+	if !a.scanner.Scan() {
+		return "", false
+	}
+
+	return a.scanner.Text(), true
+}
+
+// hasBody reports whether the prompt should ask for a request body.
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete,
+		http.MethodOptions, http.MethodTrace, http.MethodConnect:
+		return false
+	}
+
+	return true
 }
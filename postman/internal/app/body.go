@@ -0,0 +1,197 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"postman/internal/domain/models"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/shellwords"
+	"strings"
+)
+
+const bodyHelp = `Body input:
+  TEXT               a single line raw body
+  <<END              raw body of several lines, ended by a line with END
+  :edit              write the raw body in $VISUAL or $EDITOR
+  :type TYPE         set the content type of the raw or binary body, then ask again
+  :form              x-www-form-urlencoded fields, one key=value per line
+  :multipart         multipart/form-data fields, one ` + bodies.Syntax + ` per line
+  :file PATH         send the file as a binary body
+  empty              no body`
+
+// readBody asks for the body of the request in one of the body modes.
+// It returns false when stdin is closed.
+func (a *App) readBody(request *models.Request) bool {
+	for {
+		line, ok := a.prompt("Enter request body (empty for none, :help for body modes): ")
+		if !ok {
+			return false
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch {
+		case command == ":help":
+			fmt.Fprintln(a.out, bodyHelp)
+
+		case command == ":type":
+			request.ContentType = arg
+			fmt.Fprintln(a.out, "Content type:", arg)
+
+		case command == ":form" || command == ":multipart":
+			request.BodyMode = models.BodyURLEncoded
+			if command == ":multipart" {
+				request.BodyMode = models.BodyFormData
+			}
+			fields, ok := a.readFields(request.BodyMode)
+			if !ok {
+				return false
+			}
+			request.Form = fields
+			return true
+
+		case command == ":file":
+			if arg == "" {
+				fmt.Fprintln(a.out, "Error: :file needs a path")
+				continue
+			}
+			if _, err := os.Stat(arg); err != nil {
+				fmt.Fprintln(a.out, "Error:", err.Error())
+				continue
+			}
+			request.BodyMode = models.BodyBinary
+			request.BodyFile = arg
+			return true
+
+		case command == ":edit":
+			body, err := a.edit(request.Body, request.ContentType)
+			if err != nil {
+				fmt.Fprintln(a.out, "Error:", err.Error())
+				continue
+			}
+			request.Body = body
+			return true
+
+		case strings.HasPrefix(command, "<<") && arg == "":
+			body, ok := a.readLines(strings.TrimPrefix(command, "<<"))
+			if !ok {
+				return false
+			}
+			request.Body = body
+			return true
+
+		default:
+			request.Body = line
+			return true
+		}
+	}
+}
+
+// readLines reads a body until a line with the terminator, "END" when
+// none is given.
+func (a *App) readLines(terminator string) (string, bool) {
+	if terminator == "" {
+		terminator = "END"
+	}
+
+	var lines []string
+	for {
+		line, ok := a.prompt("> ")
+		if !ok {
+			return "", false
+		}
+		if line == terminator {
+			return strings.Join(lines, "\n"), true
+		}
+		lines = append(lines, line)
+	}
+}
+
+// readFields reads form fields until an empty line.
+func (a *App) readFields(mode string) ([]models.FormField, bool) {
+	text := "Enter field key=value (empty to finish): "
+	if mode == models.BodyFormData {
+		text = "Enter field " + bodies.Syntax + " (empty to finish): "
+	}
+
+	var fields []models.FormField
+	for {
+		line, ok := a.prompt(text)
+		if !ok {
+			return nil, false
+		}
+		if strings.TrimSpace(line) == "" {
+			return fields, true
+		}
+
+		var field models.FormField
+		if mode == models.BodyFormData {
+			var err error
+			if field, err = bodies.ParseField(line); err != nil {
+				fmt.Fprintln(a.out, "Error:", err.Error())
+				continue
+			}
+		} else {
+			field.Key, field.Value, _ = strings.Cut(line, "=")
+		}
+		fields = append(fields, field)
+	}
+}
+
+// edit opens the body in the editor of $VISUAL or $EDITOR, vi when
+// neither is set, and returns the saved text.
+func (a *App) edit(body, contentType string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args, err := shellwords.Split(editor)
+	if err != nil || len(args) == 0 {
+		return "", fmt.Errorf("invalid editor %q", editor)
+	}
+
+	// the extension lets the editor pick the syntax highlighting
+	pattern := "postman-body-*.txt"
+	if contentType == "" || strings.Contains(contentType, "json") {
+		pattern = "postman-body-*.json"
+	} else if strings.Contains(contentType, "xml") {
+		pattern = "postman-body-*.xml"
+	}
+
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 {
+		return "", errors.New("the body is empty")
+	}
+
+	// editors add a final newline that is rarely part of the body
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"postman/internal/domain/models"
+	"postman/internal/lib/pathparams"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// editParams lets the user add, disable, reorder and remove query
+// parameters and set path parameters. It returns false when stdin is
+// closed.
+func (a *App) editParams(request *models.Request) bool {
+	for {
+		a.printParams(*request)
+
+		line, ok := a.prompt(`Enter query parameter ("key=value" to add, "off N", "on N", "del N", "move N M", ":name=value" for a path parameter, empty to finish): `)
+		if !ok {
+			return false
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return true
+		}
+
+		if err := applyParamCommand(request, line); err != nil {
+			fmt.Fprintln(a.out, "Error:", err.Error())
+		}
+	}
+}
+
+func applyParamCommand(request *models.Request, line string) error {
+	if path, ok := strings.CutPrefix(line, ":"); ok {
+		key, value, _ := strings.Cut(path, "=")
+		if !slices.Contains(pathparams.Names(request.URL), key) {
+			return fmt.Errorf("the URL has no path parameter %q", key)
+		}
+		request.PathParams = pathparams.Set(request.PathParams, key, value)
+		return nil
+	}
+
+	command, rest, _ := strings.Cut(line, " ")
+	query := request.Query
+
+	switch command {
+	case "off", "on":
+		i, err := paramIndex(query, rest)
+		if err != nil {
+			return err
+		}
+		query[i].Disabled = command == "off"
+
+	case "del":
+		i, err := paramIndex(query, rest)
+		if err != nil {
+			return err
+		}
+		request.Query = append(query[:i], query[i+1:]...)
+
+	case "move":
+		from, to, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		i, err := paramIndex(query, from)
+		if err != nil {
+			return err
+		}
+		j, err := paramIndex(query, to)
+		if err != nil {
+			return err
+		}
+		param := query[i]
+		query = append(query[:i], query[i+1:]...)
+		request.Query = append(query[:j], append([]models.QueryParam{param}, query[j:]...)...)
+
+	default:
+		key, value, found := strings.Cut(line, "=")
+		if !found || key == "" {
+			return fmt.Errorf("expected key=value, got %q", line)
+		}
+		request.Query = append(query, models.QueryParam{Key: key, Value: value})
+	}
+
+	return nil
+}
+
+func paramIndex(query []models.QueryParam, s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > len(query) {
+		return 0, fmt.Errorf("no query parameter with number %q", s)
+	}
+
+	return n - 1, nil
+}
+
+func (a *App) printParams(request models.Request) {
+	if len(request.Query) == 0 {
+		fmt.Fprintln(a.out, "Query parameters: none")
+	} else {
+		fmt.Fprintln(a.out, "Query parameters:")
+		for i, q := range request.Query {
+			state := ""
+			if q.Disabled {
+				state = " (off)"
+			}
+			fmt.Fprintf(a.out, "  %d. %s=%s%s\n", i+1, q.Key, q.Value, state)
+		}
+	}
+
+	names := pathparams.Names(request.URL)
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintln(a.out, "Path parameters:")
+	for _, name := range names {
+		fmt.Fprintf(a.out, "  :%s = %s\n", name, pathparams.Describe(request.PathParams, name))
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/domain/models"
+	"postman/internal/lib/auth"
+	"strings"
+)
+
+const authUsage = `auth PATH [TYPE] [-user U] [-password P] [-token T] [-key K] [-value V] [-in header|query]
+                                [-grant G] [-token-url URL] [-auth-url URL] [-client-id ID] [-client-secret S] [-scope S] [-redirect-port N]
+                                show or set the auth of a collection, folder or request,
+                                TYPE is none, inherit, basic, bearer, apikey, digest or oauth2`
+
+func (c *CLI) auth(args []string) int {
+	fs := c.flagSet("auth")
+	var a models.Auth
+	fs.StringVar(&a.Username, "user", "", "basic and digest user name")
+	fs.StringVar(&a.Password, "password", "", "basic and digest password, e.g. {{password}}")
+	fs.StringVar(&a.Token, "token", "", "bearer token, e.g. {{token}}")
+	fs.StringVar(&a.Key, "key", "", "API key header or query parameter name")
+	fs.StringVar(&a.Value, "value", "", "API key value")
+	fs.StringVar(&a.In, "in", models.APIKeyInHeader, "API key location, header or query")
+	fs.StringVar(&a.GrantType, "grant", models.GrantClientCredentials, "OAuth 2.0 grant: client_credentials, password or authorization_code")
+	fs.StringVar(&a.TokenURL, "token-url", "", "OAuth 2.0 token endpoint")
+	fs.StringVar(&a.AuthURL, "auth-url", "", "OAuth 2.0 authorization endpoint, for authorization_code")
+	fs.StringVar(&a.ClientID, "client-id", "", "OAuth 2.0 client id")
+	fs.StringVar(&a.ClientSecret, "client-secret", "", "OAuth 2.0 client secret, e.g. {{clientSecret}}")
+	fs.StringVar(&a.Scope, "scope", "", "OAuth 2.0 scopes, space separated")
+	fs.IntVar(&a.RedirectPort, "redirect-port", 0, "loopback redirect port for authorization_code, random when 0")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	switch len(positional) {
+	case 1:
+		return c.authShow(ctx, positional[0])
+	case 2:
+	default:
+		fmt.Fprintln(c.errOut, "Usage: postman "+authUsage)
+		return ExitUsage
+	}
+
+	a.Type = strings.ToLower(positional[1])
+	if a.Type != models.AuthAPIKey {
+		a.In = ""
+	}
+	if a.Type != models.AuthOAuth2 {
+		a.GrantType = ""
+	}
+	if err := auth.Validate(&a); err != nil {
+		fmt.Fprintln(c.errOut, "auth:", err)
+		return ExitUsage
+	}
+
+	var value *models.Auth
+	if a.Type != models.AuthInherit {
+		value = &a
+	}
+	if err := c.collections.SetAuth(ctx, positional[0], value); err != nil {
+		return c.fail("auth", err)
+	}
+
+	return ExitOK
+}
+
+// authShow prints the auth of a request and where it comes from.
+func (c *CLI) authShow(ctx context.Context, path string) int {
+	if !strings.Contains(strings.Trim(path, "/"), "/") {
+		collection, err := c.collections.GetCollection(ctx, path)
+		if err != nil {
+			return c.fail("auth", err)
+		}
+		fmt.Fprintln(c.out, auth.Describe(collection.Auth))
+		return ExitOK
+	}
+
+	saved, err := c.collections.GetRequest(ctx, path)
+	if err != nil {
+		return c.fail("auth", err)
+	}
+	if !saved.Auth.Inherits() {
+		fmt.Fprintln(c.out, auth.Describe(saved.Auth))
+		return ExitOK
+	}
+
+	inherited, err := c.collections.InheritedAuth(ctx, path)
+	if err != nil {
+		return c.fail("auth", err)
+	}
+	if inherited == nil {
+		fmt.Fprintln(c.out, models.AuthNone+" (inherited)")
+		return ExitOK
+	}
+	fmt.Fprintln(c.out, auth.Describe(inherited)+" (inherited)")
+
+	return ExitOK
+}
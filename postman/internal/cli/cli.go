@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/jq"
+	"postman/internal/render"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exit codes returned by Run. ExitUsage is EX_USAGE of sysexits.h
+// rather than 2, which is also the code of a Go program that panics.
+const (
+	ExitOK          = 0
+	ExitTransport   = 1
+	ExitUsage       = 64
+	ExitFailure     = 3
+	ExitClientError = 4
+	ExitServerError = 5
+)
+
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+type CLI struct {
+	log            *slog.Logger
+	requests       service.IRequestsService
+	collections    service.ICollectionsService
+	environments   service.IEnvironmentsService
+	runner         service.IRunnerService
+	scripts        service.IScriptsService
+	oauth2         service.IOAuth2Service
+	cookies        service.ICookiesService
+	historyService service.IHistoryService
+	renderer       *render.Renderer
+	in             io.Reader
+	out            io.Writer
+	errOut         io.Writer
+	commands       map[string]command
+	// raw is the default of the -raw flag.
+	raw bool
+	// filter is set by the -jq flag of the command being run.
+	filter *jq.Query
+	// last is the response printed by the command being run, if any.
+	last *models.Response
+}
+
+func New(
+	log *slog.Logger,
+	requests service.IRequestsService,
+	collections service.ICollectionsService,
+	environments service.IEnvironmentsService,
+	runner service.IRunnerService,
+	scripts service.IScriptsService,
+	oauth2 service.IOAuth2Service,
+	cookies service.ICookiesService,
+	history service.IHistoryService,
+) *CLI {
+	c := &CLI{
+		log:            log,
+		requests:       requests,
+		collections:    collections,
+		environments:   environments,
+		runner:         runner,
+		scripts:        scripts,
+		oauth2:         oauth2,
+		cookies:        cookies,
+		historyService: history,
+		renderer:       render.New(os.Stdout),
+		in:             os.Stdin,
+		out:            os.Stdout,
+		errOut:         os.Stderr,
+	}
+
+	c.commands = map[string]command{
+		"send":       {usage: "send [flags] [-har FILE] URL", run: c.send},
+		"collection": {usage: collectionUsage, run: c.collection},
+		"env":        {usage: envUsage, run: c.env},
+		"curl":       {usage: curlUsage, run: c.curl},
+		"import":     {usage: importUsage, run: c.importCollection},
+		"har":        {usage: harUsage, run: c.har},
+		"run":        {usage: runUsage, run: c.run},
+		"auth":       {usage: authUsage, run: c.auth},
+		"oauth":      {usage: oauthUsage, run: c.oauth},
+		"cookie":     {usage: cookieUsage, run: c.cookie},
+		"history":    {usage: historyUsage, run: c.history},
+	}
+
+	return c
+}
+
+// Run executes the subcommand in args[0] and returns the process exit code.
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		return ExitUsage
+	}
+
+	cmd, ok := c.commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.errOut, "unknown command %q\n", args[0])
+		c.usage()
+		return ExitUsage
+	}
+
+	c.renderer.SetRaw(c.raw)
+	c.filter, c.last = nil, nil
+
+	return cmd.run(args[1:])
+}
+
+// LastResponse returns the response printed by the last command run.
+func (c *CLI) LastResponse() (models.Response, bool) {
+	if c.last == nil {
+		return models.Response{}, false
+	}
+
+	return *c.last, true
+}
+
+// SetRaw sets whether commands print bodies as received when -raw isn't
+// given, which the interactive mode toggles.
+func (c *CLI) SetRaw(raw bool) {
+	c.raw = raw
+}
+
+func (c *CLI) usage() {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(c.errOut, "Usage: postman [--config path] <command> [flags]")
+	fmt.Fprintln(c.errOut, "Commands:")
+	for _, name := range names {
+		fmt.Fprintln(c.errOut, "  postman "+c.commands[name].usage)
+	}
+	fmt.Fprintln(c.errOut, "Run without a command to start the interactive mode.")
+}
+
+// fail reports a failed command and returns ExitFailure.
+func (c *CLI) fail(command string, err error) int {
+	fmt.Fprintf(c.errOut, "%s: %s\n", command, err)
+	return ExitFailure
+}
+
+func (c *CLI) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+
+	return fs
+}
+
+// bindRaw adds the -raw flag, which turns off pretty-printing of bodies.
+func (c *CLI) bindRaw(fs *flag.FlagSet) {
+	fs.BoolFunc("raw", "print the body as received instead of pretty-printed", func(s string) error {
+		raw, err := strconv.ParseBool(s)
+		c.renderer.SetRaw(raw)
+		return err
+	})
+}
+
+// bindFilter adds the -jq flag, which prints the results of a filter
+// instead of the body. An invalid filter prints the parse error without
+// the usage text.
+func (c *CLI) bindFilter(fs *flag.FlagSet) {
+	fs.Func("jq", "print the results of a jq `filter` applied to the JSON body instead of the body", func(s string) error {
+		query, err := jq.Compile(s)
+		if err != nil {
+			fs.Usage = func() {}
+			return err
+		}
+		c.filter = query
+		return nil
+	})
+}
+
+// parseInterspersed parses flags that may appear after positional
+// arguments, e.g. "send URL -H ...", and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// boolPtrFlag is a bool flag that stays nil when not given, so an unset
+// flag inherits instead of overriding.
+type boolPtrFlag struct {
+	value *bool
+}
+
+func (b *boolPtrFlag) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *boolPtrFlag) Set(value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+func (b *boolPtrFlag) IsBoolFlag() bool {
+	return true
+}
+
+// intsFlag is a repeatable int flag.
+type intsFlag []int
+
+func (s *intsFlag) String() string {
+	return fmt.Sprint([]int(*s))
+}
+
+func (s *intsFlag) Set(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, n)
+	return nil
+}
+
+func (s intsFlag) has(n int) bool {
+	return slices.Contains(s, n)
+}
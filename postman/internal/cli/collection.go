@@ -0,0 +1,540 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/auth"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/extract"
+	"postman/internal/lib/headers"
+	"postman/internal/lib/pathparams"
+	"slices"
+	"strings"
+)
+
+const collectionUsage = `collection <subcommand>
+      list                      list collections
+      show NAME                 print the folders and requests of a collection
+      create NAME [-description D]
+      delete NAME
+      save PATH [request flags] [-description D] [-assert EXPR] [-extract VAR=EXPR] URL
+                                save a request, PATH is collection/[folder/...]name
+      edit PATH [-X M] [-url U] [-H "K: V"] [-set-header "K: V"] [-del-header K] [-description D]
+                [-q K=V] [-del-query K] [-disable-query K] [-enable-query K] [-p K=V]
+                [-d BODY | -form K=V | -F K=V | -F K=@FILE | -binary FILE] [-content-type T]
+                [-assert EXPR] [-del-assert N] [-clear-asserts] [-extract VAR=EXPR] [-del-extract VAR]
+                [-pre-request SCRIPT] [-post-response SCRIPT]
+                                scripts are Starlark source or @file, an empty value removes them
+      options PATH [-timeout D] [-no-follow] [-max-redirects N] [-proxy URL] [-no-proxy LIST]
+                [-k] [-cacert FILE] [-http2=false] [-reset]
+                                show or set the transport options of a collection or request
+      remove PATH               remove a request or folder
+      send PATH [-i] [-raw] [-jq FILTER] [-e ENV] [-var k=v] [-har FILE] [-assert EXPR] [-print-url]
+                                send a saved request and check its assertions`
+
+func (c *CLI) collection(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+collectionUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return c.collectionList()
+	case "show":
+		return c.collectionShow(args[1:])
+	case "create":
+		return c.collectionCreate(args[1:])
+	case "delete":
+		return c.collectionDelete(args[1:])
+	case "save":
+		return c.collectionSave(args[1:])
+	case "edit":
+		return c.collectionEdit(args[1:])
+	case "options":
+		return c.collectionOptions(args[1:])
+	case "remove":
+		return c.collectionRemove(args[1:])
+	case "send":
+		return c.collectionSend(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "collection: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+collectionUsage)
+	return ExitUsage
+}
+
+func (c *CLI) collectionList() int {
+	collections, err := c.collections.GetCollections(context.Background())
+	if err != nil {
+		return c.fail("collection list", err)
+	}
+
+	if len(collections) == 0 {
+		fmt.Fprintln(c.out, "No collections")
+		return ExitOK
+	}
+
+	for _, collection := range collections {
+		fmt.Fprintf(c.out, "%s\t%d requests", collection.Name, countRequests(collection.Folders, collection.Requests))
+		if collection.Description != "" {
+			fmt.Fprintf(c.out, "\t%s", collection.Description)
+		}
+		fmt.Fprintln(c.out)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionShow(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "collection show: exactly one collection name is required")
+		return ExitUsage
+	}
+
+	collection, err := c.collections.GetCollection(context.Background(), args[0])
+	if err != nil {
+		return c.fail("collection show", err)
+	}
+
+	fmt.Fprintln(c.out, collection.Name)
+	if collection.Description != "" {
+		fmt.Fprintln(c.out, "  "+collection.Description)
+	}
+	if collection.Auth != nil {
+		fmt.Fprintln(c.out, "  auth: "+auth.Describe(collection.Auth))
+	}
+	if collection.Options != nil {
+		fmt.Fprintln(c.out, "  options: "+describeOptions(*collection.Options))
+	}
+	printTree(c.out, collection.Folders, collection.Requests, "  ")
+
+	return ExitOK
+}
+
+func (c *CLI) collectionCreate(args []string) int {
+	fs := c.flagSet("collection create")
+	description := fs.String("description", "", "collection description")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "collection create: exactly one collection name is required")
+		return ExitUsage
+	}
+
+	if _, err := c.collections.CreateCollection(context.Background(), positional[0], *description); err != nil {
+		return c.fail("collection create", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionDelete(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "collection delete: exactly one collection name is required")
+		return ExitUsage
+	}
+
+	if err := c.collections.DeleteCollection(context.Background(), args[0]); err != nil {
+		return c.fail("collection delete", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionSave(args []string) int {
+	fs := c.flagSet("collection save")
+
+	var (
+		rf         requestFlags
+		checks     assertFlags
+		extractors extractFlags
+	)
+	rf.bind(fs)
+	checks.bind(fs)
+	extractors.bind(fs)
+	description := fs.String("description", "", "request description")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 2 {
+		fmt.Fprintln(c.errOut, "collection save: PATH and URL are required")
+		return ExitUsage
+	}
+
+	request, err := c.buildRequest(&rf, positional[1])
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection save:", err)
+		return ExitUsage
+	}
+	list, err := checks.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection save:", err)
+		return ExitUsage
+	}
+	extractList, err := extractors.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection save:", err)
+		return ExitUsage
+	}
+
+	saved := models.SavedRequest{
+		Description: *description,
+		Assertions:  list,
+		Extractors:  extractList,
+		Request:     request,
+	}
+	if err := c.collections.SaveRequest(context.Background(), positional[0], saved); err != nil {
+		return c.fail("collection save", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionEdit(args []string) int {
+	fs := c.flagSet("collection edit")
+
+	var (
+		method, url, description           string
+		body                               bodyFlags
+		addHeaders, setHeaders, delHeaders stringsFlag
+		addQuery, delQuery                 stringsFlag
+		disableQuery, enableQuery          stringsFlag
+		pathParams                         stringsFlag
+		checks                             assertFlags
+		delAsserts                         intsFlag
+		extractors                         extractFlags
+		delExtracts                        stringsFlag
+	)
+	fs.StringVar(&method, "X", "", "new request method")
+	fs.StringVar(&url, "url", "", "new request URL")
+	body.bind(fs)
+	fs.StringVar(&description, "description", "", "new description")
+	fs.Var(&addHeaders, "H", `add header "Key: Value", repeatable`)
+	fs.Var(&setHeaders, "set-header", `replace all headers with the key by "Key: Value", repeatable`)
+	fs.Var(&delHeaders, "del-header", "remove all headers with the key, repeatable")
+	fs.Var(&addQuery, "q", "add query parameter key=value, repeatable")
+	fs.Var(&delQuery, "del-query", "remove all query parameters with the key, repeatable")
+	fs.Var(&disableQuery, "disable-query", "keep the query parameters with the key but do not send them, repeatable")
+	fs.Var(&enableQuery, "enable-query", "send the disabled query parameters with the key again, repeatable")
+	fs.Var(&pathParams, "p", "set path parameter key=value, an empty value takes the variable, repeatable")
+	checks.bind(fs)
+	fs.Var(&delAsserts, "del-assert", "remove the assertion with the number shown by collection show, repeatable")
+	clearAsserts := fs.Bool("clear-asserts", false, "remove all assertions")
+	extractors.bind(fs)
+	fs.Var(&delExtracts, "del-extract", "remove the extractor of the variable, repeatable")
+	preRequest := fs.String("pre-request", "", "Starlark pre-request script, @file to read it from a file")
+	postResponse := fs.String("post-response", "", "Starlark post-response script, @file to read it from a file")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "collection edit: exactly one PATH is required")
+		return ExitUsage
+	}
+	list, err := checks.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection edit:", err)
+		return ExitUsage
+	}
+	extractList, err := extractors.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection edit:", err)
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	saved, err := c.collections.GetRequest(ctx, positional[0])
+	if err != nil {
+		return c.fail("collection edit", err)
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if set["X"] {
+		saved.Method = client.NormalizeMethod(method)
+	}
+	if set["url"] {
+		saved.URL = url
+	}
+	if set["description"] {
+		saved.Description = description
+	}
+	// an empty -d removes the body
+	if set["d"] && !body.given() {
+		saved.Body, saved.BodyMode, saved.Form, saved.BodyFile = "", "", nil, ""
+	}
+	if err := c.applyBody(&body, &saved.Request); err != nil {
+		fmt.Fprintln(c.errOut, "collection edit:", err)
+		return ExitUsage
+	}
+
+	for _, key := range delHeaders {
+		saved.Headers = removeHeader(saved.Headers, key)
+	}
+	for _, h := range setHeaders {
+		header, err := headers.Parse(h)
+		if err != nil {
+			fmt.Fprintln(c.errOut, "collection edit:", err)
+			return ExitUsage
+		}
+		saved.Headers = append(removeHeader(saved.Headers, header.Key), header)
+	}
+	for _, h := range addHeaders {
+		header, err := headers.Parse(h)
+		if err != nil {
+			fmt.Fprintln(c.errOut, "collection edit:", err)
+			return ExitUsage
+		}
+		saved.Headers = append(saved.Headers, header)
+	}
+
+	for _, key := range delQuery {
+		saved.Query = slices.DeleteFunc(saved.Query, func(q models.QueryParam) bool { return q.Key == key })
+	}
+	for _, q := range addQuery {
+		key, value, _ := strings.Cut(q, "=")
+		saved.Query = append(saved.Query, models.QueryParam{Key: key, Value: value})
+	}
+	for i := range saved.Query {
+		switch {
+		case slices.Contains(disableQuery, saved.Query[i].Key):
+			saved.Query[i].Disabled = true
+		case slices.Contains(enableQuery, saved.Query[i].Key):
+			saved.Query[i].Disabled = false
+		}
+	}
+	for _, p := range pathParams {
+		key, value, _ := strings.Cut(p, "=")
+		saved.PathParams = pathparams.Set(saved.PathParams, key, value)
+	}
+
+	if set["pre-request"] {
+		if saved.PreRequest, err = c.readData(*preRequest); err != nil {
+			fmt.Fprintln(c.errOut, "collection edit: read pre-request script:", err)
+			return ExitUsage
+		}
+	}
+	if set["post-response"] {
+		if saved.PostResponse, err = c.readData(*postResponse); err != nil {
+			fmt.Fprintln(c.errOut, "collection edit: read post-response script:", err)
+			return ExitUsage
+		}
+	}
+
+	if *clearAsserts {
+		saved.Assertions = nil
+	}
+	if len(delAsserts) > 0 {
+		kept := saved.Assertions[:0:0]
+		for i, a := range saved.Assertions {
+			if !delAsserts.has(i + 1) {
+				kept = append(kept, a)
+			}
+		}
+		saved.Assertions = kept
+	}
+	saved.Assertions = append(saved.Assertions, list...)
+
+	// an extractor replaces the one of the same variable
+	for _, e := range extractList {
+		delExtracts = append(delExtracts, e.Variable)
+	}
+	kept := saved.Extractors[:0:0]
+	for _, e := range saved.Extractors {
+		if !slices.Contains(delExtracts, e.Variable) {
+			kept = append(kept, e)
+		}
+	}
+	saved.Extractors = append(kept, extractList...)
+
+	if err := c.collections.SaveRequest(ctx, positional[0], saved); err != nil {
+		return c.fail("collection edit", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionRemove(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "collection remove: exactly one PATH is required")
+		return ExitUsage
+	}
+
+	if err := c.collections.DeleteRequest(context.Background(), args[0]); err != nil {
+		return c.fail("collection remove", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) collectionSend(args []string) int {
+	fs := c.flagSet("collection send")
+	include := fs.Bool("i", false, "print status line, headers and timing before the body")
+	harPath := fs.String("har", "", "append the exchange to a HAR file")
+	printURL := fs.Bool("print-url", false, "print the resolved and encoded URL instead of sending the request")
+	c.bindRaw(fs)
+	c.bindFilter(fs)
+	var (
+		sf     scopeFlags
+		checks assertFlags
+	)
+	sf.bind(fs)
+	checks.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "collection send: exactly one PATH is required")
+		return ExitUsage
+	}
+	extra, err := checks.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "collection send:", err)
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	saved, err := c.savedRequest(ctx, positional[0])
+	if err != nil {
+		return c.fail("collection send", err)
+	}
+
+	scope := sf.scope()
+	scope.Defaults, err = c.collectionVariables(ctx, positional[0])
+	if err != nil {
+		return c.fail("collection send", err)
+	}
+
+	if *printURL {
+		return c.printURL("collection send", saved.Request, scope)
+	}
+	defer c.recordTo(*harPath)()
+
+	saved.Assertions = append(saved.Assertions, extra...)
+
+	return c.doSaved(saved, scope, *include)
+}
+
+// savedRequest returns a saved request with the auth and transport
+// options it inherits.
+func (c *CLI) savedRequest(ctx context.Context, path string) (models.SavedRequest, error) {
+	saved, err := c.collections.GetRequest(ctx, path)
+	if err != nil {
+		return models.SavedRequest{}, err
+	}
+
+	if saved.Auth.Inherits() {
+		if saved.Auth, err = c.collections.InheritedAuth(ctx, path); err != nil {
+			return models.SavedRequest{}, err
+		}
+	}
+
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	collection, err := c.collections.GetCollection(ctx, name)
+	if err != nil {
+		return models.SavedRequest{}, err
+	}
+	if collection.Options != nil {
+		saved.Options = saved.Options.Inherit(*collection.Options)
+	}
+
+	return saved, nil
+}
+
+// collectionVariables returns the variables of the collection a request path points into.
+func (c *CLI) collectionVariables(ctx context.Context, path string) (map[string]string, error) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	collection, err := c.collections.GetCollection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return collection.VariablesMap(), nil
+}
+
+func printTree(out io.Writer, folders []models.Folder, requests []models.SavedRequest, indent string) {
+	for _, f := range folders {
+		fmt.Fprintf(out, "%s%s/", indent, f.Name)
+		if f.Auth != nil {
+			fmt.Fprintf(out, "\tauth: %s", auth.Describe(f.Auth))
+		}
+		fmt.Fprintln(out)
+		printTree(out, f.Folders, f.Requests, indent+"  ")
+	}
+
+	for _, r := range requests {
+		fmt.Fprintf(out, "%s%s\t%s %s", indent, r.Name, r.Method, r.URL)
+		if r.Description != "" {
+			fmt.Fprintf(out, "\t# %s", r.Description)
+		}
+		fmt.Fprintln(out)
+		if r.Auth != nil {
+			fmt.Fprintf(out, "%s  auth: %s\n", indent, auth.Describe(r.Auth))
+		}
+		for _, q := range r.Query {
+			state := ""
+			if q.Disabled {
+				state = " (disabled)"
+			}
+			fmt.Fprintf(out, "%s  query %s=%s%s\n", indent, q.Key, q.Value, state)
+		}
+		for _, name := range pathparams.Names(r.URL) {
+			fmt.Fprintf(out, "%s  path :%s = %s\n", indent, name, pathparams.Describe(r.PathParams, name))
+		}
+		if r.HasBody() {
+			fmt.Fprintf(out, "%s  body: %s\n", indent, bodies.Describe(r.Request))
+		}
+		if r.Options != (models.Options{}) {
+			fmt.Fprintf(out, "%s  options: %s\n", indent, describeOptions(r.Options))
+		}
+		for i, a := range r.Assertions {
+			fmt.Fprintf(out, "%s  %d. assert %s\n", indent, i+1, assertions.String(a))
+		}
+		for _, e := range r.Extractors {
+			fmt.Fprintf(out, "%s  extract %s\n", indent, extract.String(e))
+		}
+		if r.PreRequest != "" {
+			fmt.Fprintf(out, "%s  pre-request script, %d lines\n", indent, strings.Count(strings.TrimRight(r.PreRequest, "\n"), "\n")+1)
+		}
+		if r.PostResponse != "" {
+			fmt.Fprintf(out, "%s  post-response script, %d lines\n", indent, strings.Count(strings.TrimRight(r.PostResponse, "\n"), "\n")+1)
+		}
+	}
+}
+
+func countRequests(folders []models.Folder, requests []models.SavedRequest) int {
+	n := len(requests)
+	for _, f := range folders {
+		n += countRequests(f.Folders, f.Requests)
+	}
+
+	return n
+}
+
+func removeHeader(hdrs []models.Header, key string) []models.Header {
+	kept := hdrs[:0:0]
+	for _, h := range hdrs {
+		if !strings.EqualFold(h.Key, key) {
+			kept = append(kept, h)
+		}
+	}
+
+	return kept
+}
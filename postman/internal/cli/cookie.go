@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"postman/internal/domain/models"
+	"postman/internal/lib/cookies"
+	serviceerrors "postman/internal/service"
+	"strings"
+	"time"
+)
+
+const cookieUsage = `cookie <subcommand> [-e ENV]
+      list [-domain D]          list the cookies of the environment jar
+      add NAME VALUE -domain D [-path P] [-expires T] [-secure] [-httponly]
+                                add or replace a cookie, a domain with a leading dot
+                                also matches subdomains, T is RFC 3339 or a duration
+      edit NAME -domain D [-path P] [-value V] [-expires T] [-secure] [-httponly]
+      delete NAME -domain D
+      clear [-domain D]         delete the cookies of a domain and its subdomains, or all`
+
+func (c *CLI) cookie(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+cookieUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return c.cookieList(args[1:])
+	case "add":
+		return c.cookieAdd(args[1:])
+	case "edit":
+		return c.cookieEdit(args[1:])
+	case "delete":
+		return c.cookieDelete(args[1:])
+	case "clear":
+		return c.cookieClear(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "cookie: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+cookieUsage)
+	return ExitUsage
+}
+
+func (c *CLI) cookieList(args []string) int {
+	fs := c.flagSet("cookie list")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	domain := fs.String("domain", "", "only cookies of this domain and its subdomains")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	list, err := c.cookies.GetCookies(context.Background(), *environment)
+	if err != nil {
+		return c.fail("cookie list", err)
+	}
+
+	filter := strings.ToLower(strings.TrimPrefix(*domain, "."))
+	shown := 0
+	for _, cookie := range list {
+		if filter != "" && cookie.Domain != filter && !strings.HasSuffix(cookie.Domain, "."+filter) {
+			continue
+		}
+		fmt.Fprintf(c.out, "%s\t%s\t%s=%s\t%s%s\n", cookieDomain(cookie), cookie.Path, cookie.Name, cookie.Value, cookieExpires(cookie), cookieFlags(cookie))
+		shown++
+	}
+	if shown == 0 {
+		fmt.Fprintln(c.out, "No cookies")
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) cookieAdd(args []string) int {
+	fs := c.flagSet("cookie add")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	domain := fs.String("domain", "", "cookie domain, .example.com also matches subdomains")
+	path := fs.String("path", "/", "cookie path")
+	expires := fs.String("expires", "", "expiry as RFC 3339 time or duration, a session cookie when empty")
+	secure := fs.Bool("secure", false, "send over https only")
+	httpOnly := fs.Bool("httponly", false, "mark as HttpOnly")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 2 || *domain == "" {
+		fmt.Fprintln(c.errOut, "cookie add: NAME, VALUE and -domain are required")
+		return ExitUsage
+	}
+
+	cookie := models.Cookie{
+		Name:     positional[0],
+		Value:    positional[1],
+		Path:     *path,
+		Secure:   *secure,
+		HttpOnly: *httpOnly,
+	}
+	if cookie.Domain, cookie.HostOnly, err = cookieDomainFlag(*domain); err != nil {
+		fmt.Fprintln(c.errOut, "cookie add:", err)
+		return ExitUsage
+	}
+	if cookie.Expires, err = parseExpires(*expires); err != nil {
+		fmt.Fprintln(c.errOut, "cookie add:", err)
+		return ExitUsage
+	}
+
+	if err := c.cookies.SetCookie(context.Background(), *environment, cookie); err != nil {
+		return c.fail("cookie add", err)
+	}
+
+	return ExitOK
+}
+
+// cookieEdit changes the flags given on the command line and keeps the
+// rest of the cookie.
+func (c *CLI) cookieEdit(args []string) int {
+	fs := c.flagSet("cookie edit")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	domain := fs.String("domain", "", "cookie domain")
+	path := fs.String("path", "", "cookie path, required when the name is used on several paths")
+	value := fs.String("value", "", "new value")
+	expires := fs.String("expires", "", "new expiry as RFC 3339 time or duration, empty for a session cookie")
+	secure := fs.Bool("secure", false, "send over https only")
+	httpOnly := fs.Bool("httponly", false, "mark as HttpOnly")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 || *domain == "" {
+		fmt.Fprintln(c.errOut, "cookie edit: NAME and -domain are required")
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	list, err := c.cookies.GetCookies(ctx, *environment)
+	if err != nil {
+		return c.fail("cookie edit", err)
+	}
+
+	host := strings.ToLower(strings.TrimPrefix(*domain, "."))
+	var matches []models.Cookie
+	for _, cookie := range list {
+		if cookie.Name == positional[0] && cookie.Domain == host && (*path == "" || cookie.Path == *path) {
+			matches = append(matches, cookie)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return c.fail("cookie edit", serviceerrors.ErrNotFound)
+	case 1:
+	default:
+		fmt.Fprintln(c.errOut, "cookie edit: the cookie is set on several paths, select one with -path")
+		return ExitUsage
+	}
+
+	cookie := matches[0]
+	var parseErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "value":
+			cookie.Value = *value
+		case "expires":
+			cookie.Expires, parseErr = parseExpires(*expires)
+		case "secure":
+			cookie.Secure = *secure
+		case "httponly":
+			cookie.HttpOnly = *httpOnly
+		}
+	})
+	if parseErr != nil {
+		fmt.Fprintln(c.errOut, "cookie edit:", parseErr)
+		return ExitUsage
+	}
+
+	if err := c.cookies.SetCookie(ctx, *environment, cookie); err != nil {
+		return c.fail("cookie edit", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) cookieDelete(args []string) int {
+	fs := c.flagSet("cookie delete")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	domain := fs.String("domain", "", "cookie domain")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 || *domain == "" {
+		fmt.Fprintln(c.errOut, "cookie delete: NAME and -domain are required")
+		return ExitUsage
+	}
+
+	if err := c.cookies.DeleteCookie(context.Background(), *environment, *domain, positional[0]); err != nil {
+		return c.fail("cookie delete", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) cookieClear(args []string) int {
+	fs := c.flagSet("cookie clear")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	domain := fs.String("domain", "", "only this domain and its subdomains")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if err := c.cookies.ClearCookies(context.Background(), *environment, *domain); err != nil {
+		return c.fail("cookie clear", err)
+	}
+
+	return ExitOK
+}
+
+// cookieDomainFlag converts a -domain value. A leading dot makes a domain
+// cookie, which cannot be set for a public suffix or an IP address.
+func cookieDomainFlag(domain string) (string, bool, error) {
+	hostOnly := !strings.HasPrefix(domain, ".")
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || strings.ContainsAny(domain, "/:") {
+		return "", false, fmt.Errorf("%w: %q", cookies.ErrInvalidDomain, domain)
+	}
+
+	if !hostOnly && (net.ParseIP(domain) != nil || cookies.IsPublicSuffix(domain)) {
+		return "", false, fmt.Errorf("%w: %q cannot have subdomain cookies", cookies.ErrInvalidDomain, domain)
+	}
+
+	return domain, hostOnly, nil
+}
+
+// parseExpires accepts an RFC 3339 time or a duration from now. An
+// empty value means a session cookie.
+func parseExpires(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(d).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, expected RFC 3339 or a duration", value)
+	}
+
+	return t.UTC(), nil
+}
+
+func cookieDomain(cookie models.Cookie) string {
+	if cookie.HostOnly {
+		return cookie.Domain
+	}
+
+	return "." + cookie.Domain
+}
+
+func cookieExpires(cookie models.Cookie) string {
+	if cookie.Expires.IsZero() {
+		return "session"
+	}
+
+	return cookie.Expires.Local().Format(time.RFC3339)
+}
+
+func cookieFlags(cookie models.Cookie) string {
+	var flags []string
+	if cookie.Secure {
+		flags = append(flags, "Secure")
+	}
+	if cookie.HttpOnly {
+		flags = append(flags, "HttpOnly")
+	}
+	if cookie.SameSite != "" {
+		flags = append(flags, "SameSite="+cookie.SameSite)
+	}
+	if len(flags) == 0 {
+		return ""
+	}
+
+	return "\t" + strings.Join(flags, " ")
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"postman/internal/domain/models"
+	"postman/internal/lib/curl"
+	"strings"
+)
+
+const curlUsage = `curl <subcommand>
+      parse [-save PATH] [-send [-i] [-raw] [-jq FILTER]] ['curl ...']
+                                parse a curl command (read from stdin when omitted),
+                                print it as JSON, save it to a collection or send it
+      export PATH [-resolve] [-e ENV] [-var k=v]
+                                print a saved request as a curl command`
+
+func (c *CLI) curl(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+curlUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "parse":
+		return c.curlParse(args[1:])
+	case "export":
+		return c.curlExport(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "curl: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+curlUsage)
+	return ExitUsage
+}
+
+func (c *CLI) curlParse(args []string) int {
+	fs := c.flagSet("curl parse")
+	save := fs.String("save", "", "save the request as collection/[folder/...]name")
+	send := fs.Bool("send", false, "send the request")
+	include := fs.Bool("i", false, "with -send, print status line, headers and timing before the body")
+	c.bindRaw(fs)
+	c.bindFilter(fs)
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	var command string
+	switch len(positional) {
+	case 0:
+		b, err := io.ReadAll(c.in)
+		if err != nil {
+			return c.fail("curl parse", err)
+		}
+		command = string(b)
+	case 1:
+		command = positional[0]
+	default:
+		// an unquoted command line, e.g. postman curl parse curl -X POST ...
+		command = strings.Join(positional, " ")
+	}
+
+	request, warnings, err := curl.Parse(strings.TrimSpace(command))
+	c.warn(warnings)
+	if errors.Is(err, curl.ErrConflict) {
+		fmt.Fprintln(c.errOut, "curl parse:", err)
+		return ExitUsage
+	}
+	if err != nil {
+		return c.fail("curl parse", err)
+	}
+
+	if *save != "" {
+		if err := c.collections.SaveRequest(context.Background(), *save, models.SavedRequest{Request: request}); err != nil {
+			return c.fail("curl parse", err)
+		}
+	}
+
+	if *send {
+		return c.do(request, sf.scope(), *include, nil)
+	}
+
+	if *save == "" {
+		b, _ := json.MarshalIndent(request, "", "  ")
+		fmt.Fprintln(c.out, string(b))
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) curlExport(args []string) int {
+	fs := c.flagSet("curl export")
+	resolve := fs.Bool("resolve", false, "substitute variables before printing")
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "curl export: exactly one PATH is required")
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	saved, err := c.savedRequest(ctx, positional[0])
+	if err != nil {
+		return c.fail("curl export", err)
+	}
+
+	request := saved.Request
+	if *resolve {
+		scope := sf.scope()
+		scope.Defaults, err = c.collectionVariables(ctx, positional[0])
+		if err != nil {
+			return c.fail("curl export", err)
+		}
+
+		var unresolved []string
+		request, unresolved, err = c.requests.Resolve(ctx, request, scope)
+		if err != nil {
+			return c.fail("curl export", err)
+		}
+		c.warnUnresolved(unresolved)
+	}
+
+	command, warnings := curl.String(request)
+	c.warn(warnings)
+	fmt.Fprintln(c.out, command)
+
+	return ExitOK
+}
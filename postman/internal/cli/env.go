@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+const envUsage = `env <subcommand>
+      list                      list environments, the active one is marked with *
+      show NAME [-reveal]       print the variables, secrets are masked unless -reveal
+      create NAME
+      delete NAME
+      set NAME KEY VALUE [-secret]
+      unset NAME KEY
+      use NAME                  make NAME the active environment
+      deactivate                use no environment`
+
+const secretMask = "******"
+
+func (c *CLI) env(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+envUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return c.envList()
+	case "show":
+		return c.envShow(args[1:])
+	case "create":
+		return c.envCreate(args[1:])
+	case "delete":
+		return c.envDelete(args[1:])
+	case "set":
+		return c.envSet(args[1:])
+	case "unset":
+		return c.envUnset(args[1:])
+	case "use":
+		return c.envUse(args[1:])
+	case "deactivate":
+		if err := c.environments.UseEnvironment(context.Background(), ""); err != nil {
+			return c.fail("env deactivate", err)
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(c.errOut, "env: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+envUsage)
+	return ExitUsage
+}
+
+func (c *CLI) envList() int {
+	ctx := context.Background()
+
+	environments, err := c.environments.GetEnvironments(ctx)
+	if err != nil {
+		return c.fail("env list", err)
+	}
+
+	active, err := c.environments.ActiveEnvironment(ctx)
+	if err != nil {
+		fmt.Fprintln(c.errOut, "Warning:", err)
+	}
+
+	if len(environments) == 0 {
+		fmt.Fprintln(c.out, "No environments")
+		return ExitOK
+	}
+
+	for _, environment := range environments {
+		mark := " "
+		if environment.Name == active.Name {
+			mark = "*"
+		}
+		fmt.Fprintf(c.out, "%s %s\t%d variables\n", mark, environment.Name, len(environment.Variables))
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envShow(args []string) int {
+	fs := c.flagSet("env show")
+	reveal := fs.Bool("reveal", false, "print secret values")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "env show: exactly one environment name is required")
+		return ExitUsage
+	}
+
+	environment, err := c.environments.GetEnvironment(context.Background(), positional[0])
+	if err != nil {
+		return c.fail("env show", err)
+	}
+
+	for _, v := range environment.Variables {
+		value := v.Value
+		if v.Secret && !*reveal {
+			value = secretMask
+		}
+		fmt.Fprintf(c.out, "%s=%s", v.Key, value)
+		if v.Secret {
+			fmt.Fprint(c.out, "\t(secret)")
+		}
+		fmt.Fprintln(c.out)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envCreate(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "env create: exactly one environment name is required")
+		return ExitUsage
+	}
+
+	if _, err := c.environments.CreateEnvironment(context.Background(), args[0]); err != nil {
+		return c.fail("env create", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envDelete(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "env delete: exactly one environment name is required")
+		return ExitUsage
+	}
+
+	if err := c.environments.DeleteEnvironment(context.Background(), args[0]); err != nil {
+		return c.fail("env delete", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envSet(args []string) int {
+	fs := c.flagSet("env set")
+	secret := fs.Bool("secret", false, "mask the value when printing")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 3 || strings.TrimSpace(positional[1]) == "" {
+		fmt.Fprintln(c.errOut, "env set: NAME, KEY and VALUE are required")
+		return ExitUsage
+	}
+
+	variable := models.Variable{
+		Key:    positional[1],
+		Value:  positional[2],
+		Secret: *secret,
+	}
+	if err := c.environments.SetVariable(context.Background(), positional[0], variable); err != nil {
+		return c.fail("env set", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envUnset(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(c.errOut, "env unset: NAME and KEY are required")
+		return ExitUsage
+	}
+
+	if err := c.environments.UnsetVariable(context.Background(), args[0], args[1]); err != nil {
+		return c.fail("env unset", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) envUse(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "env use: exactly one environment name is required")
+		return ExitUsage
+	}
+
+	if err := c.environments.UseEnvironment(context.Background(), args[0]); err != nil {
+		return c.fail("env use", err)
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/lib/har"
+)
+
+const harUsage = `har <subcommand>
+      list FILE                 list the recorded requests
+      replay FILE [-i] [-e ENV] [-var k=v] [-record OUT]
+                                send the recorded requests again and compare the statuses`
+
+func (c *CLI) har(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+harUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return c.harList(args[1:])
+	case "replay":
+		return c.harReplay(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "har: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+harUsage)
+	return ExitUsage
+}
+
+func (c *CLI) harList(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "har list: exactly one FILE is required")
+		return ExitUsage
+	}
+
+	h, err := har.Load(args[0])
+	if err != nil {
+		return c.fail("har list", err)
+	}
+
+	for i, entry := range h.Log.Entries {
+		fmt.Fprintf(c.out, "%d\t%s %s\t%s\t%.0f ms", i+1, entry.Request.Method, entry.Request.URL, harStatus(entry), entry.Time)
+		if entry.Comment != "" {
+			fmt.Fprintf(c.out, "\t# %s", entry.Comment)
+		}
+		fmt.Fprintln(c.out)
+	}
+
+	return ExitOK
+}
+
+// harReplay sends every entry in order. It exits with ExitTransport when
+// a request could not be sent and with ExitFailure when a status differs
+// from the recorded one.
+func (c *CLI) harReplay(args []string) int {
+	fs := c.flagSet("har replay")
+	include := fs.Bool("i", false, "print the responses")
+	record := fs.String("record", "", "append the replayed exchanges to a HAR file")
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "har replay: exactly one FILE is required")
+		return ExitUsage
+	}
+
+	h, err := har.Load(positional[0])
+	if err != nil {
+		return c.fail("har replay", err)
+	}
+	defer c.recordTo(*record)()
+
+	ctx := context.Background()
+	code := ExitOK
+	for i, entry := range h.Log.Entries {
+		request := har.ToRequest(entry)
+		fmt.Fprintf(c.out, "%d\t%s %s\t", i+1, request.Method, request.URL)
+
+		exchange, err := c.requests.Send(ctx, request, sf.scope())
+		if err != nil {
+			fmt.Fprintln(c.out, "error:", err)
+			code = ExitTransport
+			continue
+		}
+
+		status := exchange.Response.StatusCode
+		fmt.Fprintf(c.out, "%d (recorded %s)", status, harStatus(entry))
+		if entry.Response.Status != 0 && status != entry.Response.Status {
+			fmt.Fprint(c.out, "\tMISMATCH")
+			if code == ExitOK {
+				code = ExitFailure
+			}
+		}
+		fmt.Fprintln(c.out)
+
+		if *include {
+			c.renderer.Response(exchange.Response)
+		}
+	}
+
+	return code
+}
+
+// recordTo records the exchanges of the running command and returns the
+// function that stops recording and appends them to the HAR file at path.
+func (c *CLI) recordTo(path string) func() {
+	if path == "" {
+		return func() {}
+	}
+
+	recorder := har.NewRecorder(path)
+	c.requests.AddRecorder(recorder)
+
+	return func() {
+		c.requests.RemoveRecorder(recorder)
+		if err := recorder.Close(); err != nil {
+			fmt.Fprintln(c.errOut, "Error writing HAR file:", err)
+		}
+	}
+}
+
+func harStatus(entry har.Entry) string {
+	if entry.Response.Status == 0 {
+		return "no response"
+	}
+
+	return fmt.Sprint(entry.Response.Status)
+}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/curl"
+	"postman/internal/lib/variables"
+	"postman/pkg/lib/logger/sl"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyUsage = `history <subcommand>
+      list [-method M] [-host H] [-status S] [-since T] [-until T] [-n N]
+                                list sent requests, newest first, S is 404, 4xx, 400-499 or error,
+                                T is RFC 3339, a date (2006-01-02) or a duration ago (2h)
+      show ID [-raw] [-jq FILTER] [-reveal]
+                                print the request and the saved response, secrets are masked
+                                unless -reveal
+      send ID [-i] [-raw] [-jq FILTER] [-source] [-e ENV] [-var k=v]
+                                send the request again as it was sent, -source resolves the
+                                request as written with the current variables
+      save ID PATH              save the request as written into a collection
+      clear                     delete the history`
+
+func (c *CLI) history(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+historyUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return c.historyList(args[1:])
+	case "show":
+		return c.historyShow(args[1:])
+	case "send":
+		return c.historySend(args[1:])
+	case "save":
+		return c.historySave(args[1:])
+	case "clear":
+		return c.historyClear(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "history: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+historyUsage)
+	return ExitUsage
+}
+
+func (c *CLI) historyList(args []string) int {
+	fs := c.flagSet("history list")
+	method := fs.String("method", "", "only requests with this method")
+	host := fs.String("host", "", "only requests to hosts containing this")
+	status := fs.String("status", "", "only responses with this status: 404, 4xx, 400-499 or error")
+	since := fs.String("since", "", "only requests sent after this time")
+	until := fs.String("until", "", "only requests sent before this time")
+	limit := fs.Int("n", 20, "number of entries to show, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	filter := models.HistoryFilter{Method: *method, Host: *host, Limit: *limit}
+	var err error
+	if filter.StatusMin, filter.StatusMax, filter.Failed, err = parseStatusFilter(*status); err != nil {
+		fmt.Fprintln(c.errOut, "history list:", err)
+		return ExitUsage
+	}
+	if filter.Since, err = parseTimeFilter(*since); err != nil {
+		fmt.Fprintln(c.errOut, "history list:", err)
+		return ExitUsage
+	}
+	if filter.Until, err = parseTimeFilter(*until); err != nil {
+		fmt.Fprintln(c.errOut, "history list:", err)
+		return ExitUsage
+	}
+
+	entries, err := c.historyService.GetEntries(context.Background(), filter)
+	if err != nil {
+		return c.fail("history list", err)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(c.out, "No requests")
+		return ExitOK
+	}
+
+	secrets := map[string]map[string]string{}
+	for _, entry := range entries {
+		if _, ok := secrets[entry.Environment]; !ok {
+			secrets[entry.Environment] = c.secrets(entry.Environment)
+		}
+		request := variables.Reveal(entry.Request, secrets[entry.Environment])
+		environment := entry.Environment
+		if environment == "" {
+			environment = "-"
+		}
+		fmt.Fprintf(c.out, "%d\t%s\t%s\t%s %s\t%s\t%s\n",
+			entry.ID,
+			entry.Time.Local().Format(time.DateTime),
+			environment,
+			request.Method,
+			variables.Mask(entryURL(request), secrets[entry.Environment], secretMask),
+			entryStatus(entry),
+			entry.Duration.Round(time.Millisecond),
+		)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) historyShow(args []string) int {
+	fs := c.flagSet("history show")
+	reveal := fs.Bool("reveal", false, "print secret values")
+	c.bindRaw(fs)
+	c.bindFilter(fs)
+
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(c.errOut, "history show: exactly one ID is required")
+		return ExitUsage
+	}
+	entry, code := c.historyEntry("history show", args[0])
+	if code != ExitOK {
+		return code
+	}
+
+	fmt.Fprintf(c.out, "#%d sent %s", entry.ID, entry.Time.Local().Format(time.DateTime))
+	if entry.Environment != "" {
+		fmt.Fprintf(c.out, " with environment %s", entry.Environment)
+	}
+	fmt.Fprintf(c.out, ", took %s\n\n", entry.Duration.Round(time.Millisecond))
+	secrets := c.secrets(entry.Environment)
+	command, warnings := curl.String(variables.Reveal(entry.Request, secrets))
+	c.warn(warnings)
+	if !*reveal {
+		command = variables.Mask(command, secrets, secretMask)
+	}
+	fmt.Fprintln(c.out, command)
+	fmt.Fprintln(c.out)
+
+	if entry.Response == nil {
+		fmt.Fprintln(c.out, "No response:", entry.Error)
+		return ExitOK
+	}
+
+	err = c.printResponse(models.Response{
+		Proto:      entry.Response.Proto,
+		StatusCode: entry.Status,
+		Status:     entry.Response.Status,
+		Headers:    entry.Response.Headers,
+		Body:       entry.Response.Body,
+		Duration:   entry.Duration,
+	}, true)
+	if entry.Response.Truncated {
+		fmt.Fprintf(c.out, "\n(body cut at %d of %d bytes)\n", len(entry.Response.Body), entry.Response.Size)
+	}
+	if err != nil {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) historySend(args []string) int {
+	fs := c.flagSet("history send")
+	include := fs.Bool("i", false, "print status line, headers and timing before the body")
+	source := fs.Bool("source", false, "resolve the request as written with the current variables")
+	c.bindRaw(fs)
+	c.bindFilter(fs)
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "history send: exactly one ID is required")
+		return ExitUsage
+	}
+	entry, code := c.historyEntry("history send", positional[0])
+	if code != ExitOK {
+		return code
+	}
+
+	// the cookies and tokens of the original environment are used
+	scope := sf.scope()
+	if scope.Environment == "" {
+		scope.Environment = entry.Environment
+	}
+
+	request := entry.Request
+	if *source {
+		request = entry.Source
+	}
+
+	return c.do(request, scope, *include, nil)
+}
+
+func (c *CLI) historySave(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(c.errOut, "history save: ID and PATH are required")
+		return ExitUsage
+	}
+	entry, code := c.historyEntry("history save", args[0])
+	if code != ExitOK {
+		return code
+	}
+
+	saved := models.SavedRequest{Request: entry.Source}
+	if err := c.collections.SaveRequest(context.Background(), args[1], saved); err != nil {
+		return c.fail("history save", err)
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) historyClear(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(c.errOut, "history clear: no arguments expected")
+		return ExitUsage
+	}
+
+	if err := c.historyService.ClearHistory(context.Background()); err != nil {
+		return c.fail("history clear", err)
+	}
+
+	return ExitOK
+}
+
+// secrets returns the secret variables of the environment an entry was
+// sent with. The history stores them as placeholders, which are filled
+// in before printing and then masked. The values of a deleted
+// environment are unknown and stay placeholders.
+func (c *CLI) secrets(name string) map[string]string {
+	if name == "" {
+		return nil
+	}
+
+	environment, err := c.environments.GetEnvironment(context.Background(), name)
+	if err != nil {
+		c.log.Debug("Error reading environment secrets", "environment", name, sl.Err(err))
+		return nil
+	}
+
+	return environment.Secrets()
+}
+
+func (c *CLI) historyEntry(command, id string) (models.HistoryEntry, int) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "#"))
+	if err != nil {
+		fmt.Fprintf(c.errOut, "%s: invalid ID %q\n", command, id)
+		return models.HistoryEntry{}, ExitUsage
+	}
+
+	entry, err := c.historyService.GetEntry(context.Background(), n)
+	if err != nil {
+		return models.HistoryEntry{}, c.fail(command, err)
+	}
+
+	return entry, ExitOK
+}
+
+// parseStatusFilter accepts a status (404), a class (4xx), a range
+// (400-499) or "error" for requests that got no response.
+func parseStatusFilter(s string) (int, int, bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return 0, 0, false, nil
+	case s == "error":
+		return 0, 0, true, nil
+	case len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5':
+		class := int(s[0]-'0') * 100
+		return class, class + 99, false, nil
+	}
+
+	low, high, isRange := strings.Cut(s, "-")
+	if !isRange {
+		high = low
+	}
+	min, err1 := strconv.Atoi(low)
+	max, err2 := strconv.Atoi(high)
+	if err1 != nil || err2 != nil || min < 100 || max > 599 || min > max {
+		return 0, 0, false, fmt.Errorf("invalid status %q, expected 404, 4xx, 400-499 or error", s)
+	}
+
+	return min, max, false, nil
+}
+
+// parseTimeFilter accepts an RFC 3339 time, a local date or a duration
+// before now.
+func parseTimeFilter(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, a date or a duration", s)
+}
+
+func entryURL(request models.Request) string {
+	if u, err := client.URL(request); err == nil {
+		return u.String()
+	}
+
+	return request.URL
+}
+
+func entryStatus(entry models.HistoryEntry) string {
+	if entry.Response == nil {
+		return "error"
+	}
+
+	return strconv.Itoa(entry.Status)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"postman/internal/lib/postmanv21"
+	serviceerrors "postman/internal/service"
+)
+
+const importUsage = "import FILE [-name NAME] [-overwrite]  import a Postman Collection v2.1 file"
+
+func (c *CLI) importCollection(args []string) int {
+	fs := c.flagSet("import")
+	name := fs.String("name", "", "collection name, defaults to the name in the file")
+	overwrite := fs.Bool("overwrite", false, "replace an existing collection with the same name")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "import: exactly one FILE is required")
+		return ExitUsage
+	}
+
+	f, err := os.Open(positional[0])
+	if err != nil {
+		return c.fail("import", err)
+	}
+	defer f.Close()
+
+	collection, warnings, err := postmanv21.Import(f)
+	if err != nil {
+		return c.fail("import", err)
+	}
+	if *name != "" {
+		collection.Name = *name
+	}
+
+	ctx := context.Background()
+	if !*overwrite {
+		_, err := c.collections.GetCollection(ctx, collection.Name)
+		if err == nil {
+			return c.fail("import", fmt.Errorf("collection %q already exists, use -overwrite or -name", collection.Name))
+		}
+		if !errors.Is(err, serviceerrors.ErrNotFound) {
+			return c.fail("import", err)
+		}
+	}
+
+	if err := c.collections.UpdateCollection(ctx, collection); err != nil {
+		return c.fail("import", err)
+	}
+
+	fmt.Fprintf(c.out, "Imported %d requests into collection %q\n", countRequests(collection.Folders, collection.Requests), collection.Name)
+	if len(warnings) > 0 {
+		fmt.Fprintf(c.out, "%d constructs were not translated:\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintln(c.out, "  "+w)
+		}
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/domain/models"
+	"time"
+)
+
+const oauthUsage = `oauth <subcommand>
+      token PATH [-e ENV] [-var k=v] [-show]
+                                get the OAuth 2.0 token of a request, from the cache when valid
+      clear [-e ENV]            forget the cached tokens of an environment`
+
+func (c *CLI) oauth(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(c.errOut, "Usage: postman "+oauthUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "token":
+		return c.oauthToken(args[1:])
+	case "clear":
+		return c.oauthClear(args[1:])
+	}
+
+	fmt.Fprintf(c.errOut, "oauth: unknown subcommand %q\n", args[0])
+	fmt.Fprintln(c.errOut, "Usage: postman "+oauthUsage)
+	return ExitUsage
+}
+
+func (c *CLI) oauthToken(args []string) int {
+	fs := c.flagSet("oauth token")
+	show := fs.Bool("show", false, "print the whole access token")
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "oauth token: exactly one PATH is required")
+		return ExitUsage
+	}
+
+	ctx := context.Background()
+	saved, err := c.savedRequest(ctx, positional[0])
+	if err != nil {
+		return c.fail("oauth token", err)
+	}
+
+	scope := sf.scope()
+	scope.Defaults, err = c.collectionVariables(ctx, positional[0])
+	if err != nil {
+		return c.fail("oauth token", err)
+	}
+
+	resolved, _, err := c.requests.Resolve(ctx, saved.Request, scope)
+	if err != nil {
+		return c.fail("oauth token", err)
+	}
+	if resolved.Auth == nil || resolved.Auth.Type != models.AuthOAuth2 {
+		return c.fail("oauth token", fmt.Errorf("%s does not use oauth2", positional[0]))
+	}
+
+	token, err := c.oauth2.Token(ctx, *resolved.Auth, resolved.Options, scope.Environment)
+	if err != nil {
+		return c.fail("oauth token", err)
+	}
+
+	value := token.AccessToken
+	if !*show && len(value) > 8 {
+		value = value[:8] + secretMask
+	}
+	fmt.Fprintln(c.out, "Access token:", value)
+	if token.TokenType != "" {
+		fmt.Fprintln(c.out, "Type:", token.TokenType)
+	}
+	if token.Scope != "" {
+		fmt.Fprintln(c.out, "Scope:", token.Scope)
+	}
+	if token.ExpiresAt.IsZero() {
+		fmt.Fprintln(c.out, "Expires: never")
+	} else {
+		fmt.Fprintf(c.out, "Expires: %s (in %s)\n", token.ExpiresAt.Format(time.RFC3339), time.Until(token.ExpiresAt).Round(time.Second))
+	}
+	fmt.Fprintln(c.out, "Refresh token:", token.RefreshToken != "")
+
+	return ExitOK
+}
+
+func (c *CLI) oauthClear(args []string) int {
+	fs := c.flagSet("oauth clear")
+	environment := fs.String("e", "", "environment to use instead of the active one")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if err := c.oauth2.ClearTokens(context.Background(), *environment); err != nil {
+		return c.fail("oauth clear", err)
+	}
+
+	return ExitOK
+}
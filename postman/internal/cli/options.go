@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/domain/models"
+	"strconv"
+	"strings"
+)
+
+// collectionOptions shows or changes the transport options of a
+// collection, when PATH has no slash, or of a saved request.
+func (c *CLI) collectionOptions(args []string) int {
+	fs := c.flagSet("collection options")
+	var of optionFlags
+	of.bind(fs)
+	reset := fs.Bool("reset", false, "remove the options, so the config or collection ones apply")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "collection options: exactly one PATH is required")
+		return ExitUsage
+	}
+	path := strings.Trim(positional[0], "/")
+	changed := fs.NFlag() > 0
+
+	ctx := context.Background()
+	if !strings.Contains(path, "/") {
+		collection, err := c.collections.GetCollection(ctx, path)
+		if err != nil {
+			return c.fail("collection options", err)
+		}
+		if !changed {
+			if collection.Options != nil {
+				fmt.Fprintln(c.out, describeOptions(*collection.Options))
+			}
+			return ExitOK
+		}
+
+		var options models.Options
+		if collection.Options != nil && !*reset {
+			options = *collection.Options
+		}
+		if err := of.apply(&options); err != nil {
+			fmt.Fprintln(c.errOut, "collection options:", err)
+			return ExitUsage
+		}
+		collection.Options = &options
+		if options == (models.Options{}) {
+			collection.Options = nil
+		}
+		if err := c.collections.UpdateCollection(ctx, collection); err != nil {
+			return c.fail("collection options", err)
+		}
+		return ExitOK
+	}
+
+	saved, err := c.collections.GetRequest(ctx, path)
+	if err != nil {
+		return c.fail("collection options", err)
+	}
+	if !changed {
+		fmt.Fprintln(c.out, describeOptions(saved.Options))
+		return ExitOK
+	}
+
+	if *reset {
+		saved.Options = models.Options{}
+	}
+	if err := of.apply(&saved.Options); err != nil {
+		fmt.Fprintln(c.errOut, "collection options:", err)
+		return ExitUsage
+	}
+	if err := c.collections.SaveRequest(ctx, path, saved); err != nil {
+		return c.fail("collection options", err)
+	}
+
+	return ExitOK
+}
+
+// describeOptions lists the options that are set, or "defaults".
+func describeOptions(options models.Options) string {
+	var parts []string
+	if options.Timeout > 0 {
+		parts = append(parts, "timeout="+options.Timeout.String())
+	}
+	if options.FollowRedirects != nil {
+		parts = append(parts, "follow="+strconv.FormatBool(*options.FollowRedirects))
+	}
+	if options.MaxRedirects > 0 {
+		parts = append(parts, "max-redirects="+strconv.Itoa(options.MaxRedirects))
+	}
+	if options.Proxy != "" {
+		parts = append(parts, "proxy="+options.Proxy)
+	}
+	if options.NoProxy != "" {
+		parts = append(parts, "no-proxy="+options.NoProxy)
+	}
+	if options.InsecureSkipVerify {
+		parts = append(parts, "insecure")
+	}
+	if options.CABundle != "" {
+		parts = append(parts, "cacert="+options.CABundle)
+	}
+	if options.HTTP2 != nil {
+		parts = append(parts, "http2="+strconv.FormatBool(*options.HTTP2))
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+
+	return strings.Join(parts, " ")
+}
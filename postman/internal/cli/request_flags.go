@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/extract"
+	"postman/internal/lib/headers"
+	"postman/internal/lib/pathparams"
+	"strings"
+	"time"
+)
+
+// requestFlags are the request building flags shared by the commands
+// that accept an ad hoc request.
+type requestFlags struct {
+	method  string
+	body    bodyFlags
+	options optionFlags
+	headers stringsFlag
+	query   stringsFlag
+	path    stringsFlag
+	user    string
+	digest  bool
+	bearer  string
+}
+
+func (f *requestFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&f.method, "X", "", "request method (default GET, or POST when a body is set)")
+	fs.Var(&f.headers, "H", `request header "Key: Value", repeatable`)
+	fs.Var(&f.query, "q", "query parameter key=value, repeatable")
+	fs.Var(&f.path, "p", "path parameter value key=value for :key or {key} in the URL, repeatable")
+	f.body.bind(fs)
+	fs.StringVar(&f.user, "u", "", "basic auth user:password")
+	fs.BoolVar(&f.digest, "digest", false, "use digest instead of basic auth for -u")
+	fs.StringVar(&f.bearer, "bearer", "", "bearer token")
+	f.options.bind(fs)
+}
+
+// bodyFlags select the body mode of a request. Only one of -d, -form,
+// -F and -binary may be given.
+type bodyFlags struct {
+	data        string
+	form        stringsFlag
+	multipart   stringsFlag
+	binary      string
+	contentType string
+}
+
+func (f *bodyFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&f.data, "d", "", "raw request body, @file to read it from a file, @- from stdin")
+	fs.Var(&f.form, "form", "x-www-form-urlencoded field key=value, repeatable")
+	fs.Var(&f.multipart, "F", "multipart/form-data field "+bodies.Syntax+", repeatable")
+	fs.StringVar(&f.binary, "binary", "", "send the file as the request body")
+	fs.StringVar(&f.contentType, "content-type", "", "content type of a raw or binary body")
+}
+
+// given reports whether a body mode flag is set.
+func (f *bodyFlags) given() bool {
+	return f.data != "" || len(f.form) > 0 || len(f.multipart) > 0 || f.binary != ""
+}
+
+// applyBody replaces the body of the request when a body mode flag is set.
+// -content-type alone changes the content type of the current body.
+func (c *CLI) applyBody(f *bodyFlags, request *models.Request) error {
+	modes := 0
+	for _, set := range []bool{f.data != "", len(f.form) > 0, len(f.multipart) > 0, f.binary != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf("%w: use only one of -d, -form, -F and -binary", bodies.ErrInvalidBody)
+	}
+
+	if modes == 1 {
+		request.Body, request.BodyMode, request.Form, request.BodyFile, request.ContentType = "", "", nil, "", ""
+	}
+	if f.contentType != "" {
+		request.ContentType = f.contentType
+	}
+
+	switch {
+	case f.data != "":
+		body, err := c.readData(f.data)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		request.Body = body
+
+	case len(f.form) > 0:
+		request.BodyMode = models.BodyURLEncoded
+		for _, v := range f.form {
+			key, value, _ := strings.Cut(v, "=")
+			request.Form = append(request.Form, models.FormField{Key: key, Value: value})
+		}
+
+	case len(f.multipart) > 0:
+		request.BodyMode = models.BodyFormData
+		for _, v := range f.multipart {
+			field, err := bodies.ParseField(v)
+			if err != nil {
+				return err
+			}
+			request.Form = append(request.Form, field)
+		}
+
+	case f.binary != "":
+		request.BodyMode = models.BodyBinary
+		request.BodyFile = f.binary
+	}
+
+	return nil
+}
+
+// optionFlags set the transport options of a request or a collection.
+// Flags that are not given leave the options unchanged.
+type optionFlags struct {
+	timeout      time.Duration
+	noFollow     boolPtrFlag
+	maxRedirects int
+	proxy        string
+	noProxy      string
+	insecure     bool
+	caBundle     string
+	http2        boolPtrFlag
+}
+
+func (f *optionFlags) bind(fs *flag.FlagSet) {
+	fs.DurationVar(&f.timeout, "timeout", 0, "request timeout, overrides the config")
+	fs.Var(&f.noFollow, "no-follow", "do not follow redirects, -no-follow=false follows them")
+	fs.IntVar(&f.maxRedirects, "max-redirects", 0, "maximum number of redirects to follow, 0 inherits it (10 by default), -no-follow turns redirects off")
+	fs.StringVar(&f.proxy, "proxy", "", `http, https, socks5 or socks5h proxy URL, "direct" for none; localhost and loopback addresses are always sent directly`)
+	fs.StringVar(&f.noProxy, "no-proxy", "", "comma separated hosts, domains and CIDRs that bypass the proxy")
+	fs.BoolVar(&f.insecure, "k", false, "skip TLS certificate verification")
+	fs.StringVar(&f.caBundle, "cacert", "", "PEM file with additional trusted CA certificates")
+	fs.Var(&f.http2, "http2", "use HTTP/2 when the server supports it, -http2=false forces HTTP/1.1")
+}
+
+func (f *optionFlags) apply(options *models.Options) error {
+	if f.proxy != "" {
+		if err := client.ValidateProxy(f.proxy); err != nil {
+			return err
+		}
+		options.Proxy = f.proxy
+	}
+	if f.timeout > 0 {
+		options.Timeout = f.timeout
+	}
+	if f.noFollow.value != nil {
+		follow := !*f.noFollow.value
+		options.FollowRedirects = &follow
+	}
+	if f.maxRedirects > 0 {
+		options.MaxRedirects = f.maxRedirects
+	}
+	if f.noProxy != "" {
+		options.NoProxy = f.noProxy
+	}
+	if f.insecure {
+		options.InsecureSkipVerify = true
+	}
+	if f.caBundle != "" {
+		options.CABundle = f.caBundle
+	}
+	if f.http2.value != nil {
+		options.HTTP2 = f.http2.value
+	}
+
+	return nil
+}
+
+// scopeFlags select the environment and extra variables of a request.
+type scopeFlags struct {
+	environment string
+	vars        stringsFlag
+}
+
+func (f *scopeFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&f.environment, "e", "", "environment to use instead of the active one")
+	fs.Var(&f.vars, "var", "variable key=value overriding the environment, repeatable")
+}
+
+func (f *scopeFlags) scope() models.Scope {
+	scope := models.Scope{
+		Environment: f.environment,
+		Variables:   map[string]string{},
+	}
+	for _, v := range f.vars {
+		key, value, _ := strings.Cut(v, "=")
+		scope.Variables[key] = value
+	}
+
+	return scope
+}
+
+// assertFlags collect response assertions, see assertions.Syntax.
+type assertFlags struct {
+	exprs stringsFlag
+}
+
+func (f *assertFlags) bind(fs *flag.FlagSet) {
+	fs.Var(&f.exprs, "assert", `response assertion, e.g. "status in 200..299" or "jsonpath $.id exists", repeatable`)
+}
+
+func (f *assertFlags) parse() ([]models.Assertion, error) {
+	list := make([]models.Assertion, 0, len(f.exprs))
+	for _, expr := range f.exprs {
+		a, err := assertions.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+
+	return list, nil
+}
+
+// extractFlags collect response extractors, see extract.Syntax.
+type extractFlags struct {
+	exprs stringsFlag
+}
+
+func (f *extractFlags) bind(fs *flag.FlagSet) {
+	fs.Var(&f.exprs, "extract", `copy a response value into a variable, e.g. "userId=jsonpath $.id", repeatable`)
+}
+
+func (f *extractFlags) parse() ([]models.Extractor, error) {
+	list := make([]models.Extractor, 0, len(f.exprs))
+	for _, expr := range f.exprs {
+		e, err := extract.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+
+	return list, nil
+}
+
+func (c *CLI) buildRequest(f *requestFlags, url string) (models.Request, error) {
+	request := models.Request{
+		Method: f.method,
+		URL:    url,
+	}
+	if err := f.options.apply(&request.Options); err != nil {
+		return models.Request{}, err
+	}
+
+	for _, h := range f.headers {
+		header, err := headers.Parse(h)
+		if err != nil {
+			return models.Request{}, err
+		}
+		request.Headers = append(request.Headers, header)
+	}
+
+	for _, q := range f.query {
+		key, value, _ := strings.Cut(q, "=")
+		request.Query = append(request.Query, models.QueryParam{Key: key, Value: value})
+	}
+	for _, p := range f.path {
+		key, value, _ := strings.Cut(p, "=")
+		request.PathParams = pathparams.Set(request.PathParams, key, value)
+	}
+
+	if err := c.applyBody(&f.body, &request); err != nil {
+		return models.Request{}, err
+	}
+
+	switch {
+	case f.user != "":
+		username, password, _ := strings.Cut(f.user, ":")
+		request.Auth = &models.Auth{Type: models.AuthBasic, Username: username, Password: password}
+		if f.digest {
+			request.Auth.Type = models.AuthDigest
+		}
+	case f.bearer != "":
+		request.Auth = &models.Auth{Type: models.AuthBearer, Token: f.bearer}
+	}
+
+	if request.Method == "" {
+		request.Method = "GET"
+		if request.HasBody() {
+			request.Method = "POST"
+		}
+	}
+	request.Method = client.NormalizeMethod(request.Method)
+
+	return request, nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"postman/internal/domain/models"
+	"postman/internal/lib/dataset"
+	"postman/internal/lib/report"
+)
+
+const runUsage = `run PATH [-e ENV] [-var k=v] [-data FILE] [-junit FILE] [-json FILE] [-har FILE]
+                                run a collection, folder or request and check the assertions,
+                                with -data once per row of a CSV or JSON array file`
+
+// run exits with ExitTransport when a request could not be sent, with
+// ExitFailure when an assertion failed and with ExitOK otherwise.
+func (c *CLI) run(args []string) int {
+	fs := c.flagSet("run")
+	junit := fs.String("junit", "", "write a JUnit XML report to the file")
+	jsonPath := fs.String("json", "", "write a JSON report to the file")
+	harPath := fs.String("har", "", "append the exchanges to a HAR file")
+	dataPath := fs.String("data", "", "CSV or JSON array file, the run is repeated for every row")
+	var sf scopeFlags
+	sf.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "run: exactly one PATH is required")
+		return ExitUsage
+	}
+
+	var data []map[string]string
+	if *dataPath != "" {
+		data, err = dataset.Load(*dataPath)
+		if err != nil {
+			return c.fail("run", err)
+		}
+	}
+	defer c.recordTo(*harPath)()
+
+	result, err := c.runner.Run(context.Background(), positional[0], sf.scope(), data, c.renderer.RunResult)
+	if err != nil {
+		return c.fail("run", err)
+	}
+	c.renderer.RunSummary(result)
+
+	if *junit != "" {
+		if err := writeReport(*junit, result, report.JUnit); err != nil {
+			return c.fail("run", err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeReport(*jsonPath, result, report.JSON); err != nil {
+			return c.fail("run", err)
+		}
+	}
+
+	switch {
+	case result.Count(models.OutcomeErrored) > 0:
+		return ExitTransport
+	case result.Count(models.OutcomeFailed) > 0:
+		return ExitFailure
+	default:
+		return ExitOK
+	}
+}
+
+func writeReport(path string, result models.RunReport, write func(io.Writer, models.RunReport) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f, result); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
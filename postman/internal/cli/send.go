@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/curl"
+	"postman/internal/lib/extract"
+	"postman/internal/lib/variables"
+	"strings"
+)
+
+func (c *CLI) send(args []string) int {
+	fs := c.flagSet("send")
+
+	var (
+		rf      requestFlags
+		sf      scopeFlags
+		include bool
+	)
+	rf.bind(fs)
+	sf.bind(fs)
+	fs.BoolVar(&include, "i", false, "print status line, headers and timing before the body")
+	printCurl := fs.Bool("curl", false, "print the resolved request as a curl command instead of sending it")
+	printURL := fs.Bool("print-url", false, "print the resolved and encoded URL instead of sending the request")
+	harPath := fs.String("har", "", "append the exchange to a HAR file")
+	c.bindRaw(fs)
+	c.bindFilter(fs)
+	var checks assertFlags
+	checks.bind(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(c.errOut, "send: exactly one URL is required")
+		return ExitUsage
+	}
+
+	request, err := c.buildRequest(&rf, positional[0])
+	if err != nil {
+		fmt.Fprintln(c.errOut, "send:", err)
+		return ExitUsage
+	}
+	list, err := checks.parse()
+	if err != nil {
+		fmt.Fprintln(c.errOut, "send:", err)
+		return ExitUsage
+	}
+
+	if *printCurl {
+		resolved, unresolved, err := c.requests.Resolve(context.Background(), request, sf.scope())
+		if err != nil {
+			return c.fail("send", err)
+		}
+		c.warnUnresolved(unresolved)
+		command, warnings := curl.String(resolved)
+		c.warn(warnings)
+		fmt.Fprintln(c.out, command)
+		return ExitOK
+	}
+	if *printURL {
+		return c.printURL("send", request, sf.scope())
+	}
+	defer c.recordTo(*harPath)()
+
+	return c.do(request, sf.scope(), include, list)
+}
+
+// printURL prints the URL a request would be sent to, with variables
+// and path parameters resolved and the query encoded.
+func (c *CLI) printURL(command string, request models.Request, scope models.Scope) int {
+	resolved, unresolved, err := c.requests.Resolve(context.Background(), request, scope)
+	if err != nil {
+		return c.fail(command, err)
+	}
+	c.warnUnresolved(unresolved)
+
+	u, err := client.URL(resolved)
+	if err != nil {
+		return c.fail(command, err)
+	}
+	fmt.Fprintln(c.out, u.String())
+
+	return ExitOK
+}
+
+// do sends an ad hoc request, see doSaved.
+func (c *CLI) do(request models.Request, scope models.Scope, include bool, checks []models.Assertion) int {
+	return c.doSaved(models.SavedRequest{Request: request, Assertions: checks}, scope, include)
+}
+
+// doSaved runs the pre-request script, sends the request, prints the
+// result and runs the post-response script, then maps it to an exit code.
+// With assertions or script tests the exit code is ExitFailure when one
+// of them fails and ExitOK otherwise, whatever the response status. A
+// -jq filter that fails gives ExitFailure too.
+func (c *CLI) doSaved(saved models.SavedRequest, scope models.Scope, include bool) int {
+	ctx := context.Background()
+
+	request := saved.Request
+	if saved.PreRequest != "" {
+		res, err := c.scripts.PreRequest(ctx, "pre-request", saved.PreRequest, &request, scope)
+		c.printLogs(res.Logs)
+		if err != nil {
+			fmt.Fprintln(c.errOut, "Error in pre-request script:", err)
+			return ExitFailure
+		}
+		scope.Variables = variables.Merge(scope.Variables, res.Variables)
+	}
+
+	exchange, err := c.requests.Send(ctx, request, scope)
+	c.warnUnresolved(exchange.Unresolved)
+	if err != nil {
+		fmt.Fprintln(c.errOut, "Error sending request:", err)
+		if errors.Is(err, client.ErrInvalidMethod) {
+			return ExitUsage
+		}
+		return ExitTransport
+	}
+
+	resp := exchange.Response
+	filterErr := c.printResponse(resp, include)
+
+	vars, err := c.requests.Variables(ctx, scope)
+	if err != nil {
+		fmt.Fprintln(c.errOut, "Error resolving variables:", err)
+		return ExitFailure
+	}
+	results := assertions.Check(saved.Assertions, resp, vars)
+
+	extracted, failures := extract.Apply(saved.Extractors, resp)
+	results = append(results, failures...)
+	for _, e := range saved.Extractors {
+		if value, ok := extracted[e.Variable]; ok {
+			fmt.Fprintf(c.errOut, "%s = %s\n", e.Variable, value)
+		}
+	}
+	scope.Variables = variables.Merge(scope.Variables, extracted)
+	if saved.PostResponse != "" {
+		res, err := c.scripts.PostResponse(ctx, "post-response", saved.PostResponse, exchange, scope)
+		c.printLogs(res.Logs)
+		results = append(results, res.Tests...)
+		if err != nil {
+			fmt.Fprintln(c.errOut, "Error in post-response script:", err)
+			return ExitFailure
+		}
+	}
+
+	if len(results) > 0 {
+		fmt.Fprintln(c.out)
+		c.renderer.Assertions(results)
+		if !assertions.Passed(results) {
+			return ExitFailure
+		}
+	}
+	if filterErr != nil {
+		return ExitFailure
+	}
+	if len(results) > 0 {
+		return ExitOK
+	}
+
+	return StatusExitCode(resp.StatusCode)
+}
+
+// printResponse prints the body as received, or with include after the
+// status line and headers and pretty-printed. With -jq the results of
+// the filter replace the body; a filter error is printed and returned.
+func (c *CLI) printResponse(resp models.Response, include bool) error {
+	c.last = &resp
+
+	switch {
+	case c.filter != nil:
+		if include {
+			c.renderer.Head(resp)
+		}
+		if err := c.renderer.Filter(c.filter, resp.Body); err != nil {
+			fmt.Fprintln(c.errOut, "Error in filter:", err)
+			return err
+		}
+	case include:
+		c.renderer.Response(resp)
+	default:
+		c.out.Write(resp.Body)
+	}
+
+	return nil
+}
+
+func (c *CLI) printLogs(lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(c.errOut, "log:", line)
+	}
+}
+
+func (c *CLI) warn(warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintln(c.errOut, "Warning:", w)
+	}
+}
+
+func (c *CLI) warnUnresolved(names []string) {
+	if len(names) > 0 {
+		fmt.Fprintln(c.errOut, "Warning: unresolved variables:", strings.Join(names, ", "))
+	}
+}
+
+// StatusExitCode maps a response status to ExitOK, ExitClientError or ExitServerError.
+func StatusExitCode(status int) int {
+	switch {
+	case status >= 500:
+		return ExitServerError
+	case status >= 400:
+		return ExitClientError
+	default:
+		return ExitOK
+	}
+}
+
+// readData resolves curl-style @file and @- body references.
+func (c *CLI) readData(data string) (string, error) {
+	if !strings.HasPrefix(data, "@") {
+		return data, nil
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if data == "@-" {
+		b, err = io.ReadAll(c.in)
+	} else {
+		b, err = os.ReadFile(data[1:])
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,327 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"postman/internal/domain/models"
+	"postman/internal/lib/auth"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/certs"
+	"postman/internal/lib/headers"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidMethod    = errors.New("invalid method")
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// DefaultMaxRedirects is the redirect limit when the options leave it
+// at 0. Options turn redirects off with FollowRedirects, never with 0.
+const DefaultMaxRedirects = 10
+
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+type Client struct {
+	log *slog.Logger
+	// defaults are the transport options of the config file.
+	defaults models.Options
+	// certificates are presented to the hosts they match.
+	certificates []models.ClientCertificate
+
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+func New(log *slog.Logger, defaults models.Options, certificates []models.ClientCertificate) *Client {
+	return &Client{
+		log:          log,
+		defaults:     defaults,
+		certificates: certificates,
+		transports:   map[transportKey]*http.Transport{},
+	}
+}
+
+// Do sends the request and reads the whole response. Cookies are taken
+// from and stored into jar, when not nil.
+func (c *Client) Do(ctx context.Context, request models.Request, jar http.CookieJar) (models.Response, error) {
+	const op = "client.Do"
+
+	req, err := Build(ctx, request)
+	if err != nil {
+		return models.Response{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	httpClient, err := c.httpClient(request.Options.Inherit(c.defaults))
+	if err != nil {
+		return models.Response{}, fmt.Errorf("%s: %w", op, err)
+	}
+	httpClient.Jar = jar
+
+	resp, err := c.send(httpClient, req)
+	if err != nil {
+		return models.Response{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// digest auth answers the challenge of the first response; a 401
+	// without a Digest challenge is returned as is
+	if request.Auth != nil && request.Auth.Type == models.AuthDigest && resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := digest(resp.Headers.Values("WWW-Authenticate"), request.Auth, req)
+		if errors.Is(err, auth.ErrNoChallenge) {
+			return resp, nil
+		}
+		if err != nil {
+			return models.Response{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		req, err = Build(ctx, request)
+		if err != nil {
+			return models.Response{}, fmt.Errorf("%s: %w", op, err)
+		}
+		req.Header.Set("Authorization", authorization)
+
+		if resp, err = c.send(httpClient, req); err != nil {
+			return models.Response{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// digest answers the first Digest challenge among the WWW-Authenticate
+// values that it supports. A server may offer several, e.g. Basic and
+// Digest or Digest with SHA-256 and MD5.
+func digest(challenges []string, a *models.Auth, req *http.Request) (string, error) {
+	result := auth.ErrNoChallenge
+	for _, challenge := range challenges {
+		authorization, err := auth.Digest(challenge, a, req.Method, req.URL.RequestURI())
+		if err == nil {
+			return authorization, nil
+		}
+		if !errors.Is(err, auth.ErrNoChallenge) {
+			result = err
+		}
+	}
+
+	return "", result
+}
+
+// HTTPClient returns a client for requests made on behalf of a request
+// with the options, e.g. to an OAuth 2.0 token endpoint, with the same
+// proxy, TLS settings and client certificates.
+func (c *Client) HTTPClient(options models.Options) (*http.Client, error) {
+	return c.httpClient(options.Inherit(c.defaults))
+}
+
+// httpClient returns a client with the timeout and redirect policy of
+// the options, sharing the transport with other requests that have the
+// same proxy and TLS settings.
+func (c *Client) httpClient(options models.Options) (*http.Client, error) {
+	transport, err := c.transport(options)
+	if err != nil {
+		return nil, err
+	}
+
+	follow := options.FollowRedirects == nil || *options.FollowRedirects
+	maxRedirects := options.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+
+	return &http.Client{
+		Timeout:   options.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !follow {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxRedirects)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// send does a single round trip with tracing.
+func (c *Client) send(httpClient *http.Client, req *http.Request) (models.Response, error) {
+	t := &tracer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.trace()))
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return models.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return models.Response{}, err
+	}
+	duration := time.Since(start)
+	timings, sentHeaders, remoteAddr := t.finish()
+
+	tlsInfo := certs.Describe(resp.TLS)
+	if i := c.certificateFor(resp.Request.URL); tlsInfo != nil && i >= 0 {
+		tlsInfo.ClientCertificate = c.certificates[i].CertFile
+		if tlsInfo.ClientCertificate == "" {
+			tlsInfo.ClientCertificate = c.certificates[i].PKCS12File
+		}
+	}
+
+	return models.Response{
+		Proto:          resp.Proto,
+		StatusCode:     resp.StatusCode,
+		Status:         resp.Status,
+		Headers:        resp.Header,
+		Body:           body,
+		Duration:       duration,
+		Timings:        timings,
+		RequestMethod:  resp.Request.Method,
+		RequestURL:     resp.Request.URL.String(),
+		Redirected:     resp.Request != req,
+		RequestHeaders: sentHeaders,
+		RemoteAddr:     remoteAddr,
+		TLS:            tlsInfo,
+	}, nil
+}
+
+// Build converts the request model into an *http.Request.
+func Build(ctx context.Context, request models.Request) (*http.Request, error) {
+	method := NormalizeMethod(request.Method)
+	if !ValidMethod(method) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMethod, request.Method)
+	}
+
+	u, err := URL(request)
+	if err != nil {
+		return nil, err
+	}
+
+	data, contentType, err := bodies.Encode(request)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range request.Headers {
+		if err := headers.Validate(h); err != nil {
+			return nil, err
+		}
+	}
+	headers.Apply(req, request.Headers)
+
+	if err := auth.Apply(req, request.Auth); err != nil {
+		return nil, err
+	}
+
+	// a multipart header set by the user still needs the generated boundary
+	if data != nil {
+		if ct := req.Header.Get("Content-Type"); ct == "" || request.BodyMode == models.BodyFormData && bodies.NeedsBoundary(ct) {
+			req.Header.Set("Content-Type", contentType)
+		}
+	}
+
+	return req, nil
+}
+
+// URL parses the request URL and appends the enabled query parameters
+// in order.
+func URL(request models.Request) (*url.URL, error) {
+	u, err := url.Parse(request.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, request.URL)
+	}
+
+	for _, p := range request.Query {
+		if p.Disabled {
+			continue
+		}
+		pair := url.QueryEscape(p.Key) + "=" + url.QueryEscape(p.Value)
+		if u.RawQuery == "" {
+			u.RawQuery = pair
+		} else {
+			u.RawQuery += "&" + pair
+		}
+	}
+
+	return u, nil
+}
+
+// SplitQuery moves the query string of a typed URL into parameters, so
+// that they can be edited one by one. Values are unescaped, URL encodes
+// them again when sending.
+func SplitQuery(rawURL string) (string, []models.QueryParam) {
+	base, query, found := strings.Cut(rawURL, "?")
+	if !found {
+		return rawURL, nil
+	}
+	query, fragment, hasFragment := strings.Cut(query, "#")
+	if hasFragment {
+		base += "#" + fragment
+	}
+
+	var params []models.QueryParam
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		params = append(params, models.QueryParam{Key: key, Value: value})
+	}
+
+	return base, params
+}
+
+// NormalizeMethod upper-cases the standard methods and leaves custom
+// methods as typed, since methods are case-sensitive (RFC 9110, 9.1).
+func NormalizeMethod(method string) string {
+	method = strings.TrimSpace(method)
+	for _, m := range standardMethods {
+		if strings.EqualFold(m, method) {
+			return m
+		}
+	}
+
+	return method
+}
+
+// ValidMethod reports whether method is an RFC 9110 token.
+func ValidMethod(method string) bool {
+	return headers.IsToken(method)
+}
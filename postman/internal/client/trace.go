@@ -0,0 +1,109 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"postman/internal/domain/models"
+	"sync"
+	"time"
+)
+
+// tracer collects the phase timings and the written headers of a
+// request. With redirects every hop resets it, so it describes the
+// final request.
+type tracer struct {
+	mu sync.Mutex
+
+	start, dnsStart, connectStart, tlsStart time.Time
+	wroteRequest, firstByte                 time.Time
+
+	timings    models.Timings
+	headers    http.Header
+	remoteAddr string
+}
+
+func (t *tracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.start = time.Now()
+			t.timings = models.Timings{}
+			t.headers = http.Header{}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.DNS = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.Connect = time.Since(t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.TLS = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.remoteAddr = info.Conn.RemoteAddr().String()
+		},
+		WroteHeaderField: func(key string, value []string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			for _, v := range value {
+				t.headers.Add(textproto.CanonicalMIMEHeaderKey(key), v)
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wroteRequest = time.Now()
+			// everything between getting the connection and finishing the
+			// write, minus the connection setup, is sending
+			t.timings.Send = t.wroteRequest.Sub(t.start) - t.timings.DNS - t.timings.Connect - t.timings.TLS
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+			t.timings.Wait = t.firstByte.Sub(t.wroteRequest)
+		},
+	}
+}
+
+// finish records the receive phase once the body has been read and
+// returns the collected data.
+func (t *tracer) finish() (models.Timings, http.Header, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.firstByte.IsZero() {
+		t.timings.Receive = time.Since(t.firstByte)
+	}
+	if t.timings.Send < 0 {
+		t.timings.Send = 0
+	}
+
+	return t.timings, t.headers, t.remoteAddr
+}
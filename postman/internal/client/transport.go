@@ -0,0 +1,218 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"postman/internal/domain/models"
+	"postman/internal/lib/certs"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+var (
+	ErrInvalidProxy    = errors.New("invalid proxy")
+	ErrInvalidCABundle = errors.New("invalid CA bundle")
+)
+
+// transportKey holds the options a transport is built from.
+type transportKey struct {
+	proxy    string
+	noProxy  string
+	insecure bool
+	caBundle string
+	http2    bool
+	// certificate is the index of the client certificate, -1 for none.
+	certificate int
+}
+
+// transport returns a round tripper for the options. It picks the client
+// certificate by the host of every request, so a redirect to another
+// host never presents the certificate of the first one.
+func (c *Client) transport(options models.Options) (http.RoundTripper, error) {
+	key := transportKey{
+		proxy:       options.Proxy,
+		noProxy:     options.NoProxy,
+		insecure:    options.InsecureSkipVerify,
+		caBundle:    options.CABundle,
+		http2:       options.HTTP2 == nil || *options.HTTP2,
+		certificate: -1,
+	}
+
+	// build the transport without a certificate now, so invalid proxy
+	// and CA settings fail before anything is sent
+	if _, err := c.cachedTransport(key); err != nil {
+		return nil, err
+	}
+
+	return &certificateTransport{client: c, key: key}, nil
+}
+
+type certificateTransport struct {
+	client *Client
+	key    transportKey
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *certificateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.key
+	key.certificate = t.client.certificateFor(req.URL)
+
+	transport, err := t.client.cachedTransport(key)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return transport.RoundTrip(req)
+}
+
+// certificateFor returns the index of the first client certificate with
+// a host pattern matching the URL, or -1.
+func (c *Client) certificateFor(u *url.URL) int {
+	if u.Scheme != "https" {
+		return -1
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	for i, cert := range c.certificates {
+		for _, pattern := range cert.Hosts {
+			if certs.Match(pattern, u.Hostname(), port) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// cachedTransport returns the transport for the key, building it once.
+func (c *Client) cachedTransport(key transportKey) (*http.Transport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.transports[key]; ok {
+		return t, nil
+	}
+
+	t, err := c.newTransport(key)
+	if err != nil {
+		return nil, err
+	}
+	c.transports[key] = t
+
+	return t, nil
+}
+
+func (c *Client) newTransport(key transportKey) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := proxyFunc(key.proxy, key.noProxy)
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxy
+
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: key.insecure}
+	if key.caBundle != "" {
+		pool, err := loadCABundle(key.caBundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if key.certificate >= 0 {
+		cert, err := certs.Load(c.certificates[key.certificate])
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if key.http2 {
+		t.ForceAttemptHTTP2 = true
+	} else {
+		// a non-nil empty map disables the bundled HTTP/2 support
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return t, nil
+}
+
+// proxyFunc uses the proxy for http and https URLs, or the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables when proxy is empty.
+// A noProxy list replaces NO_PROXY. As with http.ProxyFromEnvironment,
+// requests to localhost and loopback addresses never use the proxy;
+// there is no override, a proxy reaches them by another address or name.
+func proxyFunc(proxy, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == models.ProxyDirect {
+		return nil, nil
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	if proxy != "" {
+		if err := ValidateProxy(proxy); err != nil {
+			return nil, err
+		}
+		cfg.HTTPProxy = proxy
+		cfg.HTTPSProxy = proxy
+	}
+	if noProxy != "" {
+		cfg.NoProxy = noProxy
+	}
+
+	f := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return f(req.URL)
+	}, nil
+}
+
+// ValidateProxy checks a proxy URL. A URL without a scheme is an HTTP proxy.
+func ValidateProxy(proxy string) error {
+	if proxy == models.ProxyDirect {
+		return nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidProxy, proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+
+	return fmt.Errorf("%w: unsupported scheme %q, expected http, https, socks5 or socks5h", ErrInvalidProxy, u.Scheme)
+}
+
+// loadCABundle adds the PEM certificates of the file to the system pool.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCABundle, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%w: no certificates in %s", ErrInvalidCABundle, path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,296 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const passphrase = "s3cret"
+
+// authority issues the client certificates the mTLS server trusts.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &authority{cert: cert, key: key}
+}
+
+// issue returns a client certificate with the common name and its key.
+func (a *authority) issue(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, key.Public(), a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// serverCA writes the certificate of a TLS test server as a CA bundle.
+func serverCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	return writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+// newTLSServer starts a TLS server that answers with the common name of
+// the client certificate, or "anonymous". Handshake errors, expected in
+// the failing cases, are not logged.
+func newTLSServer(t *testing.T, config *tls.Config) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			fmt.Fprint(w, "anonymous")
+			return
+		}
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = config
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newClient(certificates ...models.ClientCertificate) *client.Client {
+	return client.New(slog.New(slog.NewTextHandler(io.Discard, nil)), models.Options{}, certificates)
+}
+
+func get(c *client.Client, url string, options models.Options) (models.Response, error) {
+	return c.Do(context.Background(), models.Request{Method: http.MethodGet, URL: url, Options: options}, nil)
+}
+
+func TestServerVerification(t *testing.T) {
+	server := newTLSServer(t, nil)
+
+	// every httptest server has the same certificate, so another CA
+	// comes from a test authority
+	otherCA := writeFile(t, "other.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: newAuthority(t).cert.Raw}))
+	notPEM := writeFile(t, "ca.pem", []byte("not a certificate"))
+
+	tests := []struct {
+		name    string
+		options models.Options
+		wantErr error
+		// wantFail is set when the handshake fails without a known error.
+		wantFail bool
+	}{
+		{name: "untrusted certificate", wantFail: true},
+		{name: "insecure", options: models.Options{InsecureSkipVerify: true}},
+		{name: "CA bundle", options: models.Options{CABundle: serverCA(t, server)}},
+		{name: "CA bundle of another authority", options: models.Options{CABundle: otherCA}, wantFail: true},
+		{name: "CA bundle without certificates", options: models.Options{CABundle: notPEM}, wantErr: client.ErrInvalidCABundle},
+		{name: "missing CA bundle", options: models.Options{CABundle: filepath.Join(t.TempDir(), "missing.pem")}, wantErr: client.ErrInvalidCABundle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := get(newClient(), server.URL, tt.options)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantFail:
+				if err == nil {
+					t.Fatalf("Do() succeeded with %s", resp.Status)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+					t.Errorf("Do() = %s, TLS %v, want 200 over TLS", resp.Status, resp.TLS)
+				}
+			}
+		})
+	}
+}
+
+func TestClientCertificates(t *testing.T) {
+	ca := newAuthority(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	server := newTLSServer(t, &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	})
+	options := models.Options{CABundle: serverCA(t, server)}
+
+	// PEM certificate with an encrypted PKCS#8 key in a separate file
+	pemCert, pemKey := ca.issue(t, "pem client")
+	encrypted, err := pkcs8.MarshalPrivateKey(pemKey, []byte(passphrase), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := writeFile(t, "client.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pemCert.Raw}))
+	keyFile := writeFile(t, "client.key", pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}))
+
+	// PKCS#12 bundle
+	p12Cert, p12Key := ca.issue(t, "pkcs12 client")
+	p12, err := pkcs12.Modern.Encode(p12Key, p12Cert, nil, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12File := writeFile(t, "client.p12", p12)
+
+	// a certificate the server does not trust
+	untrustedCert, untrustedKey := newAuthority(t).issue(t, "untrusted client")
+	untrustedDER, err := x509.MarshalPKCS8PrivateKey(untrustedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrustedFile := writeFile(t, "untrusted.pem", append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: untrustedCert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: untrustedDER})...,
+	))
+
+	tests := []struct {
+		name        string
+		certificate models.ClientCertificate
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:    "no certificate",
+			wantErr: true,
+		},
+		{
+			name:        "PEM with encrypted PKCS#8 key",
+			certificate: models.ClientCertificate{Hosts: []string{"127.0.0.1"}, CertFile: certFile, KeyFile: keyFile, Passphrase: passphrase},
+			want:        "pem client",
+		},
+		{
+			name:        "PKCS#12",
+			certificate: models.ClientCertificate{Hosts: []string{"127.0.0.1"}, PKCS12File: p12File, Passphrase: passphrase},
+			want:        "pkcs12 client",
+		},
+		{
+			name:        "host pattern with the port",
+			certificate: models.ClientCertificate{Hosts: []string{server.Listener.Addr().String()}, PKCS12File: p12File, Passphrase: passphrase},
+			want:        "pkcs12 client",
+		},
+		{
+			name:        "certificate for another host",
+			certificate: models.ClientCertificate{Hosts: []string{"*.example.com"}, PKCS12File: p12File, Passphrase: passphrase},
+			wantErr:     true,
+		},
+		{
+			name:        "untrusted certificate",
+			certificate: models.ClientCertificate{Hosts: []string{"127.0.0.1"}, CertFile: untrustedFile},
+			wantErr:     true,
+		},
+		{
+			name:        "wrong passphrase",
+			certificate: models.ClientCertificate{Hosts: []string{"127.0.0.1"}, PKCS12File: p12File, Passphrase: "wrong"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *client.Client
+			if tt.certificate.Hosts == nil {
+				c = newClient()
+			} else {
+				c = newClient(tt.certificate)
+			}
+
+			resp, err := get(c, server.URL, options)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Do() succeeded with %s %q", resp.Status, resp.Body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Errorf("server saw %q, want %q", resp.Body, tt.want)
+			}
+			if resp.TLS == nil || resp.TLS.ClientCertificate == "" {
+				t.Errorf("Do() TLS = %+v, want the client certificate file", resp.TLS)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"postman/internal/domain/models"
+)
+
+type ICollectionsService interface {
+	GetCollections(ctx context.Context) ([]models.Collection, error)
+	GetCollection(ctx context.Context, name string) (models.Collection, error)
+	CreateCollection(ctx context.Context, name, description string) (models.Collection, error)
+	UpdateCollection(ctx context.Context, collection models.Collection) error
+	DeleteCollection(ctx context.Context, name string) error
+	GetRequest(ctx context.Context, path string) (models.SavedRequest, error)
+	SaveRequest(ctx context.Context, path string, request models.SavedRequest) error
+	DeleteRequest(ctx context.Context, path string) error
+	SetAuth(ctx context.Context, path string, auth *models.Auth) error
+	InheritedAuth(ctx context.Context, path string) (*models.Auth, error)
+}
+
+type IEnvironmentsService interface {
+	GetEnvironments(ctx context.Context) ([]models.Environment, error)
+	GetEnvironment(ctx context.Context, name string) (models.Environment, error)
+	CreateEnvironment(ctx context.Context, name string) (models.Environment, error)
+	DeleteEnvironment(ctx context.Context, name string) error
+	SetVariable(ctx context.Context, name string, variable models.Variable) error
+	UnsetVariable(ctx context.Context, name, key string) error
+	UseEnvironment(ctx context.Context, name string) error
+	ActiveEnvironment(ctx context.Context) (models.Environment, error)
+}
+
+type IRequestsService interface {
+	Send(ctx context.Context, request models.Request, scope models.Scope) (models.Exchange, error)
+	AddRecorder(recorder IExchangeRecorder)
+	RemoveRecorder(recorder IExchangeRecorder)
+	Resolve(ctx context.Context, request models.Request, scope models.Scope) (models.Request, []string, error)
+	Variables(ctx context.Context, scope models.Scope) (map[string]string, error)
+}
+
+// IExchangeRecorder is notified of every sent request, including the
+// ones that failed with a transport error.
+type IExchangeRecorder interface {
+	Record(ctx context.Context, exchange models.Exchange, sendErr error) error
+}
+
+type IRunnerService interface {
+	// Run sends the requests under path in order and checks their
+	// assertions, once per data row or once when data is empty.
+	// progress, when set, is called after every request.
+	Run(ctx context.Context, path string, scope models.Scope, data []map[string]string, progress func(models.RunResult)) (models.RunReport, error)
+}
+
+type IScriptsService interface {
+	// PreRequest runs a script that may change the request before it is resolved and sent.
+	PreRequest(ctx context.Context, name, script string, request *models.Request, scope models.Scope) (models.ScriptResult, error)
+	PostResponse(ctx context.Context, name, script string, exchange models.Exchange, scope models.Scope) (models.ScriptResult, error)
+}
+
+type IOAuth2Service interface {
+	// Token returns a token for an oauth2 auth, cached per environment
+	// (the active one when empty) and refreshed shortly before expiry.
+	// The token endpoint is reached with the transport options given.
+	Token(ctx context.Context, auth models.Auth, options models.Options, environment string) (models.Token, error)
+	ClearTokens(ctx context.Context, environment string) error
+}
+
+// ICookiesService manages the cookie jar of an environment, the active
+// one when the name is empty.
+type ICookiesService interface {
+	GetCookies(ctx context.Context, environment string) ([]models.Cookie, error)
+	SaveCookies(ctx context.Context, environment string, cookies []models.Cookie) error
+	// SetCookie adds a cookie or replaces the one with the same name, domain and path.
+	SetCookie(ctx context.Context, environment string, cookie models.Cookie) error
+	DeleteCookie(ctx context.Context, environment, domain, name string) error
+	// ClearCookies deletes the cookies of a domain, or all when domain is empty.
+	ClearCookies(ctx context.Context, environment, domain string) error
+}
+
+// IHistoryService records every sent request, see IExchangeRecorder.
+type IHistoryService interface {
+	IExchangeRecorder
+	// GetEntries returns the matching entries, newest first.
+	GetEntries(ctx context.Context, filter models.HistoryFilter) ([]models.HistoryEntry, error)
+	GetEntry(ctx context.Context, id int) (models.HistoryEntry, error)
+	ClearHistory(ctx context.Context) error
+}
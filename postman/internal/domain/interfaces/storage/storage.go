@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"postman/internal/domain/models"
+)
+
+type ICollectionsStorage interface {
+	GetCollections(ctx context.Context) ([]models.Collection, error)
+	GetCollection(ctx context.Context, name string) (models.Collection, error)
+	SaveCollection(ctx context.Context, collection models.Collection) error
+	DeleteCollection(ctx context.Context, name string) error
+}
+
+type IEnvironmentsStorage interface {
+	GetEnvironments(ctx context.Context) ([]models.Environment, error)
+	GetEnvironment(ctx context.Context, name string) (models.Environment, error)
+	SaveEnvironment(ctx context.Context, environment models.Environment) error
+	DeleteEnvironment(ctx context.Context, name string) error
+	GetActiveEnvironment(ctx context.Context) (string, error)
+	SetActiveEnvironment(ctx context.Context, name string) error
+}
+
+// ITokensStorage caches OAuth 2.0 tokens per environment. An empty
+// environment name stands for requests sent without an environment.
+type ITokensStorage interface {
+	GetTokens(ctx context.Context, environment string) (map[string]models.Token, error)
+	SaveTokens(ctx context.Context, environment string, tokens map[string]models.Token) error
+	DeleteTokens(ctx context.Context, environment string) error
+}
+
+// ICookiesStorage keeps a cookie jar per environment. An empty
+// environment name stands for requests sent without an environment.
+type ICookiesStorage interface {
+	GetCookies(ctx context.Context, environment string) ([]models.Cookie, error)
+	SaveCookies(ctx context.Context, environment string, cookies []models.Cookie) error
+}
+
+// IHistoryStorage keeps the history of sent requests, oldest first.
+type IHistoryStorage interface {
+	GetEntries(ctx context.Context) ([]models.HistoryEntry, error)
+	AddEntry(ctx context.Context, entry models.HistoryEntry) error
+	// IDs returns the IDs of the first and last entries, zero when the
+	// history is empty, without reading the whole history.
+	IDs(ctx context.Context) (first, last int, err error)
+	// SaveEntries replaces the whole history.
+	SaveEntries(ctx context.Context, entries []models.HistoryEntry) error
+}
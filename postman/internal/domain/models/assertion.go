@@ -0,0 +1,33 @@
+package models
+
+// Assertion subjects.
+const (
+	AssertStatus   = "status"
+	AssertHeader   = "header"
+	AssertJSONPath = "jsonpath"
+	AssertBody     = "body"
+	AssertTime     = "time"
+	// AssertScript is a test() call of a post-response script, Target
+	// holds the test name.
+	AssertScript = "script"
+	// AssertExtract fails when an extractor found no value, Target holds
+	// the variable name.
+	AssertExtract = "extract"
+)
+
+// Assertion is a check on a response, e.g. status in 200..299 or
+// jsonpath $.id exists.
+type Assertion struct {
+	Subject string `json:"subject"`
+	// Target is the header name or the JSONPath expression.
+	Target string `json:"target,omitempty"`
+	Op     string `json:"op"`
+	Value  string `json:"value,omitempty"`
+}
+
+type AssertionResult struct {
+	Assertion Assertion
+	Passed    bool
+	// Actual describes the checked value, or why the check could not run.
+	Actual string
+}
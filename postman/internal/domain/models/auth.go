@@ -0,0 +1,58 @@
+package models
+
+// Auth types.
+const (
+	AuthNone    = "none"
+	AuthInherit = "inherit"
+	AuthBasic   = "basic"
+	AuthBearer  = "bearer"
+	AuthAPIKey  = "apikey"
+	AuthDigest  = "digest"
+	AuthOAuth2  = "oauth2"
+)
+
+// OAuth 2.0 grant types.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantAuthorizationCode = "authorization_code"
+)
+
+// API key locations.
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// Auth configures how a request authenticates. Every field may hold
+// {{var}} placeholders, so credentials can live in secret environment
+// variables or, through {{$env.NAME}}, in process environment variables.
+type Auth struct {
+	Type string `json:"type"`
+	// Username and Password are used by basic, digest and the OAuth 2.0
+	// password grant.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Token is used by bearer.
+	Token string `json:"token,omitempty"`
+	// Key, Value and In are used by apikey.
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
+	In    string `json:"in,omitempty"`
+	// The remaining fields are used by oauth2. The authorization code
+	// grant always uses PKCE and a loopback redirect on RedirectPort,
+	// a random port when zero.
+	GrantType    string `json:"grant_type,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	AuthURL      string `json:"auth_url,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	RedirectPort int    `json:"redirect_port,omitempty"`
+}
+
+// Inherits reports whether the auth comes from the enclosing folder or
+// collection: a missing auth or the inherit type.
+func (a *Auth) Inherits() bool {
+	return a == nil || a.Type == AuthInherit || a.Type == ""
+}
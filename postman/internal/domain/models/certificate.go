@@ -0,0 +1,13 @@
+package models
+
+// ClientCertificate is a TLS client certificate presented to the hosts
+// matching one of Hosts, e.g. "api.internal", "*.internal" or
+// "api.internal:8443". The certificate comes either from PEM files or
+// from a PKCS#12 bundle; Passphrase decrypts the key or the bundle.
+type ClientCertificate struct {
+	Hosts      []string
+	CertFile   string
+	KeyFile    string
+	PKCS12File string
+	Passphrase string
+}
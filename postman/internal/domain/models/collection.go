@@ -0,0 +1,45 @@
+package models
+
+// CollectionVersion is the current version of the collection file format.
+const CollectionVersion = 1
+
+type Collection struct {
+	Version     int        `json:"version"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Variables   []Variable `json:"variables,omitempty"`
+	Auth        *Auth      `json:"auth,omitempty"`
+	// Options are the transport defaults of the collection requests.
+	Options  *Options       `json:"options,omitempty"`
+	Folders  []Folder       `json:"folders,omitempty"`
+	Requests []SavedRequest `json:"requests,omitempty"`
+}
+
+// VariablesMap returns the collection variables as a key/value map.
+func (c Collection) VariablesMap() map[string]string {
+	vars := make(map[string]string, len(c.Variables))
+	for _, v := range c.Variables {
+		vars[v.Key] = v.Value
+	}
+
+	return vars
+}
+
+type Folder struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Auth        *Auth          `json:"auth,omitempty"`
+	Folders     []Folder       `json:"folders,omitempty"`
+	Requests    []SavedRequest `json:"requests,omitempty"`
+}
+
+type SavedRequest struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Assertions  []Assertion `json:"assertions,omitempty"`
+	Extractors  []Extractor `json:"extractors,omitempty"`
+	// PreRequest and PostResponse are Starlark scripts run around sending.
+	PreRequest   string `json:"pre_request,omitempty"`
+	PostResponse string `json:"post_response,omitempty"`
+	Request
+}
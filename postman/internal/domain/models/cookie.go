@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Cookie is a stored cookie. A host-only cookie is sent to Domain only,
+// otherwise to its subdomains too. A zero Expires marks a session
+// cookie, which is kept until the jar is cleared.
+type Cookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	HostOnly bool      `json:"host_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+	SameSite string    `json:"same_site,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// Expired reports whether the cookie expired at now.
+func (c Cookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !now.Before(c.Expires)
+}
+
+// SameCookie reports whether two cookies have the same identity, so one
+// replaces the other.
+func (c Cookie) SameCookie(other Cookie) bool {
+	return c.Name == other.Name && c.Domain == other.Domain && c.Path == other.Path
+}
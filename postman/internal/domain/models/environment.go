@@ -0,0 +1,39 @@
+package models
+
+// EnvironmentVersion is the current version of the environment file format.
+const EnvironmentVersion = 1
+
+type Environment struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Variables []Variable `json:"variables,omitempty"`
+}
+
+type Variable struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Map returns the variables as a key/value map.
+func (e Environment) Map() map[string]string {
+	vars := make(map[string]string, len(e.Variables))
+	for _, v := range e.Variables {
+		vars[v.Key] = v.Value
+	}
+
+	return vars
+}
+
+// Secrets returns the secret variables that have a value as a key/value
+// map.
+func (e Environment) Secrets() map[string]string {
+	secrets := map[string]string{}
+	for _, v := range e.Variables {
+		if v.Secret && v.Value != "" {
+			secrets[v.Key] = v.Value
+		}
+	}
+
+	return secrets
+}
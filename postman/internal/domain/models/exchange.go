@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Scope selects the variables a request is resolved with.
+type Scope struct {
+	// Environment overrides the active environment when set.
+	Environment string
+	// Variables take precedence over the environment.
+	Variables map[string]string
+	// Defaults have the lowest precedence, e.g. collection variables.
+	Defaults map[string]string
+}
+
+// Exchange is a sent request together with its response.
+type Exchange struct {
+	StartedAt time.Time
+	// Environment is the name of the environment the request was
+	// resolved with, empty without one.
+	Environment string
+	// Source is the request before variable substitution.
+	Source Request
+	// Request is the request after variable substitution.
+	Request  Request
+	Response Response
+	// Unresolved lists the placeholders that had no value.
+	Unresolved []string
+}
@@ -0,0 +1,19 @@
+package models
+
+// Extractor sources.
+const (
+	ExtractJSONPath = "jsonpath"
+	ExtractRegex    = "regex"
+	ExtractHeader   = "header"
+	ExtractStatus   = "status"
+)
+
+// Extractor copies a value of the response into a variable that the
+// later requests of a run can reference.
+type Extractor struct {
+	Variable string `json:"variable"`
+	Source   string `json:"source"`
+	// Expression is the JSONPath, the regular expression or the header
+	// name, empty for the status.
+	Expression string `json:"expression,omitempty"`
+}
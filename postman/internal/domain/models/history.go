@@ -0,0 +1,49 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// HistoryEntry is a sent request with a snapshot of its response.
+type HistoryEntry struct {
+	ID          int       `json:"id"`
+	Time        time.Time `json:"time"`
+	Environment string    `json:"environment,omitempty"`
+	// Request is the request as sent, Source the request before variable
+	// substitution.
+	Request  Request       `json:"request"`
+	Source   Request       `json:"source"`
+	Status   int           `json:"status,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Error is the transport error of a request that got no response.
+	Error    string            `json:"error,omitempty"`
+	Response *ResponseSnapshot `json:"response,omitempty"`
+}
+
+// ResponseSnapshot is a response kept in history. The body is cut at
+// the size limit of the config.
+type ResponseSnapshot struct {
+	Proto     string      `json:"proto"`
+	Status    string      `json:"status"`
+	Headers   http.Header `json:"headers,omitempty"`
+	Body      []byte      `json:"body,omitempty"`
+	Size      int         `json:"size"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// HistoryFilter selects history entries. Zero fields match everything.
+type HistoryFilter struct {
+	Method string
+	// Host matches hosts that contain it.
+	Host string
+	// StatusMin and StatusMax bound the status, both inclusive.
+	StatusMin int
+	StatusMax int
+	// Failed selects the requests that got no response.
+	Failed bool
+	Since  time.Time
+	Until  time.Time
+	// Limit is the number of newest entries to return.
+	Limit int
+}
@@ -0,0 +1,125 @@
+package models
+
+import "time"
+
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type QueryParam struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// PathParam sets a path parameter, ":id" or "{id}" in the URL. Parameters
+// without a PathParam or with an empty value take the variable of the
+// same name.
+type PathParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Options configure the transport of a request. Unset fields inherit
+// from the collection and then from the config file.
+type Options struct {
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// FollowRedirects follows up to MaxRedirects redirects when true.
+	// MaxRedirects 0 inherits the limit, which is 10 when nothing sets
+	// it; FollowRedirects false turns redirects off.
+	FollowRedirects *bool `json:"follow_redirects,omitempty"`
+	MaxRedirects    int   `json:"max_redirects,omitempty"`
+	// Proxy is an http, https, socks5 or socks5h URL, or "direct" to
+	// ignore the proxy settings of the config and the OS environment.
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy lists hosts, domains and CIDRs that bypass the proxy.
+	NoProxy            string `json:"no_proxy,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	// CABundle is a PEM file with certificates trusted in addition to
+	// the system pool.
+	CABundle string `json:"ca_bundle,omitempty"`
+	HTTP2    *bool  `json:"http2,omitempty"`
+}
+
+// ProxyDirect disables the configured proxy for a request.
+const ProxyDirect = "direct"
+
+// Inherit returns the options with the unset fields taken from parent.
+// InsecureSkipVerify is on when either has it on.
+func (o Options) Inherit(parent Options) Options {
+	if o.Timeout == 0 {
+		o.Timeout = parent.Timeout
+	}
+	if o.FollowRedirects == nil {
+		o.FollowRedirects = parent.FollowRedirects
+	}
+	if o.MaxRedirects == 0 {
+		o.MaxRedirects = parent.MaxRedirects
+	}
+	if o.Proxy == "" {
+		o.Proxy = parent.Proxy
+	}
+	if o.NoProxy == "" {
+		o.NoProxy = parent.NoProxy
+	}
+	o.InsecureSkipVerify = o.InsecureSkipVerify || parent.InsecureSkipVerify
+	if o.CABundle == "" {
+		o.CABundle = parent.CABundle
+	}
+	if o.HTTP2 == nil {
+		o.HTTP2 = parent.HTTP2
+	}
+
+	return o
+}
+
+// Body modes. An empty mode is a raw body.
+const (
+	BodyRaw        = "raw"
+	BodyURLEncoded = "urlencoded"
+	BodyFormData   = "formdata"
+	BodyBinary     = "binary"
+)
+
+// FormField is a field of an urlencoded or multipart body. A multipart
+// field with File set is a file part, read from disk when sending.
+type FormField struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	File        string `json:"file,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type Request struct {
+	Method  string       `json:"method"`
+	URL     string       `json:"url"`
+	Headers []Header     `json:"headers,omitempty"`
+	Query   []QueryParam `json:"query,omitempty"`
+	// PathParams hold values of the path parameters of URL.
+	PathParams []PathParam `json:"path_params,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyMode selects how the body is sent, see the Body constants.
+	BodyMode string `json:"body_mode,omitempty"`
+	// ContentType of a raw or binary body, used when no header sets one.
+	ContentType string      `json:"content_type,omitempty"`
+	Form        []FormField `json:"form,omitempty"`
+	// BodyFile is the file sent as a binary body.
+	BodyFile string `json:"body_file,omitempty"`
+	// Auth is applied when sending, nil inherits the folder or collection auth.
+	Auth    *Auth   `json:"auth,omitempty"`
+	Options Options `json:"options,omitempty"`
+}
+
+// HasBody reports whether the request has a body to send.
+func (r Request) HasBody() bool {
+	switch r.BodyMode {
+	case BodyURLEncoded, BodyFormData:
+		return len(r.Form) > 0
+	case BodyBinary:
+		return r.BodyFile != ""
+	}
+
+	return r.Body != ""
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+type Response struct {
+	Proto      string
+	StatusCode int
+	Status     string
+	Headers    http.Header
+	Body       []byte
+	Duration   time.Duration
+	Timings    Timings
+	// RequestMethod and RequestURL are the method and URL of the final
+	// request, Redirected is set when it followed a redirect.
+	RequestMethod string
+	RequestURL    string
+	Redirected    bool
+	// RequestHeaders are the headers as written on the wire, including
+	// the ones added by the transport such as User-Agent.
+	RequestHeaders http.Header
+	RemoteAddr     string
+	// TLS describes the connection of an https response, nil otherwise.
+	TLS *TLSInfo
+}
+
+// TLSInfo is the negotiated TLS connection of a response.
+type TLSInfo struct {
+	Version     string
+	CipherSuite string
+	ServerName  string
+	// Protocol is the ALPN protocol, e.g. "h2".
+	Protocol string
+	// PeerCertificates is the chain sent by the server, leaf first.
+	PeerCertificates []CertificateInfo
+	// ClientCertificate is the file of the client certificate configured
+	// for the host, empty when there is none.
+	ClientCertificate string
+}
+
+type CertificateInfo struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	DNSNames  []string
+}
+
+// Timings split Duration into the phases of the final request.
+// Phases that did not happen, e.g. DNS on a reused connection, are zero.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Send    time.Duration
+	Wait    time.Duration
+	Receive time.Duration
+}
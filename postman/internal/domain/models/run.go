@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Run outcomes.
+const (
+	OutcomePassed  = "passed"
+	OutcomeFailed  = "failed"
+	OutcomeErrored = "errored"
+)
+
+// RunResult is the result of one request in a collection run.
+type RunResult struct {
+	// Path is collection/[folder/...]name of the saved request.
+	Path string
+	// Iteration is the 1-based data row, 0 for a run without data.
+	Iteration int
+	// Request is the request after variable substitution.
+	Request    Request
+	StatusCode int
+	Duration   time.Duration
+	Assertions []AssertionResult
+	// Error is the transport or script error of an errored request.
+	Error string
+	// Logs are the lines printed by the scripts.
+	Logs    []string
+	Outcome string
+}
+
+type RunReport struct {
+	// Name is the collection or folder path that was run.
+	Name      string
+	StartedAt time.Time
+	Duration  time.Duration
+	// Iterations is the number of data rows, 0 for a run without data.
+	Iterations int
+	Results    []RunResult
+}
+
+// Count returns the number of results with the outcome.
+func (r RunReport) Count(outcome string) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Outcome == outcome {
+			n++
+		}
+	}
+
+	return n
+}
+
+// FailedIterations returns the number of iterations with a result that
+// did not pass.
+func (r RunReport) FailedIterations() int {
+	failed := map[int]bool{}
+	for _, res := range r.Results {
+		if res.Outcome != OutcomePassed {
+			failed[res.Iteration] = true
+		}
+	}
+
+	return len(failed)
+}
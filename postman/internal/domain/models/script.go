@@ -0,0 +1,13 @@
+package models
+
+// ScriptResult is what a pre-request or post-response script produced.
+type ScriptResult struct {
+	// Variables were set with vars.set and live for the rest of the run.
+	Variables map[string]string
+	// Environment was set with env.set and is saved to the environment.
+	Environment map[string]string
+	// Tests are the results of test() calls.
+	Tests []AssertionResult
+	// Logs are the lines written with print().
+	Logs []string
+}
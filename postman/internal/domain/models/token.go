@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Token is a cached OAuth 2.0 token.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+// ValidFor reports whether the token is still valid after d. A token
+// without an expiry never expires.
+func (t Token) ValidFor(d time.Duration) bool {
+	if t.AccessToken == "" {
+		return false
+	}
+
+	return t.ExpiresAt.IsZero() || time.Now().Add(d).Before(t.ExpiresAt)
+}
@@ -0,0 +1,352 @@
+package assertions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/lib/jsonpath"
+	"postman/internal/lib/shellwords"
+	"postman/internal/lib/variables"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAssertion = errors.New("invalid assertion")
+
+// Syntax describes the assertion expressions accepted by Parse.
+const Syntax = `status == 200 | status != 500 | status in 200..299
+header NAME exists | header NAME == VALUE | header NAME contains TEXT | header NAME ~ REGEX
+jsonpath PATH exists | jsonpath PATH == VALUE | jsonpath PATH != VALUE | jsonpath PATH ~ REGEX | jsonpath PATH type TYPE
+body contains TEXT | body ~ REGEX
+time < MS`
+
+// ops lists the operators each subject accepts.
+var ops = map[string][]string{
+	models.AssertStatus:   {"==", "!=", "in"},
+	models.AssertHeader:   {"exists", "==", "contains", "~"},
+	models.AssertJSONPath: {"exists", "==", "!=", "~", "type"},
+	models.AssertBody:     {"contains", "~"},
+	models.AssertTime:     {"<"},
+}
+
+var jsonTypes = []string{"string", "number", "boolean", "null", "object", "array"}
+
+// Parse reads an assertion expression, see Syntax. Values with spaces
+// are quoted the way a shell would quote them.
+func Parse(expr string) (models.Assertion, error) {
+	words, err := shellwords.Split(expr)
+	if err != nil {
+		return models.Assertion{}, fmt.Errorf("%w: %q: %w", ErrInvalidAssertion, expr, err)
+	}
+	if len(words) < 2 {
+		return models.Assertion{}, fmt.Errorf("%w: %q", ErrInvalidAssertion, expr)
+	}
+
+	a := models.Assertion{Subject: strings.ToLower(words[0])}
+	if _, ok := ops[a.Subject]; !ok {
+		return models.Assertion{}, fmt.Errorf("%w: %q: unknown subject %q", ErrInvalidAssertion, expr, words[0])
+	}
+
+	rest := words[1:]
+	if a.Subject == models.AssertHeader || a.Subject == models.AssertJSONPath {
+		a.Target = rest[0]
+		rest = rest[1:]
+		if len(rest) == 0 {
+			return models.Assertion{}, fmt.Errorf("%w: %q: operator expected", ErrInvalidAssertion, expr)
+		}
+	}
+
+	a.Op = rest[0]
+	a.Value = strings.Join(rest[1:], " ")
+
+	if err := Validate(a); err != nil {
+		return models.Assertion{}, err
+	}
+
+	return a, nil
+}
+
+// Validate checks the operator and the value of an assertion. A target
+// or value with {{var}} placeholders is checked by Check once the
+// variables are substituted.
+func Validate(a models.Assertion) error {
+	return validate(a, true)
+}
+
+// validate checks an assertion, skipping the values with placeholders
+// when deferred is set.
+func validate(a models.Assertion, deferred bool) error {
+	allowed, ok := ops[a.Subject]
+	if !ok {
+		return fmt.Errorf("%w: unknown subject %q", ErrInvalidAssertion, a.Subject)
+	}
+	if !slices.Contains(allowed, a.Op) {
+		return fmt.Errorf("%w: %s does not support %q, use one of %s", ErrInvalidAssertion, a.Subject, a.Op, strings.Join(allowed, " "))
+	}
+	if a.Op != "exists" && a.Value == "" && a.Subject != models.AssertBody {
+		return fmt.Errorf("%w: %s %s requires a value", ErrInvalidAssertion, a.Subject, a.Op)
+	}
+
+	var err error
+	switch {
+	case deferred && hasPlaceholder(a.Value):
+	case a.Op == "~":
+		_, err = regexp.Compile(a.Value)
+	case a.Subject == models.AssertStatus && a.Op == "in":
+		_, _, err = statusRange(a.Value)
+	case a.Subject == models.AssertStatus:
+		_, err = strconv.Atoi(a.Value)
+	case a.Subject == models.AssertTime:
+		_, err = milliseconds(a.Value)
+	case a.Op == "type" && !slices.Contains(jsonTypes, a.Value):
+		err = fmt.Errorf("type must be one of %s", strings.Join(jsonTypes, ", "))
+	}
+	if err == nil && a.Subject == models.AssertJSONPath && !(deferred && hasPlaceholder(a.Target)) {
+		_, err = jsonpath.Compile(a.Target)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidAssertion, String(a), err)
+	}
+
+	return nil
+}
+
+// String formats an assertion back into the expression syntax.
+func String(a models.Assertion) string {
+	switch a.Subject {
+	case models.AssertScript:
+		return "test " + quote(a.Target)
+	case models.AssertExtract:
+		return "extract " + a.Target
+	}
+
+	parts := []string{a.Subject}
+	if a.Target != "" {
+		parts = append(parts, a.Target)
+	}
+	parts = append(parts, a.Op)
+	if a.Value != "" {
+		parts = append(parts, quote(a.Value))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Check evaluates every assertion against the response, after replacing
+// the {{var}} placeholders of targets and values with vars.
+func Check(list []models.Assertion, resp models.Response, vars map[string]string) []models.AssertionResult {
+	results := make([]models.AssertionResult, 0, len(list))
+
+	var (
+		doc    any
+		docErr error
+		parsed bool
+	)
+	for _, a := range list {
+		if a.Subject == models.AssertJSONPath && !parsed {
+			doc, docErr = jsonpath.Decode(resp.Body)
+			parsed = true
+		}
+
+		resolved, missing := substitute(a, vars)
+
+		var result models.AssertionResult
+		switch {
+		case len(missing) > 0:
+			result = models.AssertionResult{Actual: "unresolved variables: " + strings.Join(missing, ", ")}
+		case a.Subject == models.AssertJSONPath && docErr != nil:
+			result = models.AssertionResult{Actual: "body is not JSON: " + docErr.Error()}
+		default:
+			result = check(resolved, resp, doc)
+		}
+		result.Assertion = a
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Passed reports whether every result passed.
+func Passed(results []models.AssertionResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// substitute replaces the placeholders of the target and the value and
+// returns the names left unresolved.
+func substitute(a models.Assertion, vars map[string]string) (models.Assertion, []string) {
+	var missing, m []string
+	a.Target, missing = variables.Substitute(a.Target, vars)
+	a.Value, m = variables.Substitute(a.Value, vars)
+
+	return a, append(missing, m...)
+}
+
+func hasPlaceholder(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// check evaluates an assertion with its variables substituted. Values
+// that still do not compile, e.g. a regex built from a variable, fail
+// the assertion.
+func check(a models.Assertion, resp models.Response, doc any) models.AssertionResult {
+	if err := validate(a, false); err != nil {
+		return models.AssertionResult{Actual: err.Error()}
+	}
+
+	var (
+		re   *regexp.Regexp
+		path jsonpath.Path
+		err  error
+	)
+	if a.Op == "~" {
+		re, err = regexp.Compile(a.Value)
+	}
+	if err == nil && a.Subject == models.AssertJSONPath {
+		path, err = jsonpath.Compile(a.Target)
+	}
+	if err != nil {
+		return models.AssertionResult{Actual: err.Error()}
+	}
+
+	switch a.Subject {
+	case models.AssertStatus:
+		actual := strconv.Itoa(resp.StatusCode)
+		switch a.Op {
+		case "in":
+			low, high, _ := statusRange(a.Value)
+			return result(resp.StatusCode >= low && resp.StatusCode <= high, actual)
+		case "!=":
+			return result(actual != a.Value, actual)
+		default:
+			return result(actual == a.Value, actual)
+		}
+
+	case models.AssertHeader:
+		values, ok := resp.Headers[http.CanonicalHeaderKey(a.Target)]
+		if !ok {
+			return result(false, "header is missing")
+		}
+		actual := strings.Join(values, ", ")
+		switch a.Op {
+		case "exists":
+			return result(true, actual)
+		case "contains":
+			return result(strings.Contains(actual, a.Value), actual)
+		case "~":
+			return result(re.MatchString(actual), actual)
+		default:
+			return result(actual == a.Value, actual)
+		}
+
+	case models.AssertJSONPath:
+		values := path.Get(doc)
+		if len(values) == 0 {
+			// != holds for a missing value
+			return result(a.Op == "!=", "no match")
+		}
+		actual := values[0]
+		switch a.Op {
+		case "exists":
+			return result(true, jsonpath.Format(actual))
+		case "type":
+			return result(jsonpath.TypeOf(actual) == a.Value, jsonpath.TypeOf(actual))
+		case "~":
+			return result(re.MatchString(jsonpath.Format(actual)), jsonpath.Format(actual))
+		case "!=":
+			return result(!equalValue(actual, a.Value), jsonpath.Format(actual))
+		default:
+			return result(equalValue(actual, a.Value), jsonpath.Format(actual))
+		}
+
+	case models.AssertBody:
+		actual := fmt.Sprintf("%d bytes", len(resp.Body))
+		if a.Op == "~" {
+			return result(re.Match(resp.Body), actual)
+		}
+		return result(strings.Contains(string(resp.Body), a.Value), actual)
+
+	case models.AssertTime:
+		limit, _ := milliseconds(a.Value)
+		actual := fmt.Sprintf("%d ms", resp.Duration.Milliseconds())
+		return result(resp.Duration < limit, actual)
+	}
+
+	return models.AssertionResult{}
+}
+
+func result(passed bool, actual string) models.AssertionResult {
+	return models.AssertionResult{Passed: passed, Actual: actual}
+}
+
+// equalValue compares with a JSON literal, falling back to the plain
+// string for unquoted text, so both "alice" and alice match a string.
+func equalValue(actual any, expected string) bool {
+	var want any
+	if v, err := jsonpath.Decode([]byte(expected)); err == nil {
+		want = v
+	} else {
+		want = expected
+	}
+
+	if jsonpath.Equal(actual, want) {
+		return true
+	}
+
+	s, ok := actual.(string)
+	return ok && s == expected
+}
+
+// statusRange parses "200..299", "2xx" or a single code.
+func statusRange(s string) (int, int, error) {
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		d, err := strconv.Atoi(s[:1])
+		if err != nil {
+			return 0, 0, err
+		}
+		return d * 100, d*100 + 99, nil
+	}
+
+	lowStr, highStr, found := strings.Cut(s, "..")
+	if !found {
+		highStr = lowStr
+	}
+
+	low, err := strconv.Atoi(lowStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := strconv.Atoi(highStr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return low, high, nil
+}
+
+// milliseconds accepts a plain number of milliseconds or a duration like 2s.
+func milliseconds(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Millisecond, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// quote single-quotes values that Parse would otherwise split.
+func quote(s string) string {
+	if !strings.ContainsAny(s, " \t\n'\"\\") {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
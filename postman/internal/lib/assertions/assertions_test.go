@@ -0,0 +1,159 @@
+package assertions_test
+
+import (
+	"errors"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    models.Assertion
+		wantErr bool
+	}{
+		{expr: "status == 200", want: models.Assertion{Subject: "status", Op: "==", Value: "200"}},
+		{expr: "STATUS in 2xx", want: models.Assertion{Subject: "status", Op: "in", Value: "2xx"}},
+		{expr: "status in 200..299", want: models.Assertion{Subject: "status", Op: "in", Value: "200..299"}},
+		{expr: "header Content-Type contains json", want: models.Assertion{Subject: "header", Target: "Content-Type", Op: "contains", Value: "json"}},
+		{expr: "header X-Id exists", want: models.Assertion{Subject: "header", Target: "X-Id", Op: "exists"}},
+		{expr: "jsonpath $.name == 'alice smith'", want: models.Assertion{Subject: "jsonpath", Target: "$.name", Op: "==", Value: "alice smith"}},
+		{expr: "jsonpath $.id type number", want: models.Assertion{Subject: "jsonpath", Target: "$.id", Op: "type", Value: "number"}},
+		{expr: "jsonpath $.login == {{login}}", want: models.Assertion{Subject: "jsonpath", Target: "$.login", Op: "==", Value: "{{login}}"}},
+		{expr: "body ~ '^\\{'", want: models.Assertion{Subject: "body", Op: "~", Value: "^\\{"}},
+		{expr: "body ~ '({{'", want: models.Assertion{Subject: "body", Op: "~", Value: "({{"}},
+		{expr: "time < 2s", want: models.Assertion{Subject: "time", Op: "<", Value: "2s"}},
+
+		{expr: "status", wantErr: true},
+		{expr: "latency < 100", wantErr: true},
+		{expr: "status > 200", wantErr: true},
+		{expr: "status == ok", wantErr: true},
+		{expr: "status in 200-299", wantErr: true},
+		{expr: "header X-Id", wantErr: true},
+		{expr: "header X-Id ==", wantErr: true},
+		{expr: "jsonpath $.id type integer", wantErr: true},
+		{expr: "jsonpath $[ exists", wantErr: true},
+		{expr: "body ~ (", wantErr: true},
+		{expr: "time < soon", wantErr: true},
+		{expr: "body contains 'unterminated", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := assertions.Parse(tt.expr)
+			if tt.wantErr {
+				if !errors.Is(err, assertions.ErrInvalidAssertion) {
+					t.Fatalf("Parse() error = %v, want ErrInvalidAssertion", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+
+			again, err := assertions.Parse(assertions.String(got))
+			if err != nil || again != got {
+				t.Errorf("Parse(String()) = %+v, %v, want %+v", again, err, got)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	resp := models.Response{
+		StatusCode: http.StatusCreated,
+		Headers:    http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:       []byte(`{"id": 7, "login": "alice", "tags": ["a", "b"], "admin": false}`),
+		Duration:   120 * time.Millisecond,
+	}
+	vars := map[string]string{"login": "alice", "field": "login", "paren": "("}
+
+	tests := []struct {
+		expr       string
+		wantPassed bool
+		wantActual string
+	}{
+		{"status == 201", true, "201"},
+		{"status != 201", false, "201"},
+		{"status in 2xx", true, "201"},
+		{"status in 300..399", false, "201"},
+		{"header content-type contains json", true, "application/json; charset=utf-8"},
+		{"header Content-Type ~ ^application/", true, "application/json; charset=utf-8"},
+		{"header X-Missing exists", false, "header is missing"},
+		{"jsonpath $.id == 7", true, "7"},
+		{"jsonpath $.login == alice", true, "alice"},
+		{`jsonpath $.login == '"alice"'`, true, "alice"},
+		{"jsonpath $.tags[1] == b", true, "b"},
+		{"jsonpath $.admin type boolean", true, "boolean"},
+		{"jsonpath $.missing exists", false, "no match"},
+		{"jsonpath $.missing != 1", true, "no match"},
+		{"body contains alice", true, "63 bytes"},
+		{"time < 100", false, "120 ms"},
+		{"time < 1s", true, "120 ms"},
+
+		// variables
+		{"jsonpath $.login == {{login}}", true, "alice"},
+		{"jsonpath $.{{field}} exists", true, "alice"},
+		{"jsonpath $.login == {{nobody}}", false, "unresolved variables: nobody"},
+
+		// values that only fail to compile once substituted
+		{"body ~ '({{'", false, "error parsing regexp: missing closing ): `({{`"},
+		{"body ~ {{paren}}", false, "error parsing regexp: missing closing ): `(`"},
+		{"header Content-Type ~ '[{{'", false, "error parsing regexp: missing closing ]: `[{{`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a, err := assertions.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			results := assertions.Check([]models.Assertion{a}, resp, vars)
+			if len(results) != 1 {
+				t.Fatalf("Check() returned %d results, want 1", len(results))
+			}
+			got := results[0]
+			if got.Passed != tt.wantPassed {
+				t.Errorf("Check() passed = %v, want %v (actual %q)", got.Passed, tt.wantPassed, got.Actual)
+			}
+			if got.Actual != tt.wantActual && !strings.HasSuffix(got.Actual, tt.wantActual) {
+				t.Errorf("Check() actual = %q, want %q", got.Actual, tt.wantActual)
+			}
+			if got.Assertion != a {
+				t.Errorf("Check() assertion = %+v, want %+v as written", got.Assertion, a)
+			}
+		})
+	}
+}
+
+func TestCheckInvalidJSONPathAfterSubstitution(t *testing.T) {
+	a := models.Assertion{Subject: models.AssertJSONPath, Target: "$.{{field}}", Op: "exists"}
+	if err := assertions.Validate(a); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	results := assertions.Check([]models.Assertion{a}, models.Response{Body: []byte(`{}`)}, map[string]string{"field": "["})
+	if results[0].Passed {
+		t.Errorf("Check() passed with the path $.[")
+	}
+}
+
+func TestCheckNotJSON(t *testing.T) {
+	a, err := assertions.Parse("jsonpath $.id exists")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := assertions.Check([]models.Assertion{a}, models.Response{Body: []byte("<html>")}, nil)
+	if results[0].Passed || assertions.Passed(results) {
+		t.Errorf("Check() passed on a body that is not JSON: %q", results[0].Actual)
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported auth type")
+	ErrInvalidAuth     = errors.New("invalid auth")
+	ErrNoChallenge     = errors.New("no digest challenge")
+)
+
+// Types lists the auth types a request can be configured with.
+var Types = []string{models.AuthNone, models.AuthInherit, models.AuthBasic, models.AuthBearer, models.AuthAPIKey, models.AuthDigest, models.AuthOAuth2}
+
+// Validate checks that the fields required by the auth type are set.
+func Validate(a *models.Auth) error {
+	if a == nil {
+		return nil
+	}
+
+	switch a.Type {
+	case "", models.AuthNone, models.AuthInherit, models.AuthBasic, models.AuthDigest:
+		return nil
+	case models.AuthBearer:
+		if a.Token == "" {
+			return fmt.Errorf("%w: bearer requires a token", ErrInvalidAuth)
+		}
+	case models.AuthAPIKey:
+		if a.Key == "" {
+			return fmt.Errorf("%w: apikey requires a key", ErrInvalidAuth)
+		}
+		if a.In != "" && a.In != models.APIKeyInHeader && a.In != models.APIKeyInQuery {
+			return fmt.Errorf("%w: apikey location must be header or query, got %q", ErrInvalidAuth, a.In)
+		}
+	case models.AuthOAuth2:
+		if a.TokenURL == "" {
+			return fmt.Errorf("%w: oauth2 requires a token URL", ErrInvalidAuth)
+		}
+		switch a.GrantType {
+		case models.GrantClientCredentials:
+		case models.GrantPassword:
+			if a.Username == "" {
+				return fmt.Errorf("%w: the password grant requires a user", ErrInvalidAuth)
+			}
+		case models.GrantAuthorizationCode:
+			if a.AuthURL == "" {
+				return fmt.Errorf("%w: the authorization code grant requires an authorization URL", ErrInvalidAuth)
+			}
+		default:
+			return fmt.Errorf("%w: oauth2 grant must be %s, %s or %s, got %q", ErrInvalidAuth,
+				models.GrantClientCredentials, models.GrantPassword, models.GrantAuthorizationCode, a.GrantType)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedType, a.Type)
+	}
+
+	return nil
+}
+
+// Apply adds the credentials to the request. Digest needs the server
+// challenge first and is applied with Digest on a 401 response. OAuth 2.0
+// is replaced by a bearer token before the request is built.
+func Apply(req *http.Request, a *models.Auth) error {
+	if a == nil {
+		return nil
+	}
+	if err := Validate(a); err != nil {
+		return err
+	}
+
+	switch a.Type {
+	case models.AuthBasic:
+		req.SetBasicAuth(a.Username, a.Password)
+
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+
+	case models.AuthAPIKey:
+		if a.In == models.APIKeyInQuery {
+			pair := url.QueryEscape(a.Key) + "=" + url.QueryEscape(a.Value)
+			if req.URL.RawQuery == "" {
+				req.URL.RawQuery = pair
+			} else {
+				req.URL.RawQuery += "&" + pair
+			}
+		} else {
+			req.Header.Set(a.Key, a.Value)
+		}
+	}
+
+	return nil
+}
+
+// Describe returns a one-line summary of the auth without secrets.
+func Describe(a *models.Auth) string {
+	if a == nil {
+		return models.AuthInherit
+	}
+
+	switch a.Type {
+	case models.AuthBasic, models.AuthDigest:
+		return a.Type + " " + a.Username
+	case models.AuthAPIKey:
+		in := a.In
+		if in == "" {
+			in = models.APIKeyInHeader
+		}
+		return fmt.Sprintf("apikey %s in %s", a.Key, in)
+	case models.AuthOAuth2:
+		return fmt.Sprintf("oauth2 %s %s", a.GrantType, a.ClientID)
+	case "":
+		return models.AuthInherit
+	}
+
+	return a.Type
+}
+
+// Digest answers a WWW-Authenticate Digest challenge (RFC 7616) with the
+// value of the Authorization header for the request.
+func Digest(challenge string, a *models.Auth, method, uri string) (string, error) {
+	scheme, params, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return "", ErrNoChallenge
+	}
+	c := parseChallenge(params)
+
+	var newHash func() hash.Hash
+	algorithm := strings.ToUpper(c["algorithm"])
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("%w: digest algorithm %q", ErrUnsupportedType, c["algorithm"])
+	}
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	qop := ""
+	for _, q := range strings.Split(c["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+	if c["qop"] != "" && qop == "" {
+		return "", fmt.Errorf("%w: digest qop %q", ErrUnsupportedType, c["qop"])
+	}
+
+	cnonce := randomHex(8)
+	nc := "00000001"
+
+	ha1 := h(a.Username + ":" + c["realm"] + ":" + a.Password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c["nonce"] + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + c["nonce"] + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c["nonce"] + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf("username=%q", a.Username),
+		fmt.Sprintf("realm=%q", c["realm"]),
+		fmt.Sprintf("nonce=%q", c["nonce"]),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("response=%q", response),
+	}
+	if c["algorithm"] != "" {
+		parts = append(parts, "algorithm="+c["algorithm"])
+	}
+	if c["opaque"] != "" {
+		parts = append(parts, fmt.Sprintf("opaque=%q", c["opaque"]))
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// parseChallenge splits key=value and key="quoted, value" pairs.
+func parseChallenge(s string) map[string]string {
+	result := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, found := strings.Cut(s, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		result[key] = strings.TrimSpace(value)
+	}
+
+	return result
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
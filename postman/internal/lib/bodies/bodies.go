@@ -0,0 +1,233 @@
+package bodies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+var ErrInvalidBody = errors.New("invalid body")
+
+// Syntax describes the form field notation accepted by ParseField.
+const Syntax = `key=value, key=@file or key=@file;type=content/type`
+
+// DefaultContentType is sent with a raw body that sets no content type.
+const DefaultContentType = "application/json"
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Validate checks the body mode and its fields.
+func Validate(request models.Request) error {
+	switch request.BodyMode {
+	case "", models.BodyRaw:
+	case models.BodyURLEncoded:
+		for _, f := range request.Form {
+			if f.File != "" {
+				return fmt.Errorf("%w: file field %q in an urlencoded body", ErrInvalidBody, f.Key)
+			}
+		}
+	case models.BodyFormData:
+		for _, f := range request.Form {
+			if f.Key == "" {
+				return fmt.Errorf("%w: form field without a name", ErrInvalidBody)
+			}
+		}
+	case models.BodyBinary:
+		if request.BodyFile == "" {
+			return fmt.Errorf("%w: binary body without a file", ErrInvalidBody)
+		}
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidBody, request.BodyMode)
+	}
+
+	return nil
+}
+
+// Encode returns the body of the request and its content type. Files
+// are read when encoding, and every multipart body gets a new boundary.
+// A request without a body returns nil data.
+func Encode(request models.Request) ([]byte, string, error) {
+	if err := Validate(request); err != nil {
+		return nil, "", err
+	}
+	if !request.HasBody() {
+		return nil, "", nil
+	}
+
+	switch request.BodyMode {
+	case models.BodyURLEncoded:
+		return []byte(URLEncode(request.Form)), "application/x-www-form-urlencoded", nil
+
+	case models.BodyFormData:
+		return multipartBody(request.Form)
+
+	case models.BodyBinary:
+		data, err := os.ReadFile(request.BodyFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %w", ErrInvalidBody, err)
+		}
+		return data, FileContentType(request.BodyFile, request.ContentType), nil
+	}
+
+	contentType := request.ContentType
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+
+	return []byte(request.Body), contentType, nil
+}
+
+// URLEncode joins the enabled fields in order. Unlike url.Values.Encode
+// it keeps the order and repeated keys as given.
+func URLEncode(fields []models.FormField) string {
+	pairs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Disabled {
+			continue
+		}
+		pairs = append(pairs, url.QueryEscape(f.Key)+"="+url.QueryEscape(f.Value))
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func multipartBody(fields []models.FormField) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for _, f := range fields {
+		if f.Disabled {
+			continue
+		}
+
+		h := make(textproto.MIMEHeader)
+		content := []byte(f.Value)
+		if f.File != "" {
+			var err error
+			if content, err = os.ReadFile(f.File); err != nil {
+				return nil, "", fmt.Errorf("%w: field %q: %w", ErrInvalidBody, f.Key, err)
+			}
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+				quoteEscaper.Replace(f.Key), quoteEscaper.Replace(filepath.Base(f.File))))
+			h.Set("Content-Type", FileContentType(f.File, f.ContentType))
+		} else {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(f.Key)))
+			if f.ContentType != "" {
+				h.Set("Content-Type", f.ContentType)
+			}
+		}
+
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// FileContentType returns contentType when set, otherwise the type
+// registered for the file extension or application/octet-stream.
+func FileContentType(file, contentType string) string {
+	if contentType != "" {
+		return contentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(file)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+// NeedsBoundary reports whether a Content-Type header set by the user
+// is a multipart type without the boundary, which only the encoder knows.
+func NeedsBoundary(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+
+	return err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] == ""
+}
+
+// ParseField parses a form field, see Syntax.
+func ParseField(s string) (models.FormField, error) {
+	key, value, found := strings.Cut(s, "=")
+	if !found || key == "" {
+		return models.FormField{}, fmt.Errorf("%w: field %q, expected %s", ErrInvalidBody, s, Syntax)
+	}
+
+	field := models.FormField{Key: key}
+	file, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		field.Value = value
+		return field, nil
+	}
+
+	file, params, _ := strings.Cut(file, ";")
+	if file == "" {
+		return models.FormField{}, fmt.Errorf("%w: field %q has no file name", ErrInvalidBody, key)
+	}
+	field.File = file
+	for _, param := range strings.Split(params, ";") {
+		if t, ok := strings.CutPrefix(strings.TrimSpace(param), "type="); ok {
+			field.ContentType = t
+		}
+	}
+
+	return field, nil
+}
+
+// FieldString formats a field in the notation of ParseField.
+func FieldString(f models.FormField) string {
+	if f.File == "" {
+		return f.Key + "=" + f.Value
+	}
+	if f.ContentType == "" {
+		return f.Key + "=@" + f.File
+	}
+
+	return f.Key + "=@" + f.File + ";type=" + f.ContentType
+}
+
+// Describe summarizes the body of a request in one line, e.g.
+// "multipart, 2 fields" or "binary, ./logo.png".
+func Describe(request models.Request) string {
+	switch request.BodyMode {
+	case models.BodyURLEncoded:
+		return fmt.Sprintf("urlencoded, %s", fieldCount(request.Form))
+	case models.BodyFormData:
+		return fmt.Sprintf("multipart, %s", fieldCount(request.Form))
+	case models.BodyBinary:
+		if request.ContentType != "" {
+			return fmt.Sprintf("binary %s, %s", request.ContentType, request.BodyFile)
+		}
+		return "binary, " + request.BodyFile
+	}
+
+	if request.ContentType != "" {
+		return fmt.Sprintf("raw %s, %d bytes", request.ContentType, len(request.Body))
+	}
+
+	return fmt.Sprintf("raw, %d bytes", len(request.Body))
+}
+
+func fieldCount(fields []models.FormField) string {
+	if len(fields) == 1 {
+		return "1 field"
+	}
+
+	return fmt.Sprintf("%d fields", len(fields))
+}
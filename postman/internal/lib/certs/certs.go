@@ -0,0 +1,206 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"postman/internal/domain/models"
+	"strings"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var (
+	ErrInvalidCertificate = errors.New("invalid client certificate")
+	ErrPassphrase         = errors.New("the key is encrypted, a passphrase is required")
+)
+
+// Validate checks that a client certificate names its files and hosts.
+func Validate(c models.ClientCertificate) error {
+	if len(c.Hosts) == 0 {
+		return fmt.Errorf("%w: no hosts", ErrInvalidCertificate)
+	}
+
+	switch {
+	case c.PKCS12File != "" && (c.CertFile != "" || c.KeyFile != ""):
+		return fmt.Errorf("%w: set either a PKCS#12 file or PEM files", ErrInvalidCertificate)
+	case c.PKCS12File == "" && c.CertFile == "":
+		return fmt.Errorf("%w: no certificate file", ErrInvalidCertificate)
+	}
+
+	return nil
+}
+
+// Load reads a client certificate. A PEM certificate file without a
+// KeyFile must contain the key too. Encrypted PKCS#8 and legacy
+// encrypted PEM keys are decrypted with the passphrase.
+func Load(c models.ClientCertificate) (tls.Certificate, error) {
+	if err := Validate(c); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if c.PKCS12File != "" {
+		return loadPKCS12(c.PKCS12File, c.Passphrase)
+	}
+
+	return loadPEM(c.CertFile, c.KeyFile, c.Passphrase)
+}
+
+func loadPKCS12(file, passphrase string) (tls.Certificate, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+
+	key, leaf, chain, err := pkcs12.DecodeChain(b, passphrase)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%w: %s: %w", ErrInvalidCertificate, file, err)
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, ca := range chain {
+		cert.Certificate = append(cert.Certificate, ca.Raw)
+	}
+
+	return cert, nil
+}
+
+func loadPEM(certFile, keyFile, passphrase string) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+	keyPEM := certPEM
+	if keyFile != "" {
+		if keyPEM, err = os.ReadFile(keyFile); err != nil {
+			return tls.Certificate{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+		}
+	}
+
+	var cert tls.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return tls.Certificate{}, fmt.Errorf("%w: no certificate in %s", ErrInvalidCertificate, certFile)
+	}
+
+	if cert.PrivateKey, err = privateKey(keyPEM, passphrase); err != nil {
+		return tls.Certificate{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return tls.Certificate{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+
+	return cert, nil
+}
+
+// privateKey finds the first key in the PEM data and decrypts it.
+func privateKey(data []byte, passphrase string) (crypto.PrivateKey, error) {
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no private key found")
+		}
+
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if passphrase == "" {
+				return nil, ErrPassphrase
+			}
+			return pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+
+		// legacy encrypted PEM, still produced by openssl -traditional
+		case strings.HasSuffix(block.Type, "PRIVATE KEY") && x509.IsEncryptedPEMBlock(block):
+			if passphrase == "" {
+				return nil, ErrPassphrase
+			}
+			der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+			if err != nil {
+				return nil, err
+			}
+			return parseKey(der)
+
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			return parseKey(block.Bytes)
+		}
+	}
+}
+
+func parseKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported private key type")
+}
+
+// Match reports whether a host pattern matches the host and port of a
+// URL. Patterns use path.Match syntax, so "*.example.com" matches every
+// subdomain; a pattern with a port matches that port only.
+func Match(pattern, host, port string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+
+	if h, p, err := net.SplitHostPort(pattern); err == nil {
+		if p != port {
+			return false
+		}
+		pattern = h
+	}
+
+	ok, err := path.Match(pattern, host)
+
+	return err == nil && ok
+}
+
+// Describe converts a connection state for display.
+func Describe(state *tls.ConnectionState) *models.TLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	info := &models.TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		Protocol:    state.NegotiatedProtocol,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.PeerCertificates = append(info.PeerCertificates, models.CertificateInfo{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+			DNSNames:  cert.DNSNames,
+		})
+	}
+
+	return info
+}
@@ -0,0 +1,300 @@
+package certs_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+	"postman/internal/lib/certs"
+	"testing"
+	"time"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const passphrase = "s3cret"
+
+// selfSigned returns a client certificate for the key.
+func selfSigned(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func writePEM(t *testing.T, name string, blocks ...*pem.Block) string {
+	t.Helper()
+
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaCert := selfSigned(t, rsaKey)
+	ecCert := selfSigned(t, ecKey)
+
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedDER, err := pkcs8.MarshalPrivateKey(rsaKey, []byte(passphrase), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaCertBlock := &pem.Block{Type: "CERTIFICATE", Bytes: rsaCert.Raw}
+	ecCertBlock := &pem.Block{Type: "CERTIFICATE", Bytes: ecCert.Raw}
+	pkcs1Block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+	pkcs8Block := &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}
+	encryptedBlock := &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encryptedDER}
+	ecBlock := &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}
+
+	tests := []struct {
+		name       string
+		cert       string
+		key        string
+		passphrase string
+		wantLeaf   *x509.Certificate
+		wantErr    error
+	}{
+		{
+			name:     "PKCS#1 RSA key",
+			cert:     writePEM(t, "cert.pem", rsaCertBlock),
+			key:      writePEM(t, "key.pem", pkcs1Block),
+			wantLeaf: rsaCert,
+		},
+		{
+			name:     "PKCS#8 key",
+			cert:     writePEM(t, "cert.pem", ecCertBlock),
+			key:      writePEM(t, "key.pem", pkcs8Block),
+			wantLeaf: ecCert,
+		},
+		{
+			name:     "EC key in the certificate file",
+			cert:     writePEM(t, "both.pem", ecCertBlock, ecBlock),
+			wantLeaf: ecCert,
+		},
+		{
+			name:       "encrypted PKCS#8 key",
+			cert:       writePEM(t, "cert.pem", rsaCertBlock),
+			key:        writePEM(t, "key.pem", encryptedBlock),
+			passphrase: passphrase,
+			wantLeaf:   rsaCert,
+		},
+		{
+			name:    "encrypted PKCS#8 key without a passphrase",
+			cert:    writePEM(t, "cert.pem", rsaCertBlock),
+			key:     writePEM(t, "key.pem", encryptedBlock),
+			wantErr: certs.ErrPassphrase,
+		},
+		{
+			name:       "encrypted PKCS#8 key with a wrong passphrase",
+			cert:       writePEM(t, "cert.pem", rsaCertBlock),
+			key:        writePEM(t, "key.pem", encryptedBlock),
+			passphrase: "wrong",
+			wantErr:    certs.ErrInvalidCertificate,
+		},
+		{
+			name:    "no key",
+			cert:    writePEM(t, "cert.pem", rsaCertBlock),
+			wantErr: certs.ErrInvalidCertificate,
+		},
+		{
+			name:    "no certificate",
+			cert:    writePEM(t, "key.pem", pkcs1Block),
+			wantErr: certs.ErrInvalidCertificate,
+		},
+		{
+			name:    "missing file",
+			cert:    filepath.Join(t.TempDir(), "missing.pem"),
+			wantErr: certs.ErrInvalidCertificate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := certs.Load(models.ClientCertificate{
+				Hosts:      []string{"localhost"},
+				CertFile:   tt.cert,
+				KeyFile:    tt.key,
+				Passphrase: tt.passphrase,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !cert.Leaf.Equal(tt.wantLeaf) {
+				t.Errorf("Load() leaf = %s, want %s", cert.Leaf.Subject, tt.wantLeaf.Subject)
+			}
+			signer, ok := cert.PrivateKey.(crypto.Signer)
+			if !ok || !publicKeysEqual(signer.Public(), tt.wantLeaf.PublicKey) {
+				t.Error("Load() private key does not match the certificate")
+			}
+		})
+	}
+}
+
+func TestLoadPKCS12(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := selfSigned(t, key)
+
+	write := func(t *testing.T, encoder *pkcs12.Encoder, password string) string {
+		t.Helper()
+
+		data, err := encoder.Encode(key, cert, nil, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "client.p12")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		passphrase string
+		wantErr    bool
+	}{
+		{name: "modern", file: write(t, pkcs12.Modern, passphrase), passphrase: passphrase},
+		{name: "legacy", file: write(t, pkcs12.LegacyRC2, passphrase), passphrase: passphrase},
+		{name: "passwordless", file: write(t, pkcs12.Passwordless, "")},
+		{name: "wrong passphrase", file: write(t, pkcs12.Modern, passphrase), passphrase: "wrong", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := certs.Load(models.ClientCertificate{
+				Hosts:      []string{"localhost"},
+				PKCS12File: tt.file,
+				Passphrase: tt.passphrase,
+			})
+			if tt.wantErr {
+				if !errors.Is(err, certs.ErrInvalidCertificate) {
+					t.Fatalf("Load() error = %v, want ErrInvalidCertificate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !got.Leaf.Equal(cert) {
+				t.Errorf("Load() leaf = %s, want %s", got.Leaf.Subject, cert.Subject)
+			}
+			if k, ok := got.PrivateKey.(*rsa.PrivateKey); !ok || !k.Equal(key) {
+				t.Error("Load() private key does not match")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cert    models.ClientCertificate
+		wantErr bool
+	}{
+		{name: "PEM", cert: models.ClientCertificate{Hosts: []string{"*.example.com"}, CertFile: "c.pem", KeyFile: "k.pem"}},
+		{name: "PKCS#12", cert: models.ClientCertificate{Hosts: []string{"api.example.com:8443"}, PKCS12File: "c.p12"}},
+		{name: "no hosts", cert: models.ClientCertificate{CertFile: "c.pem"}, wantErr: true},
+		{name: "no files", cert: models.ClientCertificate{Hosts: []string{"example.com"}}, wantErr: true},
+		{name: "key without certificate", cert: models.ClientCertificate{Hosts: []string{"example.com"}, KeyFile: "k.pem"}, wantErr: true},
+		{name: "both kinds", cert: models.ClientCertificate{Hosts: []string{"example.com"}, CertFile: "c.pem", PKCS12File: "c.p12"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := certs.Validate(tt.cert)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, certs.ErrInvalidCertificate) {
+				t.Errorf("Validate() error = %v, want ErrInvalidCertificate", err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, host, port string
+		want                bool
+	}{
+		{"api.example.com", "api.example.com", "443", true},
+		{"API.Example.com", "api.example.COM", "443", true},
+		{"*.example.com", "api.example.com", "443", true},
+		{"*.example.com", "example.com", "443", false},
+		{"*.example.com", "a.b.example.com", "443", true},
+		{"api.example.com:8443", "api.example.com", "8443", true},
+		{"api.example.com:8443", "api.example.com", "443", false},
+		{"127.0.0.1", "127.0.0.1", "8443", true},
+		{"other.com", "api.example.com", "443", false},
+	}
+
+	for _, tt := range tests {
+		if got := certs.Match(tt.pattern, tt.host, tt.port); got != tt.want {
+			t.Errorf("Match(%q, %q, %q) = %v, want %v", tt.pattern, tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
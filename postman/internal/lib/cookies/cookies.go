@@ -0,0 +1,305 @@
+package cookies
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"postman/internal/domain/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var ErrInvalidDomain = errors.New("invalid cookie domain")
+
+// Jar is an http.CookieJar over a list of stored cookies, following the
+// storage model of RFC 6265 section 5.3. Domain cookies for a public
+// suffix such as "com" or "co.uk" are rejected.
+type Jar struct {
+	mu      sync.Mutex
+	cookies []models.Cookie
+	changed bool
+}
+
+func NewJar(cookies []models.Cookie) *Jar {
+	return &Jar{
+		cookies: append([]models.Cookie(nil), cookies...),
+	}
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie, remove, err := fromHTTP(c, host, u.Path, now)
+		if err != nil {
+			continue
+		}
+
+		i := j.index(cookie)
+		switch {
+		case remove:
+			if i >= 0 {
+				j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+				j.changed = true
+			}
+		case i >= 0:
+			cookie.Created = j.cookies[i].Created
+			j.cookies[i] = cookie
+			j.changed = true
+		default:
+			j.cookies = append(j.cookies, cookie)
+			j.changed = true
+		}
+	}
+}
+
+// Cookies implements http.CookieJar. Longer paths come first, then
+// older cookies, as RFC 6265 section 5.4 recommends.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	var selected []models.Cookie
+	for _, c := range j.cookies {
+		if c.Expired(now) || (c.Secure && u.Scheme != "https") {
+			continue
+		}
+		if !Matches(c, host, path) {
+			continue
+		}
+		selected = append(selected, c)
+	}
+
+	sort.SliceStable(selected, func(a, b int) bool {
+		if len(selected[a].Path) != len(selected[b].Path) {
+			return len(selected[a].Path) > len(selected[b].Path)
+		}
+		return selected[a].Created.Before(selected[b].Created)
+	})
+
+	result := make([]*http.Cookie, 0, len(selected))
+	for _, c := range selected {
+		result = append(result, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	return result
+}
+
+// All returns the cookies that have not expired.
+func (j *Jar) All() []models.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	all := make([]models.Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.Expired(now) {
+			all = append(all, c)
+		}
+	}
+
+	return all
+}
+
+// Changed reports whether a response added, replaced or removed a cookie.
+func (j *Jar) Changed() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.changed
+}
+
+func (j *Jar) index(cookie models.Cookie) int {
+	for i := range j.cookies {
+		if j.cookies[i].SameCookie(cookie) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Matches reports whether the cookie is sent to host and path.
+func Matches(c models.Cookie, host, path string) bool {
+	if c.HostOnly {
+		if host != c.Domain {
+			return false
+		}
+	} else if !domainMatch(host, c.Domain) {
+		return false
+	}
+
+	return pathMatch(path, c.Path)
+}
+
+// Domain validates a cookie domain set for host. It returns the domain
+// to store and whether the cookie is host-only.
+func Domain(host, domain string) (string, bool, error) {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" {
+		return host, true, nil
+	}
+
+	if isIP(host) {
+		if domain != host {
+			return "", false, fmt.Errorf("%w: %q for the address %s", ErrInvalidDomain, domain, host)
+		}
+		return host, true, nil
+	}
+
+	if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+		// a public suffix is only accepted as the host itself
+		if host == domain {
+			return host, true, nil
+		}
+		return "", false, fmt.Errorf("%w: %q is a public suffix", ErrInvalidDomain, domain)
+	}
+
+	if !domainMatch(host, domain) {
+		return "", false, fmt.Errorf("%w: %q does not match %s", ErrInvalidDomain, domain, host)
+	}
+
+	return domain, false, nil
+}
+
+// IsPublicSuffix reports whether domain is a public suffix.
+func IsPublicSuffix(domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+
+	return suffix == domain
+}
+
+func fromHTTP(c *http.Cookie, host, requestPath string, now time.Time) (models.Cookie, bool, error) {
+	domain, hostOnly, err := Domain(host, c.Domain)
+	if err != nil {
+		return models.Cookie{}, false, err
+	}
+
+	path := c.Path
+	if !strings.HasPrefix(path, "/") {
+		path = defaultPath(requestPath)
+	}
+
+	cookie := models.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   domain,
+		Path:     path,
+		HostOnly: hostOnly,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		SameSite: sameSite(c.SameSite),
+		Created:  now,
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		return cookie, true, nil
+	case c.MaxAge > 0:
+		cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		if !c.Expires.After(now) {
+			return cookie, true, nil
+		}
+		cookie.Expires = c.Expires.UTC()
+	}
+
+	return cookie, false, nil
+}
+
+func sameSite(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+
+	return ""
+}
+
+// canonicalHost lower-cases the host and strips the port.
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return "", fmt.Errorf("%w: empty host", ErrInvalidDomain)
+	}
+
+	return host, nil
+}
+
+// domainMatch implements RFC 6265 section 5.1.3.
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+
+	return !isIP(host) && strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch implements RFC 6265 section 5.1.4.
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+// defaultPath implements RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+
+	return path[:i]
+}
+
+func isIP(host string) bool {
+	return net.ParseIP(host) != nil
+}
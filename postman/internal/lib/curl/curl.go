@@ -0,0 +1,700 @@
+package curl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/headers"
+	"postman/internal/lib/pathparams"
+	"postman/internal/lib/shellwords"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotCurl       = errors.New("not a curl command")
+	ErrMissingValue  = errors.New("option requires a value")
+	ErrNoURL         = errors.New("no URL in curl command")
+	ErrInvalidOption = errors.New("invalid option value")
+	ErrConflict      = errors.New("conflicting options")
+)
+
+// short options that take a value
+const shortWithValue = "XHdFuAebomwxTEr"
+
+// options that take a value and are ignored
+var ignoredWithValue = map[string]bool{
+	"-o": true, "--output": true, "-w": true, "--write-out": true,
+	"--connect-timeout": true, "--retry": true, "--retry-delay": true,
+	"--retry-max-time": true, "-E": true,
+	"--cert": true, "--key": true, "-r": true, "--range": true,
+	"-T": true, "--upload-file": true, "--resolve": true,
+}
+
+// boolean options that are ignored
+var ignored = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-f": true, "--fail": true,
+	"-#": true, "--progress-bar": true, "--compressed": true,
+	"-N": true, "--no-buffer": true,
+	"-g": true, "--globoff": true, "--basic": true,
+}
+
+// parser holds the state collected while walking the arguments.
+type parser struct {
+	request  models.Request
+	data     []string
+	form     []models.FormField
+	get      bool
+	head     bool
+	digest   bool
+	warnings []string
+}
+
+// Parse converts a curl command line into a request. Options that have no
+// equivalent in the request model are returned as warnings.
+func Parse(command string) (models.Request, []string, error) {
+	args, err := shellwords.Split(command)
+	if err != nil {
+		return models.Request{}, nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return models.Request{}, nil, ErrNotCurl
+	}
+
+	p := &parser{}
+	if err := p.parse(args[1:]); err != nil {
+		return models.Request{}, p.warnings, err
+	}
+
+	if err := p.finish(); err != nil {
+		return models.Request{}, p.warnings, err
+	}
+
+	return p.request, p.warnings, nil
+}
+
+func (p *parser) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		next := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%w: %s", ErrMissingValue, arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch {
+		case arg == "--":
+			for _, rest := range args[i+1:] {
+				p.setURL(rest)
+			}
+			return nil
+
+		case strings.HasPrefix(arg, "--"):
+			if ignored[arg] {
+				continue
+			}
+			value := ""
+			if takesValue(arg) {
+				v, err := next()
+				if err != nil {
+					return err
+				}
+				value = v
+			}
+			if err := p.option(arg, value); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// combined short options, e.g. -sSLk or -XPOST
+			for j := 1; j < len(arg); j++ {
+				opt := "-" + string(arg[j])
+				if !strings.ContainsRune(shortWithValue, rune(arg[j])) {
+					if ignored[opt] {
+						continue
+					}
+					if err := p.option(opt, ""); err != nil {
+						return err
+					}
+					continue
+				}
+
+				value := arg[j+1:]
+				if value == "" {
+					v, err := next()
+					if err != nil {
+						return err
+					}
+					value = v
+				}
+				if err := p.option(opt, value); err != nil {
+					return err
+				}
+				break
+			}
+
+		default:
+			p.setURL(arg)
+		}
+	}
+
+	return nil
+}
+
+func takesValue(opt string) bool {
+	if ignoredWithValue[opt] {
+		return true
+	}
+
+	switch opt {
+	case "--request", "--header", "--data", "--data-ascii", "--data-raw",
+		"--data-binary", "--data-urlencode", "--json", "--user", "--form",
+		"--form-string", "--user-agent", "--referer", "--cookie", "--url",
+		"--max-time", "--oauth2-bearer", "--proxy", "--noproxy", "--cacert",
+		"--max-redirs":
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) option(opt, value string) error {
+	switch opt {
+	case "-X", "--request":
+		p.request.Method = value
+
+	case "-H", "--header":
+		if err := p.header(value); err != nil {
+			return err
+		}
+
+	case "-d", "--data", "--data-ascii":
+		data, err := readData(value, true)
+		if err != nil {
+			return err
+		}
+		p.data = append(p.data, data)
+
+	case "--data-binary":
+		data, err := readData(value, false)
+		if err != nil {
+			return err
+		}
+		p.data = append(p.data, data)
+
+	case "--data-raw":
+		p.data = append(p.data, value)
+
+	case "--data-urlencode":
+		data, err := urlencodeData(value)
+		if err != nil {
+			return err
+		}
+		p.data = append(p.data, data)
+
+	case "--json":
+		data, err := readData(value, false)
+		if err != nil {
+			return err
+		}
+		p.data = append(p.data, data)
+		p.setDefaultHeader("Content-Type", "application/json")
+		p.setDefaultHeader("Accept", "application/json")
+
+	case "-F", "--form":
+		field, err := parseFormField(value)
+		if err != nil {
+			return err
+		}
+		p.form = append(p.form, field)
+
+	case "--form-string":
+		name, v, _ := strings.Cut(value, "=")
+		p.form = append(p.form, models.FormField{Key: name, Value: v})
+
+	case "-u", "--user":
+		username, password, _ := strings.Cut(value, ":")
+		p.request.Auth = &models.Auth{Type: models.AuthBasic, Username: username, Password: password}
+
+	case "--digest":
+		p.digest = true
+
+	case "--oauth2-bearer":
+		p.request.Auth = &models.Auth{Type: models.AuthBearer, Token: value}
+
+	case "-A", "--user-agent":
+		p.request.Headers = append(p.request.Headers, models.Header{Key: "User-Agent", Value: value})
+
+	case "-e", "--referer":
+		p.request.Headers = append(p.request.Headers, models.Header{Key: "Referer", Value: value})
+
+	case "-b", "--cookie":
+		if !strings.Contains(value, "=") {
+			p.warnings = append(p.warnings, fmt.Sprintf("%s %s: cookie files are not supported", opt, value))
+			return nil
+		}
+		p.request.Headers = append(p.request.Headers, models.Header{Key: "Cookie", Value: value})
+
+	case "-k", "--insecure":
+		p.request.Options.InsecureSkipVerify = true
+
+	case "-G", "--get":
+		p.get = true
+
+	case "-I", "--head":
+		p.head = true
+
+	case "-m", "--max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s %q", ErrInvalidOption, opt, value)
+		}
+		p.request.Options.Timeout = time.Duration(seconds * float64(time.Second))
+
+	case "-L", "--location":
+		follow := true
+		p.request.Options.FollowRedirects = &follow
+
+	case "--max-redirs":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s %q", ErrInvalidOption, opt, value)
+		}
+		switch {
+		case n == 0:
+			// 0 inherits the limit in options, curl follows no redirect
+			follow := false
+			p.request.Options.FollowRedirects = &follow
+		case n < 0:
+			p.warnings = append(p.warnings, fmt.Sprintf("%s %s: unlimited redirects are not supported, the default limit applies", opt, value))
+		default:
+			p.request.Options.MaxRedirects = n
+		}
+
+	case "-x", "--proxy":
+		p.request.Options.Proxy = value
+
+	case "--noproxy":
+		if value == "*" {
+			p.request.Options.Proxy = models.ProxyDirect
+		} else {
+			p.request.Options.NoProxy = value
+		}
+
+	case "--cacert":
+		p.request.Options.CABundle = value
+
+	case "--http1.1", "--http2":
+		http2 := opt == "--http2"
+		p.request.Options.HTTP2 = &http2
+
+	case "--url":
+		p.setURL(value)
+
+	default:
+		if ignoredWithValue[opt] {
+			p.warnings = append(p.warnings, fmt.Sprintf("%s %s is ignored", opt, value))
+			return nil
+		}
+		p.warnings = append(p.warnings, fmt.Sprintf("unknown option %s is ignored", opt))
+	}
+
+	return nil
+}
+
+func (p *parser) header(value string) error {
+	// "-H 'X-Empty;'" sends an empty header
+	if key, found := strings.CutSuffix(value, ";"); found && !strings.Contains(key, ":") {
+		p.request.Headers = append(p.request.Headers, models.Header{Key: strings.TrimSpace(key)})
+		return nil
+	}
+
+	header, err := headers.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	// "-H 'Accept:'" removes a default header, which has no equivalent here
+	if header.Value == "" {
+		p.warnings = append(p.warnings, fmt.Sprintf("-H %q removes a default header and is ignored", value))
+		return nil
+	}
+
+	p.request.Headers = append(p.request.Headers, header)
+
+	return nil
+}
+
+func (p *parser) setURL(rawURL string) {
+	if p.request.URL != "" {
+		p.warnings = append(p.warnings, fmt.Sprintf("extra URL %s is ignored", rawURL))
+		return
+	}
+
+	// curl defaults to http:// for URLs without a scheme
+	if !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "{{") {
+		rawURL = "http://" + rawURL
+	}
+	p.request.URL = rawURL
+}
+
+func (p *parser) setDefaultHeader(key, value string) {
+	for _, h := range p.request.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return
+		}
+	}
+
+	p.request.Headers = append(p.request.Headers, models.Header{Key: key, Value: value})
+}
+
+func (p *parser) finish() error {
+	if p.request.URL == "" {
+		return ErrNoURL
+	}
+
+	if p.digest && p.request.Auth != nil && p.request.Auth.Type == models.AuthBasic {
+		p.request.Auth.Type = models.AuthDigest
+	}
+
+	// curl refuses to mix the two as well
+	if len(p.form) > 0 && len(p.data) > 0 {
+		return fmt.Errorf("%w: -F cannot be combined with -d", ErrConflict)
+	}
+
+	data := strings.Join(p.data, "&")
+
+	switch {
+	case p.get:
+		if data != "" {
+			sep := "?"
+			if strings.Contains(p.request.URL, "?") {
+				sep = "&"
+			}
+			p.request.URL += sep + data
+		}
+
+	case len(p.form) > 0:
+		p.request.BodyMode = models.BodyFormData
+		p.request.Form = p.form
+
+	case len(p.data) > 0:
+		p.request.Body = data
+		p.setDefaultHeader("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if p.request.Method == "" {
+		switch {
+		case p.head:
+			p.request.Method = "HEAD"
+		case p.request.HasBody():
+			p.request.Method = "POST"
+		default:
+			p.request.Method = "GET"
+		}
+	}
+	p.request.Method = client.NormalizeMethod(p.request.Method)
+
+	return nil
+}
+
+// readData resolves @file references. Like curl, -d strips carriage
+// returns and newlines from files while --data-binary keeps them.
+func readData(value string, strip bool) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if value == "@-" {
+		b, err = readStdin()
+	} else {
+		b, err = os.ReadFile(value[1:])
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data := string(b)
+	if strip {
+		data = strings.NewReplacer("\r", "", "\n", "").Replace(data)
+	}
+
+	return data, nil
+}
+
+func readStdin() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(os.Stdin)
+	return buf.Bytes(), err
+}
+
+// urlencodeData implements the --data-urlencode forms
+// "content", "=content", "name=content", "@file" and "name@file".
+func urlencodeData(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name := value[:i]
+		content := value[i+1:]
+		if value[i] == '@' {
+			b, err := os.ReadFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(b)
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+
+	return url.QueryEscape(value), nil
+}
+
+// parseFormField implements the -F forms "name=value", "name=@file" and
+// "name=<file" with an optional ";type=..." suffix. File parts are read
+// when sending, "<file" contents are read now.
+func parseFormField(value string) (models.FormField, error) {
+	name, v, found := strings.Cut(value, "=")
+	if !found {
+		return models.FormField{}, fmt.Errorf("%w: -F %q", ErrInvalidOption, value)
+	}
+
+	field := models.FormField{Key: name}
+	if strings.HasPrefix(v, "@") || strings.HasPrefix(v, "<") {
+		path, params, _ := strings.Cut(v[1:], ";")
+		for _, param := range strings.Split(params, ";") {
+			if t, ok := strings.CutPrefix(param, "type="); ok {
+				field.ContentType = t
+			}
+		}
+
+		if v[0] == '@' {
+			field.File = path
+			return field, nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return models.FormField{}, err
+		}
+		field.Value = string(b)
+		return field, nil
+	}
+
+	field.Value = v
+	return field, nil
+}
+
+// String renders the request as a copy-pasteable curl command. Parts of
+// the request curl cannot express are returned as warnings.
+func String(request models.Request) (string, []string) {
+	var warnings []string
+	parts := []string{"curl"}
+
+	method := client.NormalizeMethod(request.Method)
+	switch {
+	case method == "HEAD":
+		parts = append(parts, "-I")
+	case method == "GET" && !request.HasBody():
+	case method == "POST" && request.HasBody():
+	default:
+		parts = append(parts, "-X "+Quote(method))
+	}
+
+	parts = append(parts, Quote(requestURL(request)))
+	// method and URL go on the first line, every other option on its own
+	parts = []string{strings.Join(parts, " ")}
+
+	for _, h := range request.Headers {
+		parts = append(parts, "-H "+Quote(headers.String(h)))
+	}
+	auth, warning := authOptions(request.Auth)
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	parts = append(parts, auth...)
+
+	parts = append(parts, bodyOptions(request)...)
+
+	if request.Options.InsecureSkipVerify {
+		parts = append(parts, "-k")
+	}
+	if request.Options.Timeout > 0 {
+		parts = append(parts, "--max-time "+strconv.FormatFloat(request.Options.Timeout.Seconds(), 'f', -1, 64))
+	}
+	parts = append(parts, transportOptions(request.Options)...)
+
+	return strings.Join(parts, " \\\n  "), warnings
+}
+
+// bodyOptions renders the body in the matching curl option. curl sends
+// -d and --data-binary as x-www-form-urlencoded, so the content type the
+// client would send is added when no header sets one.
+func bodyOptions(request models.Request) []string {
+	if !request.HasBody() {
+		return nil
+	}
+
+	var parts []string
+	contentType := func(value string) {
+		if !headers.Has(request.Headers, "Content-Type") {
+			parts = append(parts, "-H "+Quote("Content-Type: "+value))
+		}
+	}
+
+	switch request.BodyMode {
+	case models.BodyURLEncoded:
+		for _, f := range request.Form {
+			if !f.Disabled {
+				parts = append(parts, "--data-urlencode "+Quote(f.Key+"="+f.Value))
+			}
+		}
+
+	case models.BodyFormData:
+		for _, f := range request.Form {
+			switch {
+			case f.Disabled:
+			case f.File != "":
+				parts = append(parts, "-F "+Quote(bodies.FieldString(f)))
+			default:
+				// --form-string does not treat a leading @ or < as a file
+				parts = append(parts, "--form-string "+Quote(f.Key+"="+f.Value))
+			}
+		}
+
+	case models.BodyBinary:
+		contentType(bodies.FileContentType(request.BodyFile, request.ContentType))
+		parts = append(parts, "--data-binary "+Quote("@"+request.BodyFile))
+
+	default:
+		if request.ContentType != "" {
+			contentType(request.ContentType)
+		}
+		parts = append(parts, "--data-raw "+Quote(request.Body))
+	}
+
+	return parts
+}
+
+// transportOptions renders the redirect, proxy, CA and HTTP version
+// options. curl does not follow redirects unless told to, so -L is
+// added when following is not disabled. Disabled following is written
+// as --max-redirs 0, which Parse reads back as disabled.
+func transportOptions(options models.Options) []string {
+	var parts []string
+	if options.FollowRedirects == nil || *options.FollowRedirects {
+		parts = append(parts, "-L")
+		if options.MaxRedirects > 0 {
+			parts = append(parts, "--max-redirs "+strconv.Itoa(options.MaxRedirects))
+		}
+	} else {
+		parts = append(parts, "--max-redirs 0")
+	}
+	switch options.Proxy {
+	case "":
+	case models.ProxyDirect:
+		parts = append(parts, "--noproxy "+Quote("*"))
+	default:
+		parts = append(parts, "--proxy "+Quote(options.Proxy))
+	}
+	if options.NoProxy != "" {
+		parts = append(parts, "--noproxy "+Quote(options.NoProxy))
+	}
+	if options.CABundle != "" {
+		parts = append(parts, "--cacert "+Quote(options.CABundle))
+	}
+	if options.HTTP2 != nil {
+		if *options.HTTP2 {
+			parts = append(parts, "--http2")
+		} else {
+			parts = append(parts, "--http1.1")
+		}
+	}
+
+	return parts
+}
+
+// authOptions renders the auth as curl options. An API key in the query
+// is part of the URL already. An oauth2 token is only known when sending,
+// so it is left out with a warning.
+func authOptions(a *models.Auth) (parts []string, warning string) {
+	if a == nil {
+		return nil, ""
+	}
+
+	switch a.Type {
+	case models.AuthBasic:
+		return []string{"-u " + Quote(a.Username+":"+a.Password)}, ""
+	case models.AuthDigest:
+		return []string{"--digest -u " + Quote(a.Username+":"+a.Password)}, ""
+	case models.AuthBearer:
+		return []string{"-H " + Quote("Authorization: Bearer "+a.Token)}, ""
+	case models.AuthAPIKey:
+		if a.In != models.APIKeyInQuery {
+			return []string{"-H " + Quote(a.Key+": "+a.Value)}, ""
+		}
+	case models.AuthOAuth2:
+		return nil, "oauth2 auth is not exported: the token is only requested when sending"
+	}
+
+	return nil, ""
+}
+
+// requestURL fills in the path parameters that have a value and appends
+// the query parameters to the URL. Unresolved {{var}} URLs can't be
+// parsed, so they are joined as text.
+func requestURL(request models.Request) string {
+	values := map[string]string{}
+	for _, p := range request.PathParams {
+		if p.Value != "" {
+			values[p.Key] = p.Value
+		}
+	}
+	request.URL, _ = pathparams.Apply(request.URL, values)
+
+	if a := request.Auth; a != nil && a.Type == models.AuthAPIKey && a.In == models.APIKeyInQuery {
+		request.Query = append(request.Query, models.QueryParam{Key: a.Key, Value: a.Value})
+	}
+
+	if u, err := client.URL(request); err == nil {
+		return u.String()
+	}
+
+	result := request.URL
+	for _, q := range request.Query {
+		if q.Disabled {
+			continue
+		}
+		sep := "&"
+		if !strings.Contains(result, "?") {
+			sep = "?"
+		}
+		result += sep + url.QueryEscape(q.Key) + "=" + url.QueryEscape(q.Value)
+	}
+
+	return result
+}
+
+// Quote quotes s for a POSIX shell.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,361 @@
+package curl_test
+
+import (
+	"errors"
+	"postman/internal/domain/models"
+	"postman/internal/lib/curl"
+	"postman/internal/lib/shellwords"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    models.Request
+		wantErr error
+	}{
+		{
+			name:    "plain GET",
+			command: "curl http://localhost:8080/api/v1/users",
+			want:    models.Request{Method: "GET", URL: "http://localhost:8080/api/v1/users"},
+		},
+		{
+			name:    "scheme defaults to http",
+			command: "curl localhost:8080/ping",
+			want:    models.Request{Method: "GET", URL: "http://localhost:8080/ping"},
+		},
+		{
+			name:    "single and double quotes",
+			command: `curl -H 'X-Note: it'\''s "quoted"' -H "X-Path: \"a b\" \$HOME" 'http://localhost/a b'`,
+			want: models.Request{
+				Method: "GET",
+				URL:    "http://localhost/a b",
+				Headers: []models.Header{
+					{Key: "X-Note", Value: `it's "quoted"`},
+					{Key: "X-Path", Value: `"a b" $HOME`},
+				},
+			},
+		},
+		{
+			name: "continued lines",
+			command: "curl -X PUT \\\n" +
+				"  http://localhost/users/1 \\\n" +
+				"  -H 'Content-Type: application/json' \\\n" +
+				"  --data-raw '{\"login\":\"a\"}'",
+			want: models.Request{
+				Method:  "PUT",
+				URL:     "http://localhost/users/1",
+				Headers: []models.Header{{Key: "Content-Type", Value: "application/json"}},
+				Body:    `{"login":"a"}`,
+			},
+		},
+		{
+			name:    "combined short options",
+			command: "curl -sSLk -XDELETE http://localhost/users/1",
+			want: models.Request{
+				Method:  "DELETE",
+				URL:     "http://localhost/users/1",
+				Options: models.Options{FollowRedirects: boolPtr(true), InsecureSkipVerify: true},
+			},
+		},
+		{
+			name:    "-d implies POST and a form content type",
+			command: "curl -d a=1 -d b=2 http://localhost/form",
+			want: models.Request{
+				Method:  "POST",
+				URL:     "http://localhost/form",
+				Headers: []models.Header{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+				Body:    "a=1&b=2",
+			},
+		},
+		{
+			name:    "-G moves data to the query",
+			command: "curl -G -d q=go --data-urlencode 'tag=a b' 'http://localhost/search?page=1'",
+			want:    models.Request{Method: "GET", URL: "http://localhost/search?page=1&q=go&tag=a+b"},
+		},
+		{
+			name:    "--json",
+			command: `curl --json '{"a":1}' http://localhost/`,
+			want: models.Request{
+				Method: "POST",
+				URL:    "http://localhost/",
+				Headers: []models.Header{
+					{Key: "Content-Type", Value: "application/json"},
+					{Key: "Accept", Value: "application/json"},
+				},
+				Body: `{"a":1}`,
+			},
+		},
+		{
+			name:    "-F builds a multipart body",
+			command: "curl -F name=alice -F 'avatar=@./a.png;type=image/png' http://localhost/upload",
+			want: models.Request{
+				Method:   "POST",
+				URL:      "http://localhost/upload",
+				BodyMode: models.BodyFormData,
+				Form: []models.FormField{
+					{Key: "name", Value: "alice"},
+					{Key: "avatar", File: "./a.png", ContentType: "image/png"},
+				},
+			},
+		},
+		{
+			name:    "-F with -d is rejected",
+			command: "curl -F name=alice -d a=1 http://localhost/upload",
+			wantErr: curl.ErrConflict,
+		},
+		{
+			name:    "-u and --digest",
+			command: "curl --digest -u alice:secret http://localhost/",
+			want: models.Request{
+				Method: "GET",
+				URL:    "http://localhost/",
+				Auth:   &models.Auth{Type: models.AuthDigest, Username: "alice", Password: "secret"},
+			},
+		},
+		{
+			name:    "-I",
+			command: "curl -I http://localhost/",
+			want:    models.Request{Method: "HEAD", URL: "http://localhost/"},
+		},
+		{
+			name:    "--max-redirs 0 disables following",
+			command: "curl -L --max-redirs 0 http://localhost/",
+			want: models.Request{
+				Method:  "GET",
+				URL:     "http://localhost/",
+				Options: models.Options{FollowRedirects: boolPtr(false)},
+			},
+		},
+		{
+			name:    "--max-redirs sets the limit",
+			command: "curl -L --max-redirs 3 -m 1.5 http://localhost/",
+			want: models.Request{
+				Method:  "GET",
+				URL:     "http://localhost/",
+				Options: models.Options{FollowRedirects: boolPtr(true), MaxRedirects: 3, Timeout: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name:    "invalid --max-redirs",
+			command: "curl --max-redirs many http://localhost/",
+			wantErr: curl.ErrInvalidOption,
+		},
+		{
+			name:    "missing value",
+			command: "curl http://localhost/ -H",
+			wantErr: curl.ErrMissingValue,
+		},
+		{
+			name:    "no URL",
+			command: "curl -X POST",
+			wantErr: curl.ErrNoURL,
+		},
+		{
+			name:    "not curl",
+			command: "wget http://localhost/",
+			wantErr: curl.ErrNotCurl,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := curl.Parse(tt.command)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	_, warnings, err := curl.Parse("curl -o out.json -b cookies.txt --unknown http://localhost/ http://localhost/extra")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []string{
+		"-o out.json is ignored",
+		"-b cookies.txt: cookie files are not supported",
+		"unknown option --unknown is ignored",
+		"extra URL http://localhost/extra is ignored",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Parse() warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"http://localhost:8080/a-b_c.d/x=1,2", "http://localhost:8080/a-b_c.d/x=1,2"},
+		{"", "''"},
+		{"http://localhost/?a=1", "'http://localhost/?a=1'"},
+		{"a b", "'a b'"},
+		{"it's", `'it'\''s'`},
+		{`{"a":"$HOME"}`, `'{"a":"$HOME"}'`},
+		{"line\nbreak", "'line\nbreak'"},
+	}
+
+	for _, tt := range tests {
+		got := curl.Quote(tt.s)
+		if got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+
+		words, err := shellwords.Split(got)
+		if err != nil || len(words) != 1 || words[0] != tt.s {
+			t.Errorf("Split(Quote(%q)) = %q, %v, want the string back", tt.s, words, err)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.Request
+	}{
+		{
+			name:    "GET",
+			request: models.Request{Method: "GET", URL: "http://localhost:8080/api/v1/users?page=2", Options: models.Options{FollowRedirects: boolPtr(true)}},
+		},
+		{
+			name: "POST with a JSON body",
+			request: models.Request{
+				Method:  "POST",
+				URL:     "http://localhost/users",
+				Headers: []models.Header{{Key: "Content-Type", Value: "application/json"}},
+				Body:    `{"login":"o'brien","note":"a \\ b"}`,
+				Options: models.Options{FollowRedirects: boolPtr(true)},
+			},
+		},
+		{
+			name: "PUT with a body and options",
+			request: models.Request{
+				Method:  "PUT",
+				URL:     "https://localhost/users/1",
+				Headers: []models.Header{{Key: "Content-Type", Value: "text/plain"}, {Key: "X-Note", Value: "a b"}},
+				Body:    "multi\nline",
+				Options: models.Options{
+					Timeout:            2500 * time.Millisecond,
+					FollowRedirects:    boolPtr(true),
+					MaxRedirects:       5,
+					Proxy:              "http://proxy:3128",
+					NoProxy:            "internal.example",
+					InsecureSkipVerify: true,
+					CABundle:           "./ca.pem",
+					HTTP2:              boolPtr(false),
+				},
+			},
+		},
+		{
+			name: "redirects off",
+			request: models.Request{
+				Method:  "DELETE",
+				URL:     "http://localhost/users/1",
+				Options: models.Options{FollowRedirects: boolPtr(false)},
+			},
+		},
+		{
+			name:    "HEAD",
+			request: models.Request{Method: "HEAD", URL: "http://localhost/", Options: models.Options{FollowRedirects: boolPtr(true)}},
+		},
+		{
+			name: "multipart",
+			request: models.Request{
+				Method:   "POST",
+				URL:      "http://localhost/upload",
+				BodyMode: models.BodyFormData,
+				Form: []models.FormField{
+					{Key: "note", Value: "@not a file"},
+					{Key: "avatar", File: "./a b.png", ContentType: "image/png"},
+				},
+				Options: models.Options{FollowRedirects: boolPtr(true)},
+			},
+		},
+		{
+			name: "basic auth",
+			request: models.Request{
+				Method:  "GET",
+				URL:     "http://localhost/",
+				Auth:    &models.Auth{Type: models.AuthBasic, Username: "alice", Password: "p@ss word"},
+				Options: models.Options{FollowRedirects: boolPtr(true)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, warnings := curl.String(tt.request)
+			if len(warnings) > 0 {
+				t.Errorf("String() warnings = %q", warnings)
+			}
+
+			got, warnings, err := curl.Parse(command)
+			if err != nil {
+				t.Fatalf("Parse(%s) error = %v", command, err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("Parse() warnings = %q", warnings)
+			}
+			if !reflect.DeepEqual(got, tt.request) {
+				t.Errorf("Parse(String()) =\n%+v\nwant\n%+v\ncommand:\n%s", got, tt.request, command)
+			}
+		})
+	}
+}
+
+func TestStringLayout(t *testing.T) {
+	request := models.Request{
+		Method:  "POST",
+		URL:     "http://localhost/users",
+		Query:   []models.QueryParam{{Key: "q", Value: "a b"}, {Key: "off", Value: "1", Disabled: true}},
+		Headers: []models.Header{{Key: "Accept", Value: "*/*"}},
+		Body:    "x=1",
+		Options: models.Options{FollowRedirects: boolPtr(false)},
+	}
+
+	command, _ := curl.String(request)
+	want := "curl 'http://localhost/users?q=a+b' \\\n" +
+		"  -H 'Accept: */*' \\\n" +
+		"  --data-raw x=1 \\\n" +
+		"  --max-redirs 0"
+	if command != want {
+		t.Errorf("String() =\n%s\nwant\n%s", command, want)
+	}
+}
+
+func TestStringOAuth2(t *testing.T) {
+	request := models.Request{
+		Method: "GET",
+		URL:    "http://localhost/",
+		Auth:   &models.Auth{Type: models.AuthOAuth2, ClientID: "app"},
+	}
+
+	command, warnings := curl.String(request)
+	if strings.Contains(command, "Authorization") {
+		t.Errorf("String() = %s, want no Authorization header", command)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "oauth2") {
+		t.Errorf("String() warnings = %q, want one about oauth2", warnings)
+	}
+}
@@ -0,0 +1,81 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"postman/internal/lib/jsonpath"
+	"strings"
+)
+
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// Load reads the rows of a CSV file with a header line or of a JSON
+// array of objects. Every row maps column names to values; non-string
+// JSON values are kept as compact JSON.
+func Load(path string) ([]map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		return parseJSON(b)
+	}
+
+	return parseCSV(b)
+}
+
+func parseCSV(b []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDataset, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: a header line and at least one row are required", ErrInvalidDataset)
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			row[strings.TrimSpace(name)] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseJSON(b []byte) ([]map[string]string, error) {
+	doc, err := jsonpath.Decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDataset, err)
+	}
+
+	items, ok := doc.([]any)
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("%w: a non-empty JSON array of objects is required", ErrInvalidDataset)
+	}
+
+	rows := make([]map[string]string, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: element %d is not an object", ErrInvalidDataset, i)
+		}
+
+		row := make(map[string]string, len(obj))
+		for k, v := range obj {
+			row[k] = jsonpath.Format(v)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,145 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/lib/jsonpath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidExtractor = errors.New("invalid extractor")
+
+// Syntax describes the extractor expressions accepted by Parse.
+const Syntax = `VAR=jsonpath PATH | VAR=regex REGEX | VAR=header NAME | VAR=status`
+
+// Parse reads "VAR=SOURCE EXPRESSION", e.g. "userId=jsonpath $.id".
+// A regex extracts its first capture group, or the whole match without groups.
+func Parse(expr string) (models.Extractor, error) {
+	name, rest, found := strings.Cut(expr, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return models.Extractor{}, fmt.Errorf("%w: %q, expected %s", ErrInvalidExtractor, expr, Syntax)
+	}
+
+	source, expression, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	e := models.Extractor{
+		Variable:   name,
+		Source:     strings.ToLower(source),
+		Expression: strings.TrimSpace(expression),
+	}
+
+	if err := Validate(e); err != nil {
+		return models.Extractor{}, err
+	}
+
+	return e, nil
+}
+
+// Validate checks the source and the expression of an extractor.
+func Validate(e models.Extractor) error {
+	var err error
+	switch e.Source {
+	case models.ExtractJSONPath:
+		_, err = jsonpath.Compile(e.Expression)
+	case models.ExtractRegex:
+		_, err = regexp.Compile(e.Expression)
+	case models.ExtractHeader:
+		if e.Expression == "" {
+			err = errors.New("header name expected")
+		}
+	case models.ExtractStatus:
+		if e.Expression != "" {
+			err = errors.New("status takes no expression")
+		}
+	default:
+		err = fmt.Errorf("unknown source %q", e.Source)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidExtractor, String(e), err)
+	}
+
+	return nil
+}
+
+// String formats an extractor back into the expression syntax.
+func String(e models.Extractor) string {
+	s := e.Variable + "=" + e.Source
+	if e.Expression != "" {
+		s += " " + e.Expression
+	}
+
+	return s
+}
+
+// Apply runs the extractors against the response. It returns the found
+// values and a failed result for every extractor that found nothing.
+func Apply(list []models.Extractor, resp models.Response) (map[string]string, []models.AssertionResult) {
+	values := map[string]string{}
+	var failures []models.AssertionResult
+
+	var (
+		doc    any
+		docErr error
+		parsed bool
+	)
+	for _, e := range list {
+		if e.Source == models.ExtractJSONPath && !parsed {
+			doc, docErr = jsonpath.Decode(resp.Body)
+			parsed = true
+		}
+
+		value, err := apply(e, resp, doc, docErr)
+		if err != nil {
+			failures = append(failures, models.AssertionResult{
+				Assertion: models.Assertion{Subject: models.AssertExtract, Target: String(e)},
+				Actual:    err.Error(),
+			})
+			continue
+		}
+		values[e.Variable] = value
+	}
+
+	return values, failures
+}
+
+func apply(e models.Extractor, resp models.Response, doc any, docErr error) (string, error) {
+	if err := Validate(e); err != nil {
+		return "", err
+	}
+
+	switch e.Source {
+	case models.ExtractJSONPath:
+		if docErr != nil {
+			return "", fmt.Errorf("body is not JSON: %w", docErr)
+		}
+		found := jsonpath.MustCompile(e.Expression).Get(doc)
+		if len(found) == 0 {
+			return "", errors.New("no match")
+		}
+		return jsonpath.Format(found[0]), nil
+
+	case models.ExtractRegex:
+		m := regexp.MustCompile(e.Expression).FindSubmatch(resp.Body)
+		if m == nil {
+			return "", errors.New("no match")
+		}
+		if len(m) > 1 {
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+
+	case models.ExtractHeader:
+		values, ok := resp.Headers[http.CanonicalHeaderKey(e.Expression)]
+		if !ok {
+			return "", errors.New("header is missing")
+		}
+		return strings.Join(values, ", "), nil
+
+	default:
+		return strconv.Itoa(resp.StatusCode), nil
+	}
+}
@@ -0,0 +1,507 @@
+package har
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/bodies"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The types follow the HAR 1.2 spec: http://www.softwareishard.com/blog/har-12-spec/
+
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	ServerIPAddress string   `json:"serverIPAddress,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type PostData struct {
+	MimeType string  `json:"mimeType"`
+	Params   []Param `json:"params,omitempty"`
+	Text     string  `json:"text"`
+	Comment  string  `json:"comment,omitempty"`
+}
+
+// binaryComment starts the PostData comment of a binary file body.
+const binaryComment = "file: "
+
+// Param is a posted form field, FileName is set for a file part.
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings are in milliseconds, -1 means the phase does not apply.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+const creatorName = "postman"
+
+var ErrInvalidFile = errors.New("invalid HAR file")
+
+// New returns an empty HAR log.
+func New() HAR {
+	return HAR{
+		Log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: creatorName, Version: "1.0"},
+			Entries: []Entry{},
+		},
+	}
+}
+
+// FromExchange converts an exchange into a HAR entry. A transport error
+// is kept in the entry comment with a zero response status, the way
+// browsers record failed requests. After redirects the entry holds the
+// request as sent first and the final response, and the comment names
+// the final request, so that a replay sends the same request again.
+func FromExchange(exchange models.Exchange, sendErr error) Entry {
+	req := exchange.Request
+	resp := exchange.Response
+
+	requestURL := req.URL
+	if u, err := client.URL(req); err == nil {
+		requestURL = u.String()
+	}
+	if !resp.Redirected && resp.RequestURL != "" {
+		requestURL = resp.RequestURL
+	}
+
+	// the headers written on the wire are those of the final request
+	sent := resp.RequestHeaders
+	if len(sent) == 0 || resp.Redirected {
+		sent = http.Header{}
+		for _, h := range req.Headers {
+			sent.Add(h.Key, h.Value)
+		}
+	}
+
+	entry := Entry{
+		StartedDateTime: exchange.StartedAt.Format(time.RFC3339Nano),
+		Time:            ms(resp.Duration),
+		Request: Request{
+			Method:      req.Method,
+			URL:         requestURL,
+			HTTPVersion: httpVersion(resp.Proto),
+			Cookies:     requestCookies(sent),
+			Headers:     nameValues(sent),
+			QueryString: queryString(requestURL),
+			HeadersSize: -1,
+			BodySize:    bodySize(req),
+		},
+		Response: Response{
+			Status:      resp.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+			HTTPVersion: httpVersion(resp.Proto),
+			Cookies:     responseCookies(resp.Headers),
+			Headers:     nameValues(resp.Headers),
+			Content:     content(resp),
+			RedirectURL: resp.Headers.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(resp.Body),
+		},
+		Timings: Timings{
+			Blocked: -1,
+			DNS:     msOrNone(resp.Timings.DNS),
+			Connect: msOrNone(resp.Timings.Connect + resp.Timings.TLS),
+			SSL:     msOrNone(resp.Timings.TLS),
+			Send:    ms(resp.Timings.Send),
+			Wait:    ms(resp.Timings.Wait),
+			Receive: ms(resp.Timings.Receive),
+		},
+		ServerIPAddress: serverIP(resp.RemoteAddr),
+	}
+	var comments []string
+	if resp.Redirected {
+		comments = append(comments, fmt.Sprintf("response of %s %s after redirects", resp.RequestMethod, resp.RequestURL))
+	}
+	if sendErr != nil {
+		comments = append(comments, sendErr.Error())
+	}
+	entry.Comment = strings.Join(comments, "; ")
+
+	if req.HasBody() {
+		entry.Request.PostData = postData(req, sent.Get("Content-Type"))
+	}
+
+	return entry
+}
+
+// ToRequest converts a recorded entry back into a request for replay.
+// Headers managed by the transport are dropped.
+func ToRequest(entry Entry) models.Request {
+	request := models.Request{
+		Method: entry.Request.Method,
+		URL:    entry.Request.URL,
+	}
+
+	for _, h := range entry.Request.Headers {
+		// HTTP/2 pseudo headers and transport managed headers
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		switch http.CanonicalHeaderKey(h.Name) {
+		case "Host", "Content-Length", "Connection", "Accept-Encoding", "Transfer-Encoding":
+			continue
+		}
+		request.Headers = append(request.Headers, models.Header{Key: h.Name, Value: h.Value})
+	}
+
+	if data := entry.Request.PostData; data != nil {
+		request.Body = data.Text
+		if file, ok := strings.CutPrefix(data.Comment, binaryComment); ok && data.Text == "" {
+			request.BodyMode = models.BodyBinary
+			request.BodyFile = file
+		}
+		if mediaType, _, _ := mime.ParseMediaType(data.MimeType); mediaType == "multipart/form-data" && len(data.Params) > 0 {
+			request.Body = ""
+			request.BodyMode = models.BodyFormData
+			for _, p := range data.Params {
+				request.Form = append(request.Form, models.FormField{Key: p.Name, Value: p.Value, File: p.FileName, ContentType: p.ContentType})
+			}
+			// the recorded boundary does not match the one sent on replay
+			request.Headers = slices.DeleteFunc(request.Headers, func(h models.Header) bool {
+				return strings.EqualFold(h.Key, "Content-Type")
+			})
+		}
+	}
+
+	return request
+}
+
+// Load reads a HAR file.
+func Load(path string) (HAR, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return HAR{}, err
+	}
+
+	var h HAR
+	if err := json.Unmarshal(b, &h); err != nil {
+		return HAR{}, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return h, nil
+}
+
+// Save writes a HAR file. It is written to a temporary file first and
+// renamed, so an interrupted write leaves the previous file intact.
+func Save(path string, h HAR) error {
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Recorder collects exchanges in memory and appends them to a HAR file
+// on Close.
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{
+		path: path,
+	}
+}
+
+// Record implements service.IExchangeRecorder.
+func (r *Recorder) Record(_ context.Context, exchange models.Exchange, sendErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, FromExchange(exchange, sendErr))
+
+	return nil
+}
+
+// Close appends the recorded exchanges to the file, which is created when
+// missing. The recorder can be used again afterwards.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	h, err := Load(r.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		h = New()
+	}
+
+	h.Log.Entries = append(h.Log.Entries, r.entries...)
+	if err := Save(r.path, h); err != nil {
+		return err
+	}
+	r.entries = nil
+
+	return nil
+}
+
+func content(resp models.Response) Content {
+	mimeType := resp.Headers.Get("Content-Type")
+	if mimeType == "" && len(resp.Body) > 0 {
+		mimeType = http.DetectContentType(resp.Body)
+	}
+
+	c := Content{
+		Size:     len(resp.Body),
+		MimeType: mimeType,
+	}
+
+	if utf8.Valid(resp.Body) {
+		c.Text = string(resp.Body)
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(resp.Body)
+		c.Encoding = "base64"
+	}
+
+	return c
+}
+
+func nameValues(header http.Header) []NameValue {
+	result := []NameValue{}
+	for key, values := range header {
+		for _, v := range values {
+			result = append(result, NameValue{Name: key, Value: v})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func queryString(rawURL string) []NameValue {
+	result := []NameValue{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return result
+	}
+
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		k, _ := url.QueryUnescape(key)
+		v, _ := url.QueryUnescape(value)
+		result = append(result, NameValue{Name: k, Value: v})
+	}
+
+	return result
+}
+
+// postData records the body of a request. Multipart fields are kept as
+// params, with the file names of file parts, and a binary body as the
+// file name in the comment, since neither is text.
+func postData(req models.Request, mimeType string) *PostData {
+	data := &PostData{MimeType: mimeType}
+
+	switch req.BodyMode {
+	case models.BodyURLEncoded:
+		data.Text = bodies.URLEncode(req.Form)
+	case models.BodyFormData:
+		for _, f := range req.Form {
+			if !f.Disabled {
+				data.Params = append(data.Params, Param{Name: f.Key, Value: f.Value, FileName: f.File, ContentType: f.ContentType})
+			}
+		}
+		return data
+	case models.BodyBinary:
+		data.Comment = binaryComment + req.BodyFile
+		return data
+	default:
+		data.Text = req.Body
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(mimeType); mediaType == "application/x-www-form-urlencoded" {
+		for _, p := range queryString("?" + data.Text) {
+			data.Params = append(data.Params, Param{Name: p.Name, Value: p.Value})
+		}
+	}
+
+	return data
+}
+
+// bodySize is the size of a text body, -1 when the size is unknown.
+func bodySize(req models.Request) int {
+	switch req.BodyMode {
+	case models.BodyURLEncoded:
+		return len(bodies.URLEncode(req.Form))
+	case models.BodyFormData, models.BodyBinary:
+		return -1
+	}
+
+	return len(req.Body)
+}
+
+func requestCookies(header http.Header) []Cookie {
+	result := []Cookie{}
+	for _, c := range (&http.Request{Header: header}).Cookies() {
+		result = append(result, Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	return result
+}
+
+func responseCookies(header http.Header) []Cookie {
+	result := []Cookie{}
+	for _, c := range (&http.Response{Header: header}).Cookies() {
+		cookie := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			cookie.Expires = c.Expires.Format(time.RFC3339)
+		}
+		result = append(result, cookie)
+	}
+
+	return result
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return proto
+}
+
+func serverIP(remoteAddr string) string {
+	host := remoteAddr
+	if i := strings.LastIndex(remoteAddr, ":"); i >= 0 {
+		host = remoteAddr[:i]
+	}
+
+	return strings.Trim(host, "[]")
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func msOrNone(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+
+	return ms(d)
+}
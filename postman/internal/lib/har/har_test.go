@@ -0,0 +1,81 @@
+package har_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/har"
+	"strings"
+	"testing"
+)
+
+func TestFromExchangeRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/done", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/keep", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/done", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/done", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Method)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		query       []models.QueryParam
+		wantComment string
+	}{
+		{name: "no redirect", path: "/done", query: []models.QueryParam{{Key: "q", Value: "1"}}},
+		{name: "303 turns POST into GET", path: "/form", wantComment: "response of GET " + server.URL + "/done after redirects"},
+		{name: "307 keeps POST", path: "/keep", wantComment: "response of POST " + server.URL + "/done after redirects"},
+	}
+
+	c := client.New(slog.New(slog.NewTextHandler(io.Discard, nil)), models.Options{}, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := models.Request{
+				Method:  http.MethodPost,
+				URL:     server.URL + tt.path,
+				Query:   tt.query,
+				Headers: []models.Header{{Key: "Content-Type", Value: "application/json"}},
+				Body:    `{"a":1}`,
+			}
+			resp, err := c.Do(context.Background(), request, nil)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+
+			entry := har.FromExchange(models.Exchange{Request: request, Response: resp}, nil)
+
+			wantURL := request.URL
+			if len(tt.query) > 0 {
+				wantURL += "?q=1"
+			}
+			if entry.Request.Method != http.MethodPost || entry.Request.URL != wantURL {
+				t.Errorf("entry request = %s %s, want POST %s", entry.Request.Method, entry.Request.URL, wantURL)
+			}
+			if entry.Request.PostData == nil || entry.Request.PostData.Text != request.Body {
+				t.Errorf("entry post data = %+v, want the body sent", entry.Request.PostData)
+			}
+			if entry.Response.Status != http.StatusOK {
+				t.Errorf("entry status = %d, want the final 200", entry.Response.Status)
+			}
+			if entry.Comment != tt.wantComment {
+				t.Errorf("entry comment = %q, want %q", entry.Comment, tt.wantComment)
+			}
+
+			replayed := har.ToRequest(entry)
+			if replayed.Method != http.MethodPost || !strings.HasPrefix(replayed.URL, server.URL+tt.path) {
+				t.Errorf("ToRequest() = %s %s, want the request sent first", replayed.Method, replayed.URL)
+			}
+		})
+	}
+}
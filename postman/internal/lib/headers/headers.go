@@ -0,0 +1,107 @@
+package headers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+var (
+	ErrInvalidName  = errors.New("invalid header name")
+	ErrInvalidValue = errors.New("invalid header value")
+)
+
+// Parse parses a header in "Key: Value" form.
+func Parse(line string) (models.Header, error) {
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return models.Header{}, fmt.Errorf("%w: expected \"Key: Value\", got %q", ErrInvalidName, line)
+	}
+
+	header := models.Header{
+		Key:   strings.TrimSpace(key),
+		Value: strings.TrimSpace(value),
+	}
+
+	if err := Validate(header); err != nil {
+		return models.Header{}, err
+	}
+
+	return header, nil
+}
+
+// Validate checks the name is an RFC 9110 token and the value has no control characters.
+func Validate(header models.Header) error {
+	if header.Key == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidName)
+	}
+
+	if !IsToken(header.Key) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, header.Key)
+	}
+
+	for _, r := range header.Value {
+		if (r < ' ' && r != '\t') || r == 0x7f {
+			return fmt.Errorf("%w: %q", ErrInvalidValue, header.Value)
+		}
+	}
+
+	return nil
+}
+
+// Apply adds the headers to req in order. Repeated keys are sent as
+// repeated headers; Host is applied to req.Host as net/http ignores it
+// in the header map.
+func Apply(req *http.Request, headers []models.Header) {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, "Host") {
+			req.Host = h.Value
+			continue
+		}
+
+		req.Header.Add(h.Key, h.Value)
+	}
+}
+
+// Has reports whether a header with the key is in the list, ignoring case.
+func Has(headers []models.Header, key string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String formats the header as "Key: Value".
+func String(header models.Header) string {
+	return header.Key + ": " + header.Value
+}
+
+// IsToken reports whether s is an RFC 9110 token, the syntax of both
+// header names and request methods.
+func IsToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !isTokenChar(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
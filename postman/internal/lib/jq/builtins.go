@@ -0,0 +1,821 @@
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// builtin is a function called with its input and its arguments, which
+// are filters evaluated against the input as the function needs.
+type builtin func(in any, args []expr) ([]any, error)
+
+// builtins are keyed by name and number of arguments, e.g. "map/1".
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"empty/0":         func(any, []expr) ([]any, error) { return nil, nil },
+		"not/0":           unary(func(v any) (any, error) { return !truthy(v), nil }),
+		"length/0":        unary(length),
+		"keys/0":          unary(keys(true)),
+		"keys_unsorted/0": unary(keys(false)),
+		"values/0":        selectWith(func(v any) bool { return v != nil }),
+		"nulls/0":         selectType("null"),
+		"booleans/0":      selectType("boolean"),
+		"numbers/0":       selectType("number"),
+		"strings/0":       selectType("string"),
+		"arrays/0":        selectType("array"),
+		"objects/0":       selectType("object"),
+		"type/0":          unary(func(v any) (any, error) { return typeOf(v), nil }),
+		"tostring/0":      unary(func(v any) (any, error) { return tostring(v), nil }),
+		"tonumber/0":      unary(tonumber),
+		"tojson/0":        unary(func(v any) (any, error) { return string(Marshal(v)), nil }),
+		"fromjson/0":      unary(fromjson),
+		"ascii_downcase/0": unary(stringFunc("ascii_downcase", func(s string) any {
+			return strings.Map(func(r rune) rune {
+				if r >= 'A' && r <= 'Z' {
+					return r + 'a' - 'A'
+				}
+				return r
+			}, s)
+		})),
+		"ascii_upcase/0": unary(stringFunc("ascii_upcase", func(s string) any {
+			return strings.Map(func(r rune) rune {
+				if r >= 'a' && r <= 'z' {
+					return r + 'A' - 'a'
+				}
+				return r
+			}, s)
+		})),
+		"first/0":        unary(func(v any) (any, error) { return indexValue(v, intNumber(0)) }),
+		"last/0":         unary(func(v any) (any, error) { return indexValue(v, intNumber(-1)) }),
+		"first/1":        first,
+		"last/1":         last,
+		"limit/2":        limit,
+		"add/0":          unary(addAll),
+		"any/0":          unary(anyAll("any", true)),
+		"all/0":          unary(anyAll("all", false)),
+		"any/1":          anyAllBy(true),
+		"all/1":          anyAllBy(false),
+		"reverse/0":      unary(reverse),
+		"flatten/0":      unary(func(v any) (any, error) { return flatten(v) }),
+		"sort/0":         sortBy("sort", nil),
+		"sort_by/1":      func(in any, args []expr) ([]any, error) { return sortBy("sort_by", args[0])(in, nil) },
+		"group_by/1":     groupBy,
+		"unique/0":       uniqueBy("unique", nil),
+		"unique_by/1":    func(in any, args []expr) ([]any, error) { return uniqueBy("unique_by", args[0])(in, nil) },
+		"min/0":          extreme("min", nil, -1),
+		"max/0":          extreme("max", nil, 1),
+		"min_by/1":       func(in any, args []expr) ([]any, error) { return extreme("min_by", args[0], -1)(in, nil) },
+		"max_by/1":       func(in any, args []expr) ([]any, error) { return extreme("max_by", args[0], 1)(in, nil) },
+		"map/1":          mapItems,
+		"map_values/1":   mapValues,
+		"select/1":       selectItems,
+		"recurse/0":      func(in any, _ []expr) ([]any, error) { return recurse{}.eval(in) },
+		"range/1":        rangeNumbers,
+		"range/2":        rangeNumbers,
+		"to_entries/0":   unary(toEntries),
+		"from_entries/0": unary(fromEntries),
+		"with_entries/1": withEntries,
+		"has/1":          binaryFunc(has),
+		"contains/1":     binaryFunc(func(a, b any) (any, error) { return contains(a, b) }),
+		"startswith/1": binaryFunc(stringsFunc("startswith", func(s, prefix string) any {
+			return strings.HasPrefix(s, prefix)
+		})),
+		"endswith/1": binaryFunc(stringsFunc("endswith", func(s, suffix string) any {
+			return strings.HasSuffix(s, suffix)
+		})),
+		"ltrimstr/1": binaryFunc(trimFunc(strings.TrimPrefix)),
+		"rtrimstr/1": binaryFunc(trimFunc(strings.TrimSuffix)),
+		"split/1": binaryFunc(stringsFunc("split", func(s, sep string) any {
+			return split(s, sep)
+		})),
+		"join/1": binaryFunc(join),
+		"test/1": binaryFunc(func(s, re any) (any, error) { return test(s, re, "") }),
+		"test/2": func(in any, args []expr) ([]any, error) {
+			return withArgs(in, args, func(re, flags any) (any, error) {
+				f, ok := flags.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s is not a string of regex flags", describe(flags))
+				}
+				return test(in, re, f)
+			})
+		},
+	}
+}
+
+// unary makes a builtin of a function of the input alone.
+func unary(f func(any) (any, error)) builtin {
+	return func(in any, _ []expr) ([]any, error) {
+		v, err := f(in)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// binaryFunc makes a builtin of a function of the input and every output
+// of its argument.
+func binaryFunc(f func(in, arg any) (any, error)) builtin {
+	return func(in any, args []expr) ([]any, error) {
+		values, err := args[0].eval(in)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]any, 0, len(values))
+		for _, arg := range values {
+			v, err := f(in, arg)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
+
+// withArgs calls f with every combination of the outputs of two arguments.
+func withArgs(in any, args []expr, f func(a, b any) (any, error)) ([]any, error) {
+	as, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := args[1].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, b := range bs {
+		for _, a := range as {
+			v, err := f(a, b)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
+
+func stringFunc(name string, f func(string) any) func(any) (any, error) {
+	return func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s cannot be used with %s, a string is required", name, describe(v))
+		}
+		return f(s), nil
+	}
+}
+
+func stringsFunc(name string, f func(a, b string) any) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		as, aok := a.(string)
+		bs, bok := b.(string)
+		if !aok || !bok {
+			return nil, fmt.Errorf("%s requires strings, got %s and %s", name, describe(a), describe(b))
+		}
+		return f(as, bs), nil
+	}
+}
+
+// trimFunc leaves the input as is unless both are strings.
+func trimFunc(trim func(s, affix string) string) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		as, aok := a.(string)
+		bs, bok := b.(string)
+		if !aok || !bok {
+			return a, nil
+		}
+		return trim(as, bs), nil
+	}
+}
+
+func keys(sorted bool) func(any) (any, error) {
+	return func(v any) (any, error) {
+		switch v := v.(type) {
+		case *object:
+			names := v.keys
+			if sorted {
+				names = v.sortedKeys()
+			}
+			return stringsToValues(names), nil
+		case []any:
+			out := make([]any, len(v))
+			for i := range v {
+				out[i] = intNumber(i)
+			}
+			return out, nil
+		}
+
+		return nil, fmt.Errorf("%s has no keys", describe(v))
+	}
+}
+
+func fromjson(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be parsed as JSON, a string is required", describe(v))
+	}
+
+	doc, err := Decode([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot be parsed as JSON: %w", describe(v), err)
+	}
+
+	return doc, nil
+}
+
+// items returns the items of an array, for the functions that need one.
+func items(name string, v any) ([]any, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be used with %s, an array is required", name, describe(v))
+	}
+
+	return arr, nil
+}
+
+func first(in any, args []expr) ([]any, error) {
+	out, err := args[0].eval(in)
+	if len(out) > 0 {
+		return out[:1], nil
+	}
+
+	return nil, err
+}
+
+func last(in any, args []expr) ([]any, error) {
+	out, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > 0 {
+		return out[len(out)-1:], nil
+	}
+
+	return nil, nil
+}
+
+func limit(in any, args []expr) ([]any, error) {
+	counts, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, c := range counts {
+		n, ok := c.(json.Number)
+		if !ok {
+			return out, fmt.Errorf("limit requires a number, got %s", describe(c))
+		}
+		i, _ := toInt(n)
+		if i <= 0 {
+			continue
+		}
+		values, err := args[1].eval(in)
+		if len(values) > i {
+			values, err = values[:i], nil
+		}
+		out = append(out, values...)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	return out, nil
+}
+
+func addAll(v any) (any, error) {
+	list, err := values(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum any
+	for _, item := range list {
+		if sum, err = add(sum, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return sum, nil
+}
+
+func anyAll(name string, isAny bool) func(any) (any, error) {
+	return func(v any) (any, error) {
+		list, err := items(name, v)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			if truthy(item) == isAny {
+				return isAny, nil
+			}
+		}
+		return !isAny, nil
+	}
+}
+
+func anyAllBy(isAny bool) builtin {
+	return func(in any, args []expr) ([]any, error) {
+		list, err := values(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			results, err := args[0].eval(item)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range results {
+				if truthy(r) == isAny {
+					return []any{isAny}, nil
+				}
+			}
+		}
+		return []any{!isAny}, nil
+	}
+}
+
+func reverse(v any) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return []any{}, nil
+	case string:
+		runes := []rune(v)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	}
+
+	list, err := items("reverse", v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(list))
+	for i, item := range list {
+		out[len(list)-1-i] = item
+	}
+
+	return out, nil
+}
+
+func flatten(v any) ([]any, error) {
+	list, err := items("flatten", v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []any{}
+	for _, item := range list {
+		if nested, ok := item.([]any); ok {
+			flat, _ := flatten(nested)
+			out = append(out, flat...)
+			continue
+		}
+		out = append(out, item)
+	}
+
+	return out, nil
+}
+
+// keyed pairs the items of an array with the array of outputs of f, or
+// with the items themselves when f is nil.
+type keyed struct {
+	key  any
+	item any
+}
+
+func keyItems(name string, in any, f expr) ([]keyed, error) {
+	list, err := items(name, in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]keyed, len(list))
+	for i, item := range list {
+		out[i] = keyed{key: item, item: item}
+		if f != nil {
+			key, err := f.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i].key = append([]any{}, key...)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return compare(out[i].key, out[j].key) < 0 })
+
+	return out, nil
+}
+
+func sortBy(name string, f expr) builtin {
+	return func(in any, _ []expr) ([]any, error) {
+		pairs, err := keyItems(name, in, f)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(pairs))
+		for i, p := range pairs {
+			out[i] = p.item
+		}
+		return []any{out}, nil
+	}
+}
+
+func groupBy(in any, args []expr) ([]any, error) {
+	pairs, err := keyItems("group_by", in, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []any{}
+	for i, p := range pairs {
+		if i == 0 || compare(p.key, pairs[i-1].key) != 0 {
+			groups = append(groups, []any{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1].([]any), p.item)
+	}
+
+	return []any{groups}, nil
+}
+
+func uniqueBy(name string, f expr) builtin {
+	return func(in any, _ []expr) ([]any, error) {
+		pairs, err := keyItems(name, in, f)
+		if err != nil {
+			return nil, err
+		}
+		out := []any{}
+		for i, p := range pairs {
+			if i == 0 || compare(p.key, pairs[i-1].key) != 0 {
+				out = append(out, p.item)
+			}
+		}
+		return []any{out}, nil
+	}
+}
+
+// extreme is min and max, sign -1 for the smallest item. An empty array
+// gives null.
+func extreme(name string, f expr, sign int) builtin {
+	return func(in any, _ []expr) ([]any, error) {
+		pairs, err := keyItems(name, in, f)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(pairs) == 0:
+			return []any{nil}, nil
+		case sign < 0:
+			return []any{pairs[0].item}, nil
+		}
+		// the last of equal items, as jq
+		return []any{pairs[len(pairs)-1].item}, nil
+	}
+}
+
+// mapItems is map(f), [.[] | f].
+func mapItems(in any, args []expr) ([]any, error) {
+	list, err := values(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []any{}
+	for _, item := range list {
+		results, err := args[0].eval(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, results...)
+	}
+
+	return []any{out}, nil
+}
+
+// mapValues is map_values(f), the first output of f for every item of an
+// array or value of an object, dropping those without one.
+func mapValues(in any, args []expr) ([]any, error) {
+	apply := func(v any) (any, bool, error) {
+		results, err := args[0].eval(v)
+		if err != nil || len(results) == 0 {
+			return nil, false, err
+		}
+		return results[0], true, nil
+	}
+
+	switch v := in.(type) {
+	case []any:
+		out := []any{}
+		for _, item := range v {
+			r, ok, err := apply(item)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, r)
+			}
+		}
+		return []any{out}, nil
+
+	case *object:
+		out := newObject()
+		for _, k := range v.keys {
+			r, ok, err := apply(v.values[k])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out.set(k, r)
+			}
+		}
+		return []any{out}, nil
+	}
+
+	return nil, fmt.Errorf("cannot iterate over %s", describe(in))
+}
+
+// selectItems is select(f), the input once for every true output of f.
+func selectItems(in any, args []expr) ([]any, error) {
+	results, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, r := range results {
+		if truthy(r) {
+			out = append(out, in)
+		}
+	}
+
+	return out, nil
+}
+
+func selectWith(keep func(any) bool) builtin {
+	return func(in any, _ []expr) ([]any, error) {
+		if keep(in) {
+			return []any{in}, nil
+		}
+		return nil, nil
+	}
+}
+
+// selectType is nulls, numbers, strings and the like, the input when it
+// has the type.
+func selectType(name string) builtin {
+	return selectWith(func(v any) bool { return typeOf(v) == name })
+}
+
+// rangeNumbers is range(n), 0 to n-1, and range(from; upto).
+func rangeNumbers(in any, args []expr) ([]any, error) {
+	bounds := make([][]any, len(args))
+	for i, arg := range args {
+		values, err := arg.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = values
+	}
+	if len(bounds) == 1 {
+		bounds = [][]any{{intNumber(0)}, bounds[0]}
+	}
+
+	var out []any
+	for _, from := range bounds[0] {
+		for _, upto := range bounds[1] {
+			fn, fok := from.(json.Number)
+			un, uok := upto.(json.Number)
+			if !fok || !uok {
+				return out, fmt.Errorf("range requires numbers, got %s and %s", describe(from), describe(upto))
+			}
+			f, _ := toInt(fn)
+			u, _ := toInt(un)
+			for i := f; i < u; i++ {
+				out = append(out, intNumber(i))
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func toEntries(v any) (any, error) {
+	obj, ok := v.(*object)
+	if !ok {
+		return nil, fmt.Errorf("to_entries cannot be used with %s, an object is required", describe(v))
+	}
+
+	out := make([]any, len(obj.keys))
+	for i, k := range obj.keys {
+		e := newObject()
+		e.set("key", k)
+		e.set("value", obj.values[k])
+		out[i] = e
+	}
+
+	return out, nil
+}
+
+// fromEntries accepts the key and value names jq does: key, k, name,
+// Name, Key, K and value, v, Value, V.
+func fromEntries(v any) (any, error) {
+	list, err := items("from_entries", v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := newObject()
+	for _, item := range list {
+		e, ok := item.(*object)
+		if !ok {
+			return nil, fmt.Errorf("from_entries cannot use %s, an object is required", describe(item))
+		}
+
+		var key, value any
+		for _, name := range []string{"key", "k", "name", "Name", "Key", "K"} {
+			if k, ok := e.get(name); ok && truthy(k) {
+				key = k
+				break
+			}
+		}
+		for _, name := range []string{"value", "v", "Value", "V"} {
+			if v, ok := e.get(name); ok {
+				value = v
+				break
+			}
+		}
+
+		switch k := key.(type) {
+		case string:
+			out.set(k, value)
+		case json.Number, bool:
+			out.set(tostring(k), value)
+		default:
+			return nil, fmt.Errorf("from_entries cannot use %s as a key", describe(key))
+		}
+	}
+
+	return out, nil
+}
+
+// withEntries is with_entries(f), to_entries | map(f) | from_entries.
+func withEntries(in any, args []expr) ([]any, error) {
+	entries, err := toEntries(in)
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := mapItems(entries, args)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fromEntries(mapped[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{obj}, nil
+}
+
+func has(in, key any) (any, error) {
+	switch v := in.(type) {
+	case *object:
+		if k, ok := key.(string); ok {
+			_, found := v.get(k)
+			return found, nil
+		}
+	case []any:
+		if k, ok := key.(json.Number); ok {
+			i, ok := toInt(k)
+			return ok && i >= 0 && i < len(v), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot check whether %s has %s", describe(in), describe(key))
+}
+
+// contains is true when b is a substring of a, every item of b is
+// contained in an item of a, or every key of b has a value contained in
+// the one of a.
+func contains(a, b any) (bool, error) {
+	if typeOf(a) != typeOf(b) {
+		return false, fmt.Errorf("%s and %s cannot have their containment checked", describe(a), describe(b))
+	}
+
+	switch a := a.(type) {
+	case string:
+		return strings.Contains(a, b.(string)), nil
+
+	case []any:
+		for _, want := range b.([]any) {
+			found := false
+			for _, item := range a {
+				if ok, _ := contains(item, want); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case *object:
+		b := b.(*object)
+		for _, k := range b.keys {
+			v, ok := a.get(k)
+			if !ok {
+				return false, nil
+			}
+			if found, err := contains(v, b.values[k]); err != nil || !found {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return compare(a, b) == 0, nil
+}
+
+func join(in, sep any) (any, error) {
+	list, err := items("join", in)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := sep.(string)
+	if !ok {
+		return nil, fmt.Errorf("join requires a string separator, got %s", describe(sep))
+	}
+
+	parts := make([]string, len(list))
+	for i, item := range list {
+		switch item := item.(type) {
+		case nil:
+		case string:
+			parts[i] = item
+		case json.Number, bool:
+			parts[i] = tostring(item)
+		default:
+			return nil, fmt.Errorf("join cannot use %s", describe(item))
+		}
+	}
+
+	return strings.Join(parts, s), nil
+}
+
+// test matches a string against a regular expression in Go syntax. The
+// flags i (ignore case), x (extended) and g (ignored) are supported.
+func test(in, re any, flags string) (any, error) {
+	s, sok := in.(string)
+	pattern, pok := re.(string)
+	if !sok || !pok {
+		return nil, fmt.Errorf("test requires strings, got %s and %s", describe(in), describe(re))
+	}
+
+	var prefix string
+	for _, f := range flags {
+		switch f {
+		case 'i':
+			prefix += "i"
+		case 'x':
+			pattern = extendedPattern(pattern)
+		case 'g':
+		default:
+			return nil, fmt.Errorf("unsupported regex flag %q", f)
+		}
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", re, err)
+	}
+
+	return compiled.MatchString(s), nil
+}
+
+// extendedPattern drops the whitespace and # comments of a pattern.
+func extendedPattern(pattern string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(pattern, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, r := range line {
+			if r != ' ' && r != '\t' && r != '\r' {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	return b.String()
+}
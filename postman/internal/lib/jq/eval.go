@@ -0,0 +1,674 @@
+package jq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// expr is a node of a compiled filter. eval returns every output for an
+// input; on an error the outputs produced before it.
+type expr interface {
+	eval(in any) ([]any, error)
+}
+
+type identity struct{}
+
+func (identity) eval(in any) ([]any, error) {
+	return []any{in}, nil
+}
+
+// recurse is .., the input and everything in it, depth first.
+type recurse struct{}
+
+func (recurse) eval(in any) ([]any, error) {
+	out := []any{in}
+	children, _ := values(in)
+	for _, child := range children {
+		more, _ := recurse{}.eval(child)
+		out = append(out, more...)
+	}
+
+	return out, nil
+}
+
+type literal struct {
+	value any
+}
+
+func (l literal) eval(any) ([]any, error) {
+	return []any{l.value}, nil
+}
+
+type pipe struct {
+	left, right expr
+}
+
+func (p pipe) eval(in any) ([]any, error) {
+	left, err := p.left.eval(in)
+	var out []any
+	for _, v := range left {
+		right, err := p.right.eval(v)
+		out = append(out, right...)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	return out, err
+}
+
+type comma struct {
+	left, right expr
+}
+
+func (c comma) eval(in any) ([]any, error) {
+	left, err := c.left.eval(in)
+	if err != nil {
+		return left, err
+	}
+	right, err := c.right.eval(in)
+
+	return append(left, right...), err
+}
+
+// alternative is a // b: the outputs of a that are neither false nor
+// null, or the outputs of b when there are none.
+type alternative struct {
+	left, right expr
+}
+
+func (a alternative) eval(in any) ([]any, error) {
+	left, _ := a.left.eval(in)
+	var out []any
+	for _, v := range left {
+		if truthy(v) {
+			out = append(out, v)
+		}
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+
+	return a.right.eval(in)
+}
+
+type logical struct {
+	or          bool
+	left, right expr
+}
+
+func (l logical) eval(in any) ([]any, error) {
+	left, err := l.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, v := range left {
+		if truthy(v) == l.or {
+			out = append(out, l.or)
+			continue
+		}
+		right, err := l.right.eval(in)
+		if err != nil {
+			return out, err
+		}
+		for _, r := range right {
+			out = append(out, truthy(r))
+		}
+	}
+
+	return out, nil
+}
+
+type negate struct {
+	operand expr
+}
+
+func (n negate) eval(in any) ([]any, error) {
+	values, err := n.operand.eval(in)
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		num, ok := v.(json.Number)
+		if !ok {
+			return out, fmt.Errorf("%s cannot be negated", describe(v))
+		}
+		out = append(out, json.Number(strings.TrimPrefix("-"+num.String(), "--")))
+	}
+
+	return out, err
+}
+
+type binary struct {
+	op          string
+	left, right expr
+}
+
+// eval applies the operator to every pair of outputs, the right operand
+// varying slowest as in jq.
+func (b binary) eval(in any) ([]any, error) {
+	right, err := b.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	left, err := b.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, r := range right {
+		for _, l := range left {
+			v, err := operate(b.op, l, r)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
+
+func operate(op string, l, r any) (any, error) {
+	switch op {
+	case "==":
+		return compare(l, r) == 0, nil
+	case "!=":
+		return compare(l, r) != 0, nil
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	case "+":
+		return add(l, r)
+	}
+
+	ln, lok := l.(json.Number)
+	rn, rok := r.(json.Number)
+	if lok && rok {
+		return arithmetic(op, ln, rn)
+	}
+
+	switch {
+	case op == "-" && typeOf(l) == "array" && typeOf(r) == "array":
+		var out []any
+		for _, v := range l.([]any) {
+			if !containsValue(r.([]any), v) {
+				out = append(out, v)
+			}
+		}
+		return append([]any{}, out...), nil
+
+	case op == "/" && typeOf(l) == "string" && typeOf(r) == "string":
+		return split(l.(string), r.(string)), nil
+	}
+
+	return nil, fmt.Errorf("%s and %s cannot be used with %s", describe(l), describe(r), op)
+}
+
+// add is +: numbers are summed, strings and arrays joined, objects
+// merged, and null added to anything is that thing.
+func add(l, r any) (any, error) {
+	switch {
+	case l == nil:
+		return r, nil
+	case r == nil:
+		return l, nil
+	}
+
+	switch l := l.(type) {
+	case json.Number:
+		if r, ok := r.(json.Number); ok {
+			return arithmetic("+", l, r)
+		}
+	case string:
+		if r, ok := r.(string); ok {
+			return l + r, nil
+		}
+	case []any:
+		if r, ok := r.([]any); ok {
+			return append(append([]any{}, l...), r...), nil
+		}
+	case *object:
+		if r, ok := r.(*object); ok {
+			merged := l.clone()
+			for _, k := range r.keys {
+				merged.set(k, r.values[k])
+			}
+			return merged, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s and %s cannot be added", describe(l), describe(r))
+}
+
+// arithmetic computes integers exactly, whatever their size, and
+// everything else as float64.
+func arithmetic(op string, l, r json.Number) (any, error) {
+	li, lok := bigInt(l)
+	ri, rok := bigInt(r)
+	if lok && rok {
+		switch op {
+		case "+":
+			return json.Number(new(big.Int).Add(li, ri).String()), nil
+		case "-":
+			return json.Number(new(big.Int).Sub(li, ri).String()), nil
+		case "*":
+			return json.Number(new(big.Int).Mul(li, ri).String()), nil
+		case "/", "%":
+			if ri.Sign() == 0 {
+				return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", l, r)
+			}
+			q, m := new(big.Int).QuoRem(li, ri, new(big.Int))
+			if op == "%" {
+				return json.Number(m.String()), nil
+			}
+			if m.Sign() == 0 {
+				return json.Number(q.String()), nil
+			}
+		}
+	}
+
+	lf, err := l.Float64()
+	if err != nil {
+		return nil, err
+	}
+	rf, err := r.Float64()
+	if err != nil {
+		return nil, err
+	}
+
+	var f float64
+	switch op {
+	case "+":
+		f = lf + rf
+	case "-":
+		f = lf - rf
+	case "*":
+		f = lf * rf
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", l, r)
+		}
+		f = lf / rf
+	case "%":
+		li, lok := toInt(l)
+		ri, rok := toInt(r)
+		if !lok || !rok || ri == 0 {
+			return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", l, r)
+		}
+		return intNumber(li % ri), nil
+	}
+
+	return floatNumber(f), nil
+}
+
+// index is .[key], the key evaluated against the input of the whole path.
+type index struct {
+	target, key expr
+}
+
+func (ix index) eval(in any) ([]any, error) {
+	targets, err := ix.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := ix.key.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, t := range targets {
+		for _, k := range keys {
+			v, err := indexValue(t, k)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
+
+func indexValue(v, key any) (any, error) {
+	switch k := key.(type) {
+	case string:
+		switch v := v.(type) {
+		case nil:
+			return nil, nil
+		case *object:
+			value, _ := v.get(k)
+			return value, nil
+		}
+
+	case json.Number:
+		switch v := v.(type) {
+		case nil:
+			return nil, nil
+		case []any:
+			i, ok := toInt(k)
+			if !ok {
+				return nil, nil
+			}
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return nil, nil
+			}
+			return v[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot index %s with %s", typeOf(v), describe(key))
+}
+
+type iterate struct {
+	target expr
+}
+
+func (it iterate) eval(in any) ([]any, error) {
+	targets, err := it.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, t := range targets {
+		children, err := values(t)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, children...)
+	}
+
+	return out, nil
+}
+
+// values returns the items of an array or the values of an object.
+func values(v any) ([]any, error) {
+	switch v := v.(type) {
+	case []any:
+		return v, nil
+	case *object:
+		out := make([]any, 0, len(v.keys))
+		for _, k := range v.keys {
+			out = append(out, v.values[k])
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("cannot iterate over %s", describe(v))
+}
+
+// slice is .[from:to] of an array or a string, either bound optional.
+type slice struct {
+	target, from, to expr
+}
+
+func (s slice) eval(in any) ([]any, error) {
+	targets, err := s.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	from, err := sliceBound(s.from, in)
+	if err != nil {
+		return nil, err
+	}
+	to, err := sliceBound(s.to, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, t := range targets {
+		switch t := t.(type) {
+		case nil:
+			out = append(out, nil)
+		case []any:
+			i, j := sliceRange(from, to, len(t))
+			out = append(out, append([]any{}, t[i:j]...))
+		case string:
+			runes := []rune(t)
+			i, j := sliceRange(from, to, len(runes))
+			out = append(out, string(runes[i:j]))
+		default:
+			return out, fmt.Errorf("cannot slice %s", describe(t))
+		}
+	}
+
+	return out, nil
+}
+
+func sliceBound(e expr, in any) (*int, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	values, err := e.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, errors.New("a slice bound must be a single number")
+	}
+	n, ok := values[0].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("a slice bound must be a number, not %s", describe(values[0]))
+	}
+	i, ok := toInt(n)
+	if !ok {
+		return nil, fmt.Errorf("slice bound %s is out of range", n)
+	}
+
+	return &i, nil
+}
+
+func sliceRange(from, to *int, length int) (int, int) {
+	bound := func(b *int, fallback int) int {
+		if b == nil {
+			return fallback
+		}
+		i := *b
+		if i < 0 {
+			i += length
+		}
+		return max(0, min(i, length))
+	}
+
+	i, j := bound(from, 0), bound(to, length)
+	if j < i {
+		j = i
+	}
+
+	return i, j
+}
+
+// try is e? and try e: the outputs of e up to its first error.
+type try struct {
+	body expr
+}
+
+func (t try) eval(in any) ([]any, error) {
+	out, _ := t.body.eval(in)
+	return out, nil
+}
+
+// collect is [e], an array of every output of e.
+type collect struct {
+	body expr
+}
+
+func (c collect) eval(in any) ([]any, error) {
+	if c.body == nil {
+		return []any{[]any{}}, nil
+	}
+
+	out, err := c.body.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{append([]any{}, out...)}, nil
+}
+
+type entry struct {
+	key, value expr
+}
+
+// construct is {...}, one object for every combination of the outputs
+// of its keys and values.
+type construct struct {
+	entries []entry
+}
+
+func (c construct) eval(in any) ([]any, error) {
+	objects := []*object{newObject()}
+	for _, e := range c.entries {
+		keys, err := e.key.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		values, err := e.value.eval(in)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []*object
+		for _, o := range objects {
+			for _, k := range keys {
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("object keys must be strings, not %s", describe(k))
+				}
+				for _, v := range values {
+					obj := o.clone()
+					obj.set(key, v)
+					next = append(next, obj)
+				}
+			}
+		}
+		objects = next
+	}
+
+	out := make([]any, len(objects))
+	for i, o := range objects {
+		out[i] = o
+	}
+
+	return out, nil
+}
+
+type conditional struct {
+	cond, then, otherwise expr
+}
+
+func (c conditional) eval(in any) ([]any, error) {
+	conds, err := c.cond.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []any
+	for _, cond := range conds {
+		branch := c.otherwise
+		if truthy(cond) {
+			branch = c.then
+		}
+		values, err := branch.eval(in)
+		out = append(out, values...)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	return out, nil
+}
+
+type call struct {
+	fn   builtin
+	args []expr
+}
+
+func (c call) eval(in any) ([]any, error) {
+	return c.fn(in, c.args)
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if compare(item, v) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func split(s, sep string) []any {
+	if s == "" {
+		return []any{}
+	}
+
+	parts := strings.Split(s, sep)
+	out := make([]any, len(parts))
+	for i, p := range parts {
+		out[i] = p
+	}
+
+	return out
+}
+
+// tostring returns strings as is and other values as JSON.
+func tostring(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return string(Marshal(v))
+}
+
+func length(v any) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return intNumber(0), nil
+	case json.Number:
+		return json.Number(strings.TrimPrefix(v.String(), "-")), nil
+	case string:
+		return intNumber(utf8.RuneCountInString(v)), nil
+	case []any:
+		return intNumber(len(v)), nil
+	case *object:
+		return intNumber(len(v.keys)), nil
+	}
+
+	return nil, fmt.Errorf("%s has no length", describe(v))
+}
+
+func tonumber(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if jsonNumber.MatchString(s) {
+			return json.Number(s), nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN_") {
+			return floatNumber(f), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot parse %s as a number", describe(v))
+}
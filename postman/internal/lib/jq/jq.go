@@ -0,0 +1,335 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Query is a compiled filter in a subset of the jq language: paths
+// (., .a.b, .["a"], .[0], .[1:3], .[], ..), pipes, commas, literals,
+// array and object construction, arithmetic, comparisons, and, or, //,
+// if-then-else, ? and the builtins listed in builtins.go.
+type Query struct {
+	expr string
+	root expr
+}
+
+// Compile parses a filter.
+func Compile(filter string) (*Query, error) {
+	tokens, err := lex(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	p := parser{tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	return &Query{expr: filter, root: root}, nil
+}
+
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Run applies the filter to a document from Decode. On an error it
+// returns the results produced before it.
+func (q *Query) Run(doc any) ([]any, error) {
+	return q.root.eval(doc)
+}
+
+// Decode parses a JSON document. Objects keep the order of their keys
+// and numbers are kept as json.Number, so big integers survive unchanged.
+func Decode(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	doc, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return doc, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+
+	case json.Delim('{'):
+		obj := newObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	}
+
+	return t, nil
+}
+
+// Marshal returns a value as compact JSON.
+func Marshal(v any) []byte {
+	var b bytes.Buffer
+	encode(&b, v)
+
+	return b.Bytes()
+}
+
+func encode(b *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		b.WriteString(v.String())
+	case string:
+		encodeString(b, v)
+	case []any:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			encode(b, item)
+		}
+		b.WriteByte(']')
+	case *object:
+		b.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			encodeString(b, key)
+			b.WriteByte(':')
+			encode(b, v.values[key])
+		}
+		b.WriteByte('}')
+	}
+}
+
+func encodeString(b *bytes.Buffer, s string) {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode ends the value with a newline
+	b.Truncate(b.Len() - 1)
+}
+
+// object is a JSON object that keeps the order of its keys.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: map[string]any{}}
+}
+
+func (o *object) get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set adds a key at the end or replaces the value of an existing one in
+// place. Objects are shared between values, so only new ones are set.
+func (o *object) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *object) clone() *object {
+	c := &object{keys: append([]string(nil), o.keys...), values: make(map[string]any, len(o.values))}
+	for k, v := range o.values {
+		c.values[k] = v
+	}
+
+	return c
+}
+
+func (o *object) sortedKeys() []string {
+	keys := append([]string(nil), o.keys...)
+	sort.Strings(keys)
+
+	return keys
+}
+
+// typeOf returns the jq type name of a value.
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// describe names a value in error messages, e.g. `string ("abc")`.
+func describe(v any) string {
+	text := string(Marshal(v))
+	if len(text) > 20 {
+		text = text[:17] + "..."
+	}
+
+	return fmt.Sprintf("%s (%s)", typeOf(v), text)
+}
+
+func truthy(v any) bool {
+	return v != nil && v != false
+}
+
+// rank orders the types as jq does: null, false, true, numbers, strings,
+// arrays, objects.
+func rank(v any) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 2
+		}
+		return 1
+	case json.Number:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// compare orders any two values, numbers by value.
+func compare(a, b any) int {
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch a := a.(type) {
+	case json.Number:
+		return bigFloat(a).Cmp(bigFloat(b.(json.Number)))
+
+	case string:
+		return strings.Compare(a, b.(string))
+
+	case []any:
+		b := b.([]any)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+
+	case *object:
+		b := b.(*object)
+		ak, bk := a.sortedKeys(), b.sortedKeys()
+		if c := compare(stringsToValues(ak), stringsToValues(bk)); c != 0 {
+			return c
+		}
+		for _, k := range ak {
+			if c := compare(a.values[k], b.values[k]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+func stringsToValues(s []string) []any {
+	values := make([]any, len(s))
+	for i, v := range s {
+		values[i] = v
+	}
+
+	return values
+}
+
+func bigFloat(n json.Number) *big.Float {
+	f, _, err := big.ParseFloat(n.String(), 10, 256, big.ToNearestEven)
+	if err != nil {
+		return new(big.Float)
+	}
+
+	return f
+}
+
+func bigInt(n json.Number) (*big.Int, bool) {
+	return new(big.Int).SetString(n.String(), 10)
+}
+
+func intNumber(i int) json.Number {
+	return json.Number(strconv.Itoa(i))
+}
+
+func floatNumber(f float64) json.Number {
+	if f == float64(int64(f)) && f > -1e15 && f < 1e15 {
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// toInt returns the integer part of a number that fits an int.
+func toInt(n json.Number) (int, bool) {
+	if i, err := strconv.Atoi(n.String()); err == nil {
+		return i, true
+	}
+
+	f, err := n.Float64()
+	if err != nil || f > 1<<31 || f < -(1<<31) {
+		return 0, false
+	}
+
+	return int(f), true
+}
@@ -0,0 +1,218 @@
+package jq_test
+
+import (
+	"errors"
+	"postman/internal/lib/jq"
+	"strings"
+	"testing"
+)
+
+const doc = `{
+	"id": 12345678901234567890,
+	"name": "alice",
+	"active": true,
+	"tags": ["a", "b", "c"],
+	"address": {"city": "Berlin", "zip": null},
+	"users": [
+		{"name": "bob", "age": 31, "role": "admin"},
+		{"name": "carol", "age": 25, "role": "user"},
+		{"name": "dave", "age": 40, "role": "user"}
+	]
+}`
+
+// run applies a filter to the test document and returns the results as
+// compact JSON, one per line.
+func run(t *testing.T, filter string) (string, error) {
+	t.Helper()
+
+	query, err := jq.Compile(filter)
+	if err != nil {
+		t.Fatalf("Compile(%q) error = %v", filter, err)
+	}
+	input, err := jq.Decode([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := query.Run(input)
+	lines := make([]string, len(results))
+	for i, result := range results {
+		lines[i] = string(jq.Marshal(result))
+	}
+
+	return strings.Join(lines, "\n"), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name, filter, want string
+	}{
+		// paths
+		{"identity of a big integer", ".id", `12345678901234567890`},
+		{"field", ".name", `"alice"`},
+		{"nested field", ".address.city", `"Berlin"`},
+		{"missing field", ".missing", `null`},
+		{"field of null", ".missing.field", `null`},
+		{"bracket key", `.["name"]`, `"alice"`},
+		{"index", ".tags[0]", `"a"`},
+		{"negative index", ".tags[-1]", `"c"`},
+		{"index out of range", ".tags[10]", `null`},
+		{"slice", ".tags[1:3]", `["b","c"]`},
+		{"open slice", ".tags[:1]", `["a"]`},
+		{"iterate", ".tags[]", "\"a\"\n\"b\"\n\"c\""},
+		{"iterate object", ".address[]", "\"Berlin\"\nnull"},
+		{"optional", ".name[]?", ``},
+		{"recurse", `[.. | .city? // empty]`, `["Berlin"]`},
+
+		// pipes, commas and construction
+		{"pipe", ".users[] | .name", "\"bob\"\n\"carol\"\n\"dave\""},
+		{"comma", ".name, .active", "\"alice\"\ntrue"},
+		{"array", "[.users[].age]", `[31,25,40]`},
+		{"object", "{name, city: .address.city}", `{"name":"alice","city":"Berlin"}`},
+		{"computed key", `{(.name): 1}`, `{"alice":1}`},
+		{"keys keep their order", ".address | keys_unsorted", `["city","zip"]`},
+
+		// select, map and builtins
+		{"select", `.users[] | select(.age > 30) | .name`, "\"bob\"\n\"dave\""},
+		{"select equality", `[.users[] | select(.role == "user") | .name]`, `["carol","dave"]`},
+		{"map", "[.users | map(.age * 2)[]]", `[62,50,80]`},
+		{"map_values", `.address | map_values(. // "none")`, `{"city":"Berlin","zip":"none"}`},
+		{"length", ".tags | length", `3`},
+		{"sort_by", "[.users | sort_by(.age)[] | .name]", `["carol","bob","dave"]`},
+		{"group_by", "[.users | group_by(.role)[] | length]", `[1,2]`},
+		{"min_by", ".users | min_by(.age) | .name", `"carol"`},
+		{"add", "[.users[].age] | add", `96`},
+		{"any", "[.users[].age] | any(. > 35)", `true`},
+		{"all", "[.users[].age] | all(. > 35)", `false`},
+		{"has", `.address | has("zip")`, `true`},
+		{"contains", `.tags | contains(["b"])`, `true`},
+		{"join", `.tags | join("-")`, `"a-b-c"`},
+		{"test", `.name | test("^AL"; "i")`, `true`},
+		{"to_entries", "[.address | to_entries[] | .key]", `["city","zip"]`},
+		{"with_entries", `.address | with_entries(select(.value != null))`, `{"city":"Berlin"}`},
+		{"first and last", "[first(.tags[]), last(.tags[])]", `["a","c"]`},
+		{"limit", "[limit(2; .tags[])]", `["a","b"]`},
+		{"range", "[range(0; 3)]", `[0,1,2]`},
+		{"type", "[.name, .active, .address.zip, .tags] | map(type)", `["string","boolean","null","array"]`},
+		{"tostring", `.users[0].age | tostring`, `"31"`},
+
+		// comparisons, arithmetic and logic
+		{"equal", `.name == "alice"`, `true`},
+		{"not equal", `.name != "alice"`, `false`},
+		{"less", ".users[1].age < .users[0].age", `true`},
+		{"less or equal", ".users[0].age <= 31", `true`},
+		{"greater or equal", ".users[0].age >= 32", `false`},
+		{"numbers by value", "1.0 == 1", `true`},
+		{"type order", "[null, false, 0, \"\", [], {}] | . == sort", `true`},
+		{"arithmetic", "(.users[0].age + 9) / 4 - 1", `9`},
+		{"modulo", ".users[0].age % 10", `1`},
+		{"string concatenation", `.name + "@example.com"`, `"alice@example.com"`},
+		{"array subtraction", `.tags - ["b"]`, `["a","c"]`},
+		{"object merge", `{a: 1} + {b: 2}`, `{"a":1,"b":2}`},
+		{"and or", ".active and (.missing or true)", `true`},
+		{"not", ".active | not", `false`},
+		{"alternative", `.missing // "default"`, `"default"`},
+		{"if", `if .active then "on" elif .missing then "?" else "off" end`, `"on"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.filter)
+			if err != nil {
+				t.Fatalf("Run(%q) error = %v", tt.filter, err)
+			}
+			if got != tt.want {
+				t.Errorf("Run(%q) = %s, want %s", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name, filter string
+	}{
+		{"empty brackets", ".["},
+		{"unclosed parenthesis", "(.a"},
+		{"unclosed array", "[.a"},
+		{"dangling pipe", ".a |"},
+		{"unterminated string", `"abc`},
+		{"string interpolation", `"\(.a)"`},
+		{"unknown function", "frobnicate"},
+		{"wrong number of arguments", "select(.a; .b)"},
+		{"missing then", "if .a else 1 end"},
+		{"unexpected character", ".a ; .b"},
+		{"trailing tokens", ".a .b)"},
+		{"object without key", "{: 1}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jq.Compile(tt.filter)
+			if !errors.Is(err, jq.ErrInvalidFilter) {
+				t.Errorf("Compile(%q) error = %v, want ErrInvalidFilter", tt.filter, err)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name, filter string
+		// want are the results produced before the error.
+		want string
+	}{
+		{"field of a string", ".name.first", ``},
+		{"index of an object", ".address[0]", ``},
+		{"iterate a number", ".users[0].age[]", ``},
+		{"add string and number", `.name + 1`, ``},
+		{"divide by zero", ".users[0].age / 0", ``},
+		{"keys of a string", ".name | keys", ``},
+		{"error after results", ".tags[], .name.first", "\"a\"\n\"b\"\n\"c\""},
+		{"invalid regex", `.name | test("(")`, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.filter)
+			if err == nil {
+				t.Fatalf("Run(%q) = %s, want an error", tt.filter, got)
+			}
+			if got != tt.want {
+				t.Errorf("Run(%q) results before the error = %s, want %s", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name, input string
+		wantErr     bool
+	}{
+		{name: "object", input: `{"b": 1, "a": [true, null]}`},
+		{name: "scalar", input: `"text"`},
+		{name: "invalid", input: `{"a":`, wantErr: true},
+		{name: "trailing data", input: `{} {}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := jq.Decode([]byte(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Decode(%q) = %s, want an error", tt.input, jq.Marshal(v))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode(%q) error = %v", tt.input, err)
+			}
+			// keys keep their order and the document survives a round trip
+			compact := strings.NewReplacer(" ", "").Replace(tt.input)
+			if got := string(jq.Marshal(v)); got != compact {
+				t.Errorf("Marshal(Decode(%q)) = %s, want %s", tt.input, got, compact)
+			}
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package jq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokIdent
+	// tokField is ".name", its text the name.
+	tokField
+	tokNumber
+	// tokString is a string literal, its text decoded.
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokField:
+		return "." + t.text
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+
+	return t.text
+}
+
+// punctuation lists the operators, longest first.
+var punctuation = []string{
+	"..", "//", "==", "!=", "<=", ">=",
+	".", "[", "]", "{", "}", "(", ")", "|", ",", ":", ";", "?", "<", ">", "+", "-", "*", "/", "%",
+}
+
+var (
+	numberLiteral = regexp.MustCompile(`^[0-9]+(?:\.[0-9]*)?(?:[eE][+-]?[0-9]+)?`)
+	// jsonNumber is a number as JSON writes it.
+	jsonNumber = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+)
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					if end+1 < len(s) && s[end+1] == '(' {
+						return nil, errors.New("string interpolation is not supported")
+					}
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, errors.New("unterminated string")
+			}
+			var text string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &text); err != nil {
+				return nil, fmt.Errorf("string %s: %w", s[i:end+1], err)
+			}
+			tokens = append(tokens, token{kind: tokString, text: text})
+			i = end + 1
+
+		case c >= '0' && c <= '9':
+			text := numberLiteral.FindString(s[i:])
+			tokens = append(tokens, token{kind: tokNumber, text: text})
+			i += len(text)
+
+		case c == '.' && i+1 < len(s) && isIdentStart(s[i+1]):
+			n := identLength(s[i+1:])
+			tokens = append(tokens, token{kind: tokField, text: s[i+1 : i+1+n]})
+			i += 1 + n
+
+		case isIdentStart(c):
+			n := identLength(s[i:])
+			tokens = append(tokens, token{kind: tokIdent, text: s[i : i+n]})
+			i += n
+
+		default:
+			found := false
+			for _, p := range punctuation {
+				if strings.HasPrefix(s[i:], p) {
+					tokens = append(tokens, token{kind: tokPunct, text: p})
+					i += len(p)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected %q", c)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func identLength(s string) int {
+	n := 0
+	for n < len(s) && (isIdentStart(s[n]) || s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+
+	return n
+}
+
+// parser is a recursive descent parser, from the loosest operator to the
+// tightest: |, ",", //, or, and, comparisons, + -, * / %, then paths.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) parse() (expr, error) {
+	e, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s", next)
+	}
+
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is the given operator or keyword.
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("%s expected, got %s", text, p.peek())
+	}
+
+	return nil
+}
+
+func (p *parser) pipe() (expr, error) {
+	left, err := p.comma()
+	if err != nil || !p.accept("|") {
+		return left, err
+	}
+
+	right, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return pipe{left, right}, nil
+}
+
+func (p *parser) comma() (expr, error) {
+	left, err := p.alternative()
+	for err == nil && p.accept(",") {
+		var right expr
+		right, err = p.alternative()
+		left = comma{left, right}
+	}
+
+	return left, err
+}
+
+func (p *parser) alternative() (expr, error) {
+	left, err := p.or()
+	if err != nil || !p.accept("//") {
+		return left, err
+	}
+
+	right, err := p.alternative()
+	if err != nil {
+		return nil, err
+	}
+
+	return alternative{left, right}, nil
+}
+
+func (p *parser) or() (expr, error) {
+	left, err := p.and()
+	for err == nil && p.accept("or") {
+		var right expr
+		right, err = p.and()
+		left = logical{or: true, left: left, right: right}
+	}
+
+	return left, err
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.comparison()
+	for err == nil && p.accept("and") {
+		var right expr
+		right, err = p.comparison()
+		left = logical{left: left, right: right}
+	}
+
+	return left, err
+}
+
+func (p *parser) comparison() (expr, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return binary{op: op, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) additive() (expr, error) {
+	left, err := p.multiplicative()
+	for err == nil {
+		op := p.peek().text
+		if p.peek().kind != tokPunct || op != "+" && op != "-" {
+			break
+		}
+		p.next()
+		var right expr
+		right, err = p.multiplicative()
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, err
+}
+
+func (p *parser) multiplicative() (expr, error) {
+	left, err := p.postfix()
+	for err == nil {
+		op := p.peek().text
+		if p.peek().kind != tokPunct || op != "*" && op != "/" && op != "%" {
+			break
+		}
+		p.next()
+		var right expr
+		right, err = p.postfix()
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, err
+}
+
+// postfix parses a term followed by .name, ."name", [...] and ?.
+func (p *parser) postfix() (expr, error) {
+	e, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokField:
+			p.next()
+			e = index{target: e, key: literal{t.text}}
+
+		case t.kind == tokPunct && t.text == "." && p.tokens[p.pos+1].kind == tokString:
+			p.next()
+			e = index{target: e, key: literal{p.next().text}}
+
+		case t.kind == tokPunct && t.text == "." && p.tokens[p.pos+1].text == "[":
+			p.next()
+
+		case t.kind == tokPunct && t.text == "[":
+			p.next()
+			if e, err = p.bracket(e); err != nil {
+				return nil, err
+			}
+
+		case t.kind == tokPunct && t.text == "?":
+			p.next()
+			e = try{e}
+
+		default:
+			return e, nil
+		}
+	}
+}
+
+// bracket parses what follows the [ of .[], .[i] and .[i:j].
+func (p *parser) bracket(target expr) (expr, error) {
+	if p.accept("]") {
+		return iterate{target}, nil
+	}
+
+	var from, to expr
+	var err error
+	if p.peek().text != ":" {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept(":") {
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return index{target: target, key: from}, nil
+	}
+
+	if p.peek().text != "]" {
+		if to, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+
+	return slice{target: target, from: from, to: to}, nil
+}
+
+func (p *parser) term() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokField:
+		return index{target: identity{}, key: literal{t.text}}, nil
+
+	case tokNumber:
+		if !jsonNumber.MatchString(t.text) {
+			f, err := strconv.ParseFloat(t.text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("number %s: %w", t.text, err)
+			}
+			return literal{floatNumber(f)}, nil
+		}
+		return literal{json.Number(t.text)}, nil
+
+	case tokString:
+		return literal{t.text}, nil
+
+	case tokIdent:
+		return p.ident(t.text)
+
+	case tokPunct:
+		switch t.text {
+		case ".":
+			if p.peek().kind == tokString {
+				return index{target: identity{}, key: literal{p.next().text}}, nil
+			}
+			return identity{}, nil
+
+		case "..":
+			return recurse{}, nil
+
+		case "(":
+			e, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+
+		case "[":
+			if p.accept("]") {
+				return collect{}, nil
+			}
+			e, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return collect{e}, p.expect("]")
+
+		case "{":
+			return p.object()
+
+		case "-":
+			e, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			return negate{e}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+func (p *parser) ident(name string) (expr, error) {
+	switch name {
+	case "true":
+		return literal{true}, nil
+	case "false":
+		return literal{false}, nil
+	case "null":
+		return literal{nil}, nil
+	case "if":
+		return p.conditional()
+	case "try":
+		e, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return try{e}, nil
+	case "then", "elif", "else", "end", "and", "or":
+		return nil, fmt.Errorf("unexpected %s", name)
+	case "def", "reduce", "foreach", "as", "label", "import", "include":
+		return nil, fmt.Errorf("%s is not supported", name)
+	}
+
+	var args []expr
+	if p.accept("(") {
+		for {
+			arg, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.accept(";") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	fn, ok := builtins[fmt.Sprintf("%s/%d", name, len(args))]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s/%d", name, len(args))
+	}
+
+	return call{fn: fn, args: args}, nil
+}
+
+// conditional parses what follows "if", the else branch being optional.
+func (p *parser) conditional() (expr, error) {
+	cond, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	c := conditional{cond: cond, then: then, otherwise: identity{}}
+	switch {
+	case p.accept("elif"):
+		if c.otherwise, err = p.conditional(); err != nil {
+			return nil, err
+		}
+		return c, nil
+
+	case p.accept("else"):
+		if c.otherwise, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, p.expect("end")
+}
+
+// object parses {a, "b": e, (e): e, c: e}, values without | or commas
+// unless in parentheses.
+func (p *parser) object() (expr, error) {
+	var o construct
+	for !p.accept("}") {
+		if len(o.entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		var key expr
+		switch t := p.next(); {
+		case t.kind == tokIdent, t.kind == tokString:
+			key = literal{t.text}
+		case t.kind == tokPunct && t.text == "(":
+			e, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			key = e
+		default:
+			return nil, fmt.Errorf("object key expected, got %s", t)
+		}
+
+		var value expr
+		if p.accept(":") {
+			v, err := p.alternative()
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		} else if k, ok := key.(literal); ok {
+			value = index{target: identity{}, key: k}
+		} else {
+			return nil, errors.New(": expected after a computed object key")
+		}
+
+		o.entries = append(o.entries, entry{key: key, value: value})
+	}
+
+	return o, nil
+}
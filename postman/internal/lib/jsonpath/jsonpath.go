@@ -0,0 +1,341 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid JSONPath")
+
+// Path is a compiled JSONPath expression. The supported subset is
+// $, .key, ['key'], [n] (negative counts from the end), [*], .* and
+// ..key for recursive descent. The leading $ may be omitted.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepWildcard
+	stepDescend
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// Compile parses a JSONPath expression.
+func Compile(expr string) (Path, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	p := Path{expr: expr}
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			i += 2
+			key, n := readKey(s[i:])
+			if key == "" {
+				return Path{}, fmt.Errorf("%w: %q: key expected after ..", ErrInvalidPath, expr)
+			}
+			p.steps = append(p.steps, step{kind: stepDescend, key: key})
+			i += n
+
+		case s[i] == '.':
+			i++
+			if i < len(s) && s[i] == '*' {
+				p.steps = append(p.steps, step{kind: stepWildcard})
+				i++
+				continue
+			}
+			key, n := readKey(s[i:])
+			if key == "" {
+				return Path{}, fmt.Errorf("%w: %q: key expected after .", ErrInvalidPath, expr)
+			}
+			p.steps = append(p.steps, step{kind: stepKey, key: key})
+			i += n
+
+		case s[i] == '[':
+			end := closingBracket(s, i)
+			if end < 0 {
+				return Path{}, fmt.Errorf("%w: %q: unterminated [", ErrInvalidPath, expr)
+			}
+			st, err := bracket(strings.TrimSpace(s[i+1 : end]))
+			if err != nil {
+				return Path{}, fmt.Errorf("%w: %q: %w", ErrInvalidPath, expr, err)
+			}
+			p.steps = append(p.steps, st)
+			i = end + 1
+
+		case i == 0:
+			// a bare first key, e.g. "data.id"
+			key, n := readKey(s)
+			if key == "" {
+				return Path{}, fmt.Errorf("%w: %q", ErrInvalidPath, expr)
+			}
+			p.steps = append(p.steps, step{kind: stepKey, key: key})
+			i += n
+
+		default:
+			return Path{}, fmt.Errorf("%w: %q: unexpected %q", ErrInvalidPath, expr, s[i])
+		}
+	}
+
+	return p, nil
+}
+
+// MustCompile is Compile for expressions known to be valid.
+func MustCompile(expr string) Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+func (p Path) String() string {
+	return p.expr
+}
+
+// Get returns every value the path selects in doc.
+func (p Path) Get(doc any) []any {
+	current := []any{doc}
+	for _, st := range p.steps {
+		var next []any
+		for _, v := range current {
+			next = append(next, st.apply(v)...)
+		}
+		current = next
+	}
+
+	return current
+}
+
+// Query compiles expr and applies it to doc.
+func Query(doc any, expr string) ([]any, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Get(doc), nil
+}
+
+// Decode parses a JSON document keeping numbers as json.Number, so big
+// integers survive unchanged.
+func Decode(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// TypeOf returns the JSON type name of a decoded value.
+func TypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// Format returns strings as is and everything else as compact JSON.
+func Format(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+// Equal compares decoded values, numbers by value so 1 equals 1.0.
+func Equal(a, b any) bool {
+	an, aok := number(a)
+	bn, bok := number(b)
+	if aok && bok {
+		return an.Cmp(bn) == 0
+	}
+
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !Equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok || !Equal(v, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func number(v any) (*big.Float, bool) {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case float64:
+		s = strconv.FormatFloat(n, 'g', -1, 64)
+	default:
+		return nil, false
+	}
+
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	return f, err == nil
+}
+
+func (st step) apply(v any) []any {
+	switch st.kind {
+	case stepKey:
+		if obj, ok := v.(map[string]any); ok {
+			if child, ok := obj[st.key]; ok {
+				return []any{child}
+			}
+		}
+
+	case stepIndex:
+		if arr, ok := v.([]any); ok {
+			i := st.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				return []any{arr[i]}
+			}
+		}
+
+	case stepWildcard:
+		return children(v)
+
+	case stepDescend:
+		var result []any
+		if obj, ok := v.(map[string]any); ok {
+			if child, ok := obj[st.key]; ok {
+				result = append(result, child)
+			}
+		}
+		for _, child := range children(v) {
+			result = append(result, st.apply(child)...)
+		}
+		return result
+	}
+
+	return nil
+}
+
+// children returns array items in order and object values sorted by key.
+func children(v any) []any {
+	switch c := v.(type) {
+	case []any:
+		return c
+	case map[string]any:
+		keys := make([]string, 0, len(c))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		result := make([]any, 0, len(keys))
+		for _, k := range keys {
+			result = append(result, c[k])
+		}
+		return result
+	}
+
+	return nil
+}
+
+func bracket(inner string) (step, error) {
+	switch {
+	case inner == "*":
+		return step{kind: stepWildcard}, nil
+
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return step{kind: stepKey, key: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, fmt.Errorf("unsupported selector [%s]", inner)
+	}
+
+	return step{kind: stepIndex, index: index}, nil
+}
+
+func readKey(s string) (string, int) {
+	n := 0
+	for n < len(s) && s[n] != '.' && s[n] != '[' {
+		n++
+	}
+
+	return s[:n], n
+}
+
+func closingBracket(s string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '\'' || s[i] == '"':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,170 @@
+package pathparams
+
+import (
+	"net/url"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+// Names returns the path parameters of a URL in order, without
+// duplicates. A parameter is a whole path segment ":name", as Postman
+// writes them, or "{name}" anywhere in the path, as OpenAPI does.
+// {{var}} placeholders are not path parameters.
+func Names(rawURL string) []string {
+	var names []string
+	seen := map[string]bool{}
+	walk(pathOf(rawURL), func(name string) (string, bool) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return "", false
+	})
+
+	return names
+}
+
+// Apply replaces the path parameters with escaped values. Parameters
+// without a value are left as is and returned in missing.
+func Apply(rawURL string, values map[string]string) (result string, missing []string) {
+	start, end := pathBounds(rawURL)
+	if start == end {
+		return rawURL, nil
+	}
+
+	seen := map[string]bool{}
+	path := walk(rawURL[start:end], func(name string) (string, bool) {
+		if value, ok := values[name]; ok {
+			return url.PathEscape(value), true
+		}
+		if !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		return "", false
+	})
+
+	return rawURL[:start] + path + rawURL[end:], missing
+}
+
+// Set sets the value of a path parameter, replacing an earlier one.
+func Set(params []models.PathParam, key, value string) []models.PathParam {
+	for i := range params {
+		if params[i].Key == key {
+			params[i].Value = value
+			return params
+		}
+	}
+
+	return append(params, models.PathParam{Key: key, Value: value})
+}
+
+// Describe returns the value set for a path parameter, or the {{name}}
+// variable it takes the value from.
+func Describe(params []models.PathParam, name string) string {
+	for _, p := range params {
+		if p.Key == name && p.Value != "" {
+			return p.Value
+		}
+	}
+
+	return "{{" + name + "}}"
+}
+
+// walk calls replace for every parameter of path and substitutes the
+// returned value when replace reports one.
+func walk(path string, replace func(name string) (string, bool)) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && validName(name) {
+			if value, ok := replace(name); ok {
+				segments[i] = value
+			}
+			continue
+		}
+		segments[i] = braces(segment, replace)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// braces replaces {name} in a segment and skips {{var}} placeholders.
+func braces(segment string, replace func(name string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(segment, '{')
+		if i < 0 {
+			b.WriteString(segment)
+			return b.String()
+		}
+
+		if strings.HasPrefix(segment[i:], "{{") {
+			end := strings.Index(segment[i:], "}}")
+			if end < 0 {
+				b.WriteString(segment)
+				return b.String()
+			}
+			b.WriteString(segment[:i+end+2])
+			segment = segment[i+end+2:]
+			continue
+		}
+
+		end := strings.IndexByte(segment[i:], '}')
+		if end < 0 || !validName(segment[i+1:i+end]) {
+			b.WriteString(segment[:i+1])
+			segment = segment[i+1:]
+			continue
+		}
+
+		b.WriteString(segment[:i])
+		if value, ok := replace(segment[i+1 : i+end]); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(segment[i : i+end+1])
+		}
+		segment = segment[i+end+1:]
+	}
+}
+
+func pathOf(rawURL string) string {
+	start, end := pathBounds(rawURL)
+
+	return rawURL[start:end]
+}
+
+// pathBounds finds the path of a URL that may still hold placeholders
+// and so can't be parsed by net/url.
+func pathBounds(rawURL string) (int, int) {
+	start := 0
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		start = i + 3
+	}
+	slash := strings.IndexByte(rawURL[start:], '/')
+	if slash < 0 {
+		return len(rawURL), len(rawURL)
+	}
+	start += slash
+
+	end := len(rawURL)
+	if i := strings.IndexAny(rawURL[start:], "?#"); i >= 0 {
+		end = start + i
+	}
+
+	return start, end
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
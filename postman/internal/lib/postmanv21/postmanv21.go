@@ -0,0 +1,514 @@
+package postmanv21
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"strings"
+)
+
+var ErrUnsupportedSchema = errors.New("not a Postman Collection v2.1 file")
+
+// Import reads a Postman Collection v2.1 file and converts it into a
+// collection. Every construct that could not be translated is reported
+// in the returned warnings, prefixed with the path of the item.
+func Import(r io.Reader) (models.Collection, []string, error) {
+	var src collection
+	if err := json.NewDecoder(r).Decode(&src); err != nil {
+		return models.Collection{}, nil, fmt.Errorf("%w: %w", ErrUnsupportedSchema, err)
+	}
+
+	if !strings.Contains(src.Info.Schema, "/collection/v2.1") && !strings.Contains(src.Info.Schema, "/collection/v2.0") {
+		return models.Collection{}, nil, fmt.Errorf("%w: schema %q", ErrUnsupportedSchema, src.Info.Schema)
+	}
+
+	c := &converter{}
+	if !strings.Contains(src.Info.Schema, "/collection/v2.1") {
+		c.warn(src.Info.Name, "schema %q is imported as v2.1", src.Info.Schema)
+	}
+
+	result := models.Collection{
+		Version:     models.CollectionVersion,
+		Name:        pathName(src.Info.Name, "collection"),
+		Description: description(src.Info.Description),
+		Variables:   convertVariables(src.Variable),
+		Auth:        c.auth(src.Info.Name, src.Auth),
+	}
+	c.events(src.Info.Name, src.Event)
+
+	root := models.Folder{}
+	c.items(&root, src.Item, src.Info.Name)
+	result.Folders = root.Folders
+	result.Requests = root.Requests
+
+	return result, c.warnings, nil
+}
+
+type converter struct {
+	warnings []string
+}
+
+func (c *converter) warn(path, format string, args ...any) {
+	c.warnings = append(c.warnings, path+": "+fmt.Sprintf(format, args...))
+}
+
+// items converts the items of a folder. Their names become elements of
+// request paths, so they are cleaned up like the collection name and
+// made unique among the folders and among the requests of the folder.
+func (c *converter) items(parent *models.Folder, items []item, path string) {
+	folders, requests := map[string]bool{}, map[string]bool{}
+	for _, it := range items {
+		itemPath := path + "/" + it.Name
+		c.events(itemPath, it.Event)
+
+		if it.Item != nil || len(it.Request) == 0 {
+			name := c.uniqueName(itemPath, it.Name, "folder", folders)
+			if len(it.Variable) > 0 {
+				c.warn(itemPath, "folder variables are not supported, %d skipped", len(it.Variable))
+			}
+
+			folder := models.Folder{
+				Name:        name,
+				Description: description(it.Description),
+				Auth:        c.auth(itemPath, it.Auth),
+			}
+			c.items(&folder, it.Item, itemPath)
+			parent.Folders = append(parent.Folders, folder)
+			continue
+		}
+
+		saved, ok := c.request(itemPath, it)
+		if ok {
+			saved.Name = c.uniqueName(itemPath, it.Name, "request", requests)
+			parent.Requests = append(parent.Requests, saved)
+		}
+	}
+}
+
+// uniqueName returns the path name of an item, numbered when a sibling
+// already has it, and reports a renamed item.
+func (c *converter) uniqueName(path, name, fallback string, used map[string]bool) string {
+	clean := pathName(name, fallback)
+	unique := clean
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)", clean, i)
+	}
+	used[unique] = true
+
+	if unique != name {
+		c.warn(path, "imported as %q", unique)
+	}
+
+	return unique
+}
+
+func (c *converter) request(path string, it item) (models.SavedRequest, bool) {
+	var src request
+
+	// a request may be just a URL string
+	var rawURL string
+	if err := json.Unmarshal(it.Request, &rawURL); err == nil {
+		src = request{Method: "GET", URL: it.Request}
+	} else if err := json.Unmarshal(it.Request, &src); err != nil {
+		c.warn(path, "request skipped: %s", err)
+		return models.SavedRequest{}, false
+	}
+
+	if src.Method == "" {
+		src.Method = "GET"
+	}
+
+	saved := models.SavedRequest{
+		Description: description(it.Description),
+		Request: models.Request{
+			Method: client.NormalizeMethod(src.Method),
+		},
+	}
+	saved.URL, saved.PathParams, saved.Query = c.url(path, src.URL)
+	if saved.Description == "" {
+		saved.Description = description(src.Description)
+	}
+
+	saved.Headers = c.headers(path, src.Header)
+
+	saved.Auth = c.auth(path, src.Auth)
+
+	c.body(path, src.Body, &saved.Request)
+
+	return saved, true
+}
+
+// url returns the URL, the values of its path variables (:id) and its
+// disabled query parameters, kept disabled. A path variable without a
+// value takes the variable of the same name.
+func (c *converter) url(path string, raw json.RawMessage) (string, []models.PathParam, []models.QueryParam) {
+	if len(raw) == 0 {
+		c.warn(path, "request has no URL")
+		return "", nil, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil, nil
+	}
+
+	var u url
+	if err := json.Unmarshal(raw, &u); err != nil {
+		c.warn(path, "unreadable URL: %s", err)
+		return "", nil, nil
+	}
+
+	result := u.Raw
+	if result == "" {
+		result = buildURL(u)
+	}
+
+	var params []models.PathParam
+	for _, v := range u.Variable {
+		if v.Key != "" {
+			params = append(params, models.PathParam{Key: v.Key, Value: v.Value})
+		}
+	}
+
+	var query []models.QueryParam
+	for _, q := range u.Query {
+		if !q.Disabled {
+			continue
+		}
+		if strings.Contains(result, q.Key+"=") {
+			c.warn(path, "disabled query parameter %q is part of the raw URL", q.Key)
+		}
+		query = append(query, models.QueryParam{Key: q.Key, Value: q.Value, Disabled: true})
+	}
+
+	return result, params, query
+}
+
+func buildURL(u url) string {
+	var b strings.Builder
+	if u.Protocol != "" {
+		b.WriteString(u.Protocol + "://")
+	}
+	b.WriteString(strings.Join(stringOrList(u.Host), "."))
+	if u.Port != "" {
+		b.WriteString(":" + u.Port)
+	}
+	for _, p := range stringOrList(u.Path) {
+		b.WriteString("/" + p)
+	}
+
+	sep := "?"
+	for _, q := range u.Query {
+		if q.Disabled {
+			continue
+		}
+		b.WriteString(sep + q.Key + "=" + q.Value)
+		sep = "&"
+	}
+
+	return b.String()
+}
+
+func (c *converter) headers(path string, raw json.RawMessage) []models.Header {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var list []keyValue
+	if err := json.Unmarshal(raw, &list); err != nil {
+		// the schema also allows the raw header block as a single string
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			c.warn(path, "unreadable headers: %s", err)
+			return nil
+		}
+		for _, line := range strings.Split(s, "\n") {
+			key, value, found := strings.Cut(line, ":")
+			if found {
+				list = append(list, keyValue{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+			}
+		}
+	}
+
+	var hdrs []models.Header
+	for _, h := range list {
+		if h.Key == "" {
+			continue
+		}
+		if h.Disabled {
+			c.warn(path, "disabled header %q skipped", h.Key)
+			continue
+		}
+		hdrs = append(hdrs, models.Header{Key: h.Key, Value: h.Value})
+	}
+
+	return hdrs
+}
+
+// auth converts an auth block, nil and "inherit" inherit from the parent.
+func (c *converter) auth(path string, a *auth) *models.Auth {
+	if a == nil {
+		return nil
+	}
+
+	switch a.Type {
+	case "", "inherit":
+		return nil
+
+	case "noauth":
+		return &models.Auth{Type: models.AuthNone}
+
+	case "bearer":
+		token, _ := a.param("token")
+		return &models.Auth{Type: models.AuthBearer, Token: token}
+
+	case "basic", "digest":
+		username, _ := a.param("username")
+		password, _ := a.param("password")
+		return &models.Auth{Type: a.Type, Username: username, Password: password}
+
+	case "apikey":
+		key, _ := a.param("key")
+		value, _ := a.param("value")
+		in, _ := a.param("in")
+		if in != models.APIKeyInQuery {
+			in = models.APIKeyInHeader
+		}
+		return &models.Auth{Type: models.AuthAPIKey, Key: key, Value: value, In: in}
+
+	case "oauth2":
+		return c.oauth2(path, a)
+	}
+
+	// nil would inherit the auth of the parent, which the request did not
+	// use in Postman either
+	c.warn(path, "auth type %q is not supported, sent without auth", a.Type)
+	return &models.Auth{Type: models.AuthNone}
+}
+
+// oauth2 converts the OAuth 2.0 settings. The authorization code grant
+// always runs with PKCE and a loopback redirect, so the callback URL of
+// the export is not used.
+func (c *converter) oauth2(path string, a *auth) *models.Auth {
+	grant, _ := a.param("grant_type")
+	result := &models.Auth{Type: models.AuthOAuth2}
+	switch grant {
+	case "", "authorization_code", "authorization_code_with_pkce":
+		result.GrantType = models.GrantAuthorizationCode
+	case "client_credentials":
+		result.GrantType = models.GrantClientCredentials
+	case "password_credentials":
+		result.GrantType = models.GrantPassword
+	default:
+		c.warn(path, "oauth2 grant %q is not supported, sent without auth", grant)
+		return &models.Auth{Type: models.AuthNone}
+	}
+
+	result.TokenURL, _ = a.param("accessTokenUrl")
+	result.AuthURL, _ = a.param("authUrl")
+	result.ClientID, _ = a.param("clientId")
+	result.ClientSecret, _ = a.param("clientSecret")
+	result.Scope, _ = a.param("scope")
+	result.Username, _ = a.param("username")
+	result.Password, _ = a.param("password")
+
+	return result
+}
+
+func (c *converter) body(path string, b *body, request *models.Request) {
+	if b == nil || b.Disabled || b.Mode == "" {
+		return
+	}
+
+	switch b.Mode {
+	case "raw":
+		request.Body = b.Raw
+		if b.Raw != "" && !hasHeader(request.Headers, "Content-Type") {
+			language := "text"
+			if b.Options != nil && b.Options.Raw != nil && b.Options.Raw.Language != "" {
+				language = b.Options.Raw.Language
+			}
+			request.Headers = append(request.Headers, models.Header{Key: "Content-Type", Value: rawContentType(language)})
+		}
+
+	case "urlencoded":
+		request.BodyMode = models.BodyURLEncoded
+		for _, kv := range b.URLEncoded {
+			request.Form = append(request.Form, models.FormField{Key: kv.Key, Value: kv.Value, Disabled: kv.Disabled})
+		}
+
+	case "formdata":
+		request.BodyMode = models.BodyFormData
+		for _, kv := range b.FormData {
+			field := models.FormField{Key: kv.Key, ContentType: kv.ContentType, Disabled: kv.Disabled}
+			if kv.Type != "file" {
+				field.Value = kv.Value
+				request.Form = append(request.Form, field)
+				continue
+			}
+
+			files := stringOrList(kv.Src)
+			if len(files) == 0 {
+				c.warn(path, "form-data file field %q has no file, skipped", kv.Key)
+				continue
+			}
+			// a field with several files is sent as one part per file
+			for _, file := range files {
+				field.File = file
+				request.Form = append(request.Form, field)
+			}
+		}
+
+	case "file":
+		if b.File == nil || b.File.Src == "" {
+			c.warn(path, "binary body has no file, skipped")
+			return
+		}
+		request.BodyMode = models.BodyBinary
+		request.BodyFile = b.File.Src
+
+	case "graphql":
+		if b.GraphQL == nil {
+			return
+		}
+		payload := map[string]any{"query": b.GraphQL.Query}
+		if strings.TrimSpace(b.GraphQL.Variables) != "" {
+			payload["variables"] = json.RawMessage(b.GraphQL.Variables)
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			c.warn(path, "graphql body skipped: %s", err)
+			return
+		}
+		request.Body = string(encoded)
+		setHeader(&request.Headers, "Content-Type", "application/json")
+
+	default:
+		c.warn(path, "body mode %q is not supported", b.Mode)
+	}
+}
+
+func convertVariables(vars []variable) []models.Variable {
+	var result []models.Variable
+	for _, v := range vars {
+		key := v.Key
+		if key == "" {
+			key = v.ID
+		}
+		if key == "" || v.Disabled {
+			continue
+		}
+
+		var value string
+		switch val := v.Value.(type) {
+		case string:
+			value = val
+		case nil:
+		default:
+			b, _ := json.Marshal(val)
+			value = string(b)
+		}
+
+		result = append(result, models.Variable{Key: key, Value: value, Secret: v.Type == "secret"})
+	}
+
+	return result
+}
+
+func (c *converter) events(path string, events []event) {
+	for _, e := range events {
+		c.warn(path, "%s script is not imported", e.Listen)
+	}
+}
+
+func rawContentType(language string) string {
+	switch language {
+	case "json":
+		return "application/json"
+	case "xml":
+		return "application/xml"
+	case "html":
+		return "text/html"
+	case "javascript":
+		return "application/javascript"
+	default:
+		return "text/plain"
+	}
+}
+
+// pathName turns the name of a collection, folder or request into a
+// valid element of a request path: slashes, which separate the elements,
+// become dashes, e.g. "GET /api/v1/users" is imported as
+// "GET-api-v1-users". An empty name becomes fallback.
+func pathName(name, fallback string) string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	name = strings.Join(parts, "-")
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+
+	return name
+}
+
+// description accepts both the string and the {"content": ...} forms.
+func description(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var d struct {
+		Content string `json:"content"`
+	}
+	_ = json.Unmarshal(raw, &d)
+
+	return d.Content
+}
+
+func stringOrList(raw json.RawMessage) []string {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && s != "" {
+		return []string{s}
+	}
+
+	return nil
+}
+
+func hasHeader(hdrs []models.Header, key string) bool {
+	for _, h := range hdrs {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setHeader(hdrs *[]models.Header, key, value string) {
+	for i, h := range *hdrs {
+		if strings.EqualFold(h.Key, key) {
+			(*hdrs)[i].Value = value
+			return
+		}
+	}
+
+	*hdrs = append(*hdrs, models.Header{Key: key, Value: value})
+}
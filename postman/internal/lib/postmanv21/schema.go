@@ -0,0 +1,143 @@
+package postmanv21
+
+import "encoding/json"
+
+// The types below cover the parts of the Postman Collection v2.1 schema
+// (https://schema.postman.com/json/collection/v2.1.0/collection.json)
+// that the importer reads.
+
+type collection struct {
+	Info     info       `json:"info"`
+	Item     []item     `json:"item"`
+	Auth     *auth      `json:"auth"`
+	Variable []variable `json:"variable"`
+	Event    []event    `json:"event"`
+}
+
+type info struct {
+	Name        string          `json:"name"`
+	Schema      string          `json:"schema"`
+	Description json.RawMessage `json:"description"`
+}
+
+// item is either a folder (Item is set) or a request.
+type item struct {
+	Name        string          `json:"name"`
+	Description json.RawMessage `json:"description"`
+	Item        []item          `json:"item"`
+	Request     json.RawMessage `json:"request"`
+	Auth        *auth           `json:"auth"`
+	Event       []event         `json:"event"`
+	Variable    []variable      `json:"variable"`
+}
+
+type request struct {
+	Method      string          `json:"method"`
+	URL         json.RawMessage `json:"url"`
+	Header      json.RawMessage `json:"header"`
+	Body        *body           `json:"body"`
+	Auth        *auth           `json:"auth"`
+	Description json.RawMessage `json:"description"`
+}
+
+type url struct {
+	Raw      string          `json:"raw"`
+	Protocol string          `json:"protocol"`
+	Host     json.RawMessage `json:"host"`
+	Port     string          `json:"port"`
+	Path     json.RawMessage `json:"path"`
+	Query    []keyValue      `json:"query"`
+	Variable []keyValue      `json:"variable"`
+}
+
+type keyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"`
+	// Src is a file path, or a list of them, of a form-data file field.
+	Src         json.RawMessage `json:"src"`
+	ContentType string          `json:"contentType"`
+}
+
+type body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	URLEncoded []keyValue `json:"urlencoded"`
+	FormData   []keyValue `json:"formdata"`
+	File       *struct {
+		Src string `json:"src"`
+	} `json:"file"`
+	GraphQL *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options *struct {
+		Raw *struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+	Disabled bool `json:"disabled"`
+}
+
+type auth struct {
+	Type string `json:"type"`
+	// Each auth type keeps its parameters in a list named after the type,
+	// e.g. "basic": [{"key": "username", "value": "..."}].
+	Params map[string]json.RawMessage `json:"-"`
+}
+
+func (a *auth) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	if t, ok := raw["type"]; ok {
+		if err := json.Unmarshal(t, &a.Type); err != nil {
+			return err
+		}
+	}
+	a.Params = raw
+
+	return nil
+}
+
+// param returns an auth parameter of the current type.
+func (a *auth) param(key string) (string, bool) {
+	var params []struct {
+		Key   string `json:"key"`
+		Value any    `json:"value"`
+	}
+	if err := json.Unmarshal(a.Params[a.Type], &params); err != nil {
+		return "", false
+	}
+
+	for _, p := range params {
+		if p.Key == key {
+			switch v := p.Value.(type) {
+			case string:
+				return v, true
+			case nil:
+				return "", true
+			default:
+				b, _ := json.Marshal(v)
+				return string(b), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+type variable struct {
+	Key      string `json:"key"`
+	ID       string `json:"id"`
+	Value    any    `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+type event struct {
+	Listen string `json:"listen"`
+}
@@ -0,0 +1,215 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"strings"
+	"time"
+)
+
+// JUnit writes the run as a JUnit XML document with one test case per
+// request, grouped in a test suite per folder.
+func JUnit(w io.Writer, report models.RunReport) error {
+	doc := junitSuites{
+		Name:     report.Name,
+		Tests:    len(report.Results),
+		Failures: report.Count(models.OutcomeFailed),
+		Errors:   report.Count(models.OutcomeErrored),
+		Time:     seconds(report.Duration),
+	}
+
+	index := map[string]int{}
+	for _, res := range report.Results {
+		folder := path.Dir(res.Path)
+		suiteName := folder
+		if res.Iteration > 0 {
+			suiteName = fmt.Sprintf("%s [iteration %d]", folder, res.Iteration)
+		}
+		i, ok := index[suiteName]
+		if !ok {
+			i = len(doc.Suites)
+			index[suiteName] = i
+			doc.Suites = append(doc.Suites, junitSuite{
+				Name:      suiteName,
+				Timestamp: report.StartedAt.Format("2006-01-02T15:04:05"),
+			})
+		}
+
+		suite := &doc.Suites[i]
+		suite.Tests++
+		suite.Time += res.Duration.Seconds()
+
+		tc := junitCase{
+			Name:      path.Base(res.Path),
+			Classname: strings.ReplaceAll(folder, "/", "."),
+			Time:      seconds(res.Duration),
+			SystemOut: fmt.Sprintf("%s %s -> %d", res.Request.Method, res.Request.URL, res.StatusCode),
+		}
+		switch res.Outcome {
+		case models.OutcomeFailed:
+			suite.Failures++
+			failed := failedAssertions(res)
+			tc.Failure = &junitProblem{
+				Message: fmt.Sprintf("%d assertion(s) failed", len(failed)),
+				Type:    "AssertionError",
+				Text:    strings.Join(failed, "\n"),
+			}
+		case models.OutcomeErrored:
+			suite.Errors++
+			tc.Error = &junitProblem{
+				Message: res.Error,
+				Type:    "TransportError",
+			}
+			tc.SystemOut = res.Request.Method + " " + res.Request.URL
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	for i := range doc.Suites {
+		doc.Suites[i].Time = float64(int64(doc.Suites[i].Time*1000)) / 1000
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// JSON writes the run as a JSON document, durations are in milliseconds.
+func JSON(w io.Writer, report models.RunReport) error {
+	doc := jsonReport{
+		Name:       report.Name,
+		StartedAt:  report.StartedAt,
+		DurationMs: report.Duration.Milliseconds(),
+		Iterations: report.Iterations,
+		Summary: jsonSummary{
+			Total:   len(report.Results),
+			Passed:  report.Count(models.OutcomePassed),
+			Failed:  report.Count(models.OutcomeFailed),
+			Errored: report.Count(models.OutcomeErrored),
+		},
+		Results: []jsonResult{},
+	}
+
+	for _, res := range report.Results {
+		jr := jsonResult{
+			Path:       res.Path,
+			Iteration:  res.Iteration,
+			Method:     res.Request.Method,
+			URL:        res.Request.URL,
+			Status:     res.StatusCode,
+			DurationMs: res.Duration.Milliseconds(),
+			Outcome:    res.Outcome,
+			Error:      res.Error,
+			Assertions: []jsonAssertion{},
+		}
+		for _, a := range res.Assertions {
+			jr.Assertions = append(jr.Assertions, jsonAssertion{
+				Assertion: assertions.String(a.Assertion),
+				Passed:    a.Passed,
+				Actual:    a.Actual,
+			})
+		}
+		doc.Results = append(doc.Results, jr)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+func failedAssertions(res models.RunResult) []string {
+	var failed []string
+	for _, a := range res.Assertions {
+		if !a.Passed {
+			failed = append(failed, fmt.Sprintf("%s (got %s)", assertions.String(a.Assertion), a.Actual))
+		}
+	}
+
+	return failed
+}
+
+func seconds(d time.Duration) float64 {
+	return float64(d.Milliseconds()) / 1000
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     float64      `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type jsonReport struct {
+	Name       string       `json:"name"`
+	StartedAt  time.Time    `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Iterations int          `json:"iterations,omitempty"`
+	Summary    jsonSummary  `json:"summary"`
+	Results    []jsonResult `json:"results"`
+}
+
+type jsonSummary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Errored int `json:"errored"`
+}
+
+type jsonResult struct {
+	Path       string          `json:"path"`
+	Iteration  int             `json:"iteration,omitempty"`
+	Method     string          `json:"method"`
+	URL        string          `json:"url"`
+	Status     int             `json:"status,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+	Outcome    string          `json:"outcome"`
+	Error      string          `json:"error,omitempty"`
+	Assertions []jsonAssertion `json:"assertions"`
+}
+
+type jsonAssertion struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Actual    string `json:"actual"`
+}
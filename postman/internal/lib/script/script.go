@@ -0,0 +1,452 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"postman/internal/domain/models"
+	"sort"
+	"strings"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxSteps bounds the work a script may do, so a runaway loop fails
+// instead of hanging the run.
+const maxSteps = 10_000_000
+
+var ErrScript = errors.New("script failed")
+
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// PreRequest runs a Starlark script before the request is sent. The
+// script may change request.method, request.url, request.body and the
+// request.headers and request.query dicts; the changes are written back.
+// vars holds the variables visible to vars.get.
+func PreRequest(ctx context.Context, name, src string, request *models.Request, vars map[string]string) (models.ScriptResult, error) {
+	req := newRequest(*request)
+	env := newEnvironment(vars)
+
+	err := exec(ctx, name, src, env, starlark.StringDict{"request": req})
+	if err != nil {
+		return env.result, err
+	}
+
+	updated, err := req.toRequest(*request)
+	if err != nil {
+		return env.result, fmt.Errorf("%w: %s: %w", ErrScript, name, err)
+	}
+	*request = updated
+
+	return env.result, nil
+}
+
+// PostResponse runs a Starlark script after the response arrived. The
+// request is read-only and the response is available as response.
+func PostResponse(ctx context.Context, name, src string, exchange models.Exchange, vars map[string]string) (models.ScriptResult, error) {
+	req := newRequest(exchange.Request)
+	req.Freeze()
+	env := newEnvironment(vars)
+
+	err := exec(ctx, name, src, env, starlark.StringDict{
+		"request":  req,
+		"response": newResponse(exchange.Response),
+	})
+
+	return env.result, err
+}
+
+// exec runs src with the predeclared globals. There is no load(), so
+// scripts can not reach the file system or the network.
+func exec(ctx context.Context, name, src string, env *environment, globals starlark.StringDict) error {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			env.result.Logs = append(env.result.Logs, msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	predeclared := starlark.StringDict{
+		"json": json.Module,
+		"vars": env.varsModule(),
+		"env":  env.envModule(),
+		"test": starlark.NewBuiltin("test", env.test),
+	}
+	for k, v := range globals {
+		predeclared[k] = v
+	}
+
+	if _, err := starlark.ExecFileOptions(fileOptions, thread, name, src, predeclared); err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return fmt.Errorf("%w: %s", ErrScript, evalErr.Backtrace())
+		}
+		return fmt.Errorf("%w: %w", ErrScript, err)
+	}
+
+	return nil
+}
+
+// environment collects what a script sets and tests.
+type environment struct {
+	vars   map[string]string
+	result models.ScriptResult
+}
+
+func newEnvironment(vars map[string]string) *environment {
+	copied := make(map[string]string, len(vars))
+	for k, v := range vars {
+		copied[k] = v
+	}
+
+	return &environment{
+		vars: copied,
+		result: models.ScriptResult{
+			Variables:   map[string]string{},
+			Environment: map[string]string{},
+		},
+	}
+}
+
+func (e *environment) varsModule() *starlarkstruct.Module {
+	return &starlarkstruct.Module{
+		Name: "vars",
+		Members: starlark.StringDict{
+			"get": starlark.NewBuiltin("vars.get", e.get),
+			"set": starlark.NewBuiltin("vars.set", e.setter(e.result.Variables)),
+		},
+	}
+}
+
+func (e *environment) envModule() *starlarkstruct.Module {
+	return &starlarkstruct.Module{
+		Name: "env",
+		Members: starlark.StringDict{
+			"get": starlark.NewBuiltin("env.get", e.get),
+			"set": starlark.NewBuiltin("env.set", e.setter(e.result.Environment)),
+		},
+	}
+}
+
+func (e *environment) get(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		name string
+		def  starlark.Value = starlark.None
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "default?", &def); err != nil {
+		return nil, err
+	}
+
+	if v, ok := e.vars[name]; ok {
+		return starlark.String(v), nil
+	}
+
+	return def, nil
+}
+
+// setter stores a value in target and makes it visible to later get calls.
+// Non-string values are stored as their string form, e.g. 42 as "42".
+func (e *environment) setter(target map[string]string) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var (
+			name  string
+			value starlark.Value
+		)
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+			return nil, err
+		}
+
+		s := value.String()
+		if str, ok := value.(starlark.String); ok {
+			s = string(str)
+		}
+		target[name] = s
+		e.vars[name] = s
+
+		return starlark.None, nil
+	}
+}
+
+// test records a named check. The condition is a bool or a function
+// that passes unless it calls fail().
+func (e *environment) test(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		name      string
+		condition starlark.Value
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "condition", &condition); err != nil {
+		return nil, err
+	}
+
+	result := models.AssertionResult{
+		Assertion: models.Assertion{Subject: models.AssertScript, Target: name},
+		Passed:    true,
+		Actual:    "ok",
+	}
+
+	if fn, ok := condition.(starlark.Callable); ok {
+		if _, err := starlark.Call(thread, fn, nil, nil); err != nil {
+			var evalErr *starlark.EvalError
+			if errors.As(err, &evalErr) {
+				err = errors.New(evalErr.Msg)
+			}
+			result.Passed = false
+			result.Actual = err.Error()
+		}
+	} else if !bool(condition.Truth()) {
+		result.Passed = false
+		result.Actual = "condition is false"
+	}
+
+	e.result.Tests = append(e.result.Tests, result)
+
+	return starlark.Bool(result.Passed), nil
+}
+
+// request is the script view of a request. Headers and query parameters
+// are dicts holding the last value of each key; the entries they were
+// built from are kept, so that repeated keys and disabled parameters the
+// script did not change are written back as they were.
+type request struct {
+	method  string
+	url     string
+	body    string
+	headers *starlark.Dict
+	query   *starlark.Dict
+	// headerPairs and queryPairs are the entries of the request.
+	headerPairs []pair
+	queryPairs  []pair
+	frozen      bool
+}
+
+var _ starlark.HasSetField = (*request)(nil)
+
+// pair is a header or a query parameter.
+type pair struct {
+	key, value string
+	disabled   bool
+}
+
+func newRequest(r models.Request) *request {
+	req := &request{
+		method: r.Method,
+		url:    r.URL,
+		body:   r.Body,
+	}
+	for _, h := range r.Headers {
+		req.headerPairs = append(req.headerPairs, pair{key: h.Key, value: h.Value})
+	}
+	for _, q := range r.Query {
+		req.queryPairs = append(req.queryPairs, pair{key: q.Key, value: q.Value, disabled: q.Disabled})
+	}
+	req.headers = dict(req.headerPairs)
+	req.query = dict(req.queryPairs)
+
+	return req
+}
+
+func (r *request) toRequest(base models.Request) (models.Request, error) {
+	base.Method = r.method
+	base.URL = r.url
+	base.Body = r.body
+
+	headers, err := update(r.headerPairs, r.headers)
+	if err != nil {
+		return models.Request{}, fmt.Errorf("request.headers: %w", err)
+	}
+	base.Headers = nil
+	for _, h := range headers {
+		base.Headers = append(base.Headers, models.Header{Key: h.key, Value: h.value})
+	}
+
+	query, err := update(r.queryPairs, r.query)
+	if err != nil {
+		return models.Request{}, fmt.Errorf("request.query: %w", err)
+	}
+	base.Query = nil
+	for _, q := range query {
+		base.Query = append(base.Query, models.QueryParam{Key: q.key, Value: q.value, Disabled: q.disabled})
+	}
+
+	return base, nil
+}
+
+// dict returns the enabled pairs as a dict, a repeated key with its last
+// value. Disabled parameters are not sent, so scripts don't see them.
+func dict(pairs []pair) *starlark.Dict {
+	d := starlark.NewDict(len(pairs))
+	for _, p := range pairs {
+		if !p.disabled {
+			_ = d.SetKey(starlark.String(p.key), starlark.String(p.value))
+		}
+	}
+
+	return d
+}
+
+// update applies the changes a script made to the dict built from pairs.
+// Keys the script left alone keep all their entries, a changed key gets
+// its new value in place of its first entry, deleted keys are dropped
+// and new keys are appended in the order of the dict.
+func update(pairs []pair, d *starlark.Dict) ([]pair, error) {
+	initial := map[string]string{}
+	for _, p := range pairs {
+		if !p.disabled {
+			initial[p.key] = p.value
+		}
+	}
+
+	values := map[string]string{}
+	var added []string
+	for _, item := range d.Items() {
+		k, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("key %s is not a string", item[0])
+		}
+		v, ok := starlark.AsString(item[1])
+		if !ok {
+			v = item[1].String()
+		}
+		values[k] = v
+		if _, ok := initial[k]; !ok {
+			added = append(added, k)
+		}
+	}
+
+	var result []pair
+	changed := map[string]bool{}
+	for _, p := range pairs {
+		v, ok := values[p.key]
+		switch {
+		case p.disabled:
+			result = append(result, p)
+		case !ok:
+			// deleted by the script
+		case v == initial[p.key]:
+			result = append(result, p)
+		case !changed[p.key]:
+			result = append(result, pair{key: p.key, value: v})
+			changed[p.key] = true
+		}
+	}
+	for _, k := range added {
+		result = append(result, pair{key: k, value: values[k]})
+	}
+
+	return result, nil
+}
+
+func (r *request) String() string        { return fmt.Sprintf("<request %s %s>", r.method, r.url) }
+func (r *request) Type() string          { return "request" }
+func (r *request) Truth() starlark.Bool  { return starlark.True }
+func (r *request) Hash() (uint32, error) { return 0, errors.New("unhashable type: request") }
+
+func (r *request) Freeze() {
+	r.frozen = true
+	r.headers.Freeze()
+	r.query.Freeze()
+}
+
+func (r *request) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "method":
+		return starlark.String(r.method), nil
+	case "url":
+		return starlark.String(r.url), nil
+	case "body":
+		return starlark.String(r.body), nil
+	case "headers":
+		return r.headers, nil
+	case "query":
+		return r.query, nil
+	}
+
+	return nil, nil
+}
+
+func (r *request) AttrNames() []string {
+	return []string{"body", "headers", "method", "query", "url"}
+}
+
+func (r *request) SetField(name string, val starlark.Value) error {
+	if r.frozen {
+		return errors.New("request is read-only after sending")
+	}
+
+	s, ok := starlark.AsString(val)
+	if !ok {
+		return fmt.Errorf("request.%s must be a string, got %s", name, val.Type())
+	}
+
+	switch name {
+	case "method":
+		r.method = strings.ToUpper(s)
+	case "url":
+		r.url = s
+	case "body":
+		r.body = s
+	default:
+		return starlark.NoSuchAttrError(fmt.Sprintf("request has no settable field .%s", name))
+	}
+
+	return nil
+}
+
+func newResponse(resp models.Response) *starlarkstruct.Struct {
+	headers := starlark.NewDict(len(resp.Headers))
+	keys := make([]string, 0, len(resp.Headers))
+	for k := range resp.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		_ = headers.SetKey(starlark.String(k), starlark.String(strings.Join(resp.Headers[k], ", ")))
+	}
+	headers.Freeze()
+
+	body := string(resp.Body)
+
+	return starlarkstruct.FromStringDict(starlark.String("response"), starlark.StringDict{
+		"status":  starlark.MakeInt(resp.StatusCode),
+		"headers": headers,
+		"body":    starlark.String(body),
+		"time_ms": starlark.MakeInt64(resp.Duration.Milliseconds()),
+		"header": starlark.NewBuiltin("response.header", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+				return nil, err
+			}
+			values, ok := resp.Headers[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return starlark.None, nil
+			}
+			return starlark.String(strings.Join(values, ", ")), nil
+		}),
+		"json": starlark.NewBuiltin("response.json", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+				return nil, err
+			}
+			return starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(body)}, nil)
+		}),
+	})
+}
@@ -0,0 +1,124 @@
+package script_test
+
+import (
+	"context"
+	"postman/internal/domain/models"
+	"postman/internal/lib/script"
+	"reflect"
+	"testing"
+)
+
+func TestPreRequestWritesBackChanges(t *testing.T) {
+	base := models.Request{
+		Method: "GET",
+		URL:    "http://localhost:8080/api/v1/users",
+		Headers: []models.Header{
+			{Key: "X-A", Value: "1"},
+			{Key: "Accept", Value: "application/json"},
+			{Key: "X-A", Value: "2"},
+		},
+		Query: []models.QueryParam{
+			{Key: "a", Value: "1"},
+			{Key: "off", Value: "3", Disabled: true},
+			{Key: "a", Value: "2"},
+			{Key: "page", Value: "1"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		src         string
+		wantHeaders []models.Header
+		wantQuery   []models.QueryParam
+	}{
+		{
+			name:        "untouched",
+			src:         `print(request.headers["X-A"], request.query)`,
+			wantHeaders: base.Headers,
+			wantQuery:   base.Query,
+		},
+		{
+			name: "value set to the same",
+			src:  `request.headers["X-A"] = "2"`,
+			// the dict holds the last value, so nothing changed
+			wantHeaders: base.Headers,
+			wantQuery:   base.Query,
+		},
+		{
+			name: "changed repeated key",
+			src:  `request.headers["X-A"] = "3"; request.query["a"] = "9"`,
+			wantHeaders: []models.Header{
+				{Key: "X-A", Value: "3"},
+				{Key: "Accept", Value: "application/json"},
+			},
+			wantQuery: []models.QueryParam{
+				{Key: "a", Value: "9"},
+				{Key: "off", Value: "3", Disabled: true},
+				{Key: "page", Value: "1"},
+			},
+		},
+		{
+			name: "deleted and added keys",
+			src:  `request.headers.pop("X-A"); request.headers["X-Trace"] = "t1"; request.query.pop("page"); request.query["q"] = 5`,
+			wantHeaders: []models.Header{
+				{Key: "Accept", Value: "application/json"},
+				{Key: "X-Trace", Value: "t1"},
+			},
+			wantQuery: []models.QueryParam{
+				{Key: "a", Value: "1"},
+				{Key: "off", Value: "3", Disabled: true},
+				{Key: "a", Value: "2"},
+				{Key: "q", Value: "5"},
+			},
+		},
+		{
+			name:        "disabled parameter is not visible",
+			src:         `request.query["off"] = "4"`,
+			wantHeaders: base.Headers,
+			wantQuery: []models.QueryParam{
+				{Key: "a", Value: "1"},
+				{Key: "off", Value: "3", Disabled: true},
+				{Key: "a", Value: "2"},
+				{Key: "page", Value: "1"},
+				{Key: "off", Value: "4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := base
+			if _, err := script.PreRequest(context.Background(), "test", tt.src, &request, nil); err != nil {
+				t.Fatalf("PreRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(request.Headers, tt.wantHeaders) {
+				t.Errorf("headers = %v, want %v", request.Headers, tt.wantHeaders)
+			}
+			if !reflect.DeepEqual(request.Query, tt.wantQuery) {
+				t.Errorf("query = %v, want %v", request.Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestPreRequestFields(t *testing.T) {
+	request := models.Request{Method: "get", URL: "http://localhost/", Body: "{}"}
+	src := `
+request.method = "post"
+request.url = request.url + "users"
+request.body = '{"id": %d}' % 1
+vars.set("seen", "yes")
+`
+
+	result, err := script.PreRequest(context.Background(), "test", src, &request, nil)
+	if err != nil {
+		t.Fatalf("PreRequest() error = %v", err)
+	}
+	want := models.Request{Method: "POST", URL: "http://localhost/users", Body: `{"id": 1}`}
+	if !reflect.DeepEqual(request, want) {
+		t.Errorf("request = %+v, want %+v", request, want)
+	}
+	if result.Variables["seen"] != "yes" {
+		t.Errorf("variables = %v, want seen=yes", result.Variables)
+	}
+}
@@ -0,0 +1,231 @@
+package variables
+
+import (
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"postman/internal/domain/models"
+	"postman/internal/lib/pathparams"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var placeholder = regexp.MustCompile(`{{\s*([A-Za-z0-9_.$-]+)\s*}}`)
+
+// Substitute replaces {{name}} placeholders with values from vars.
+// Dynamic variables ($guid, $timestamp, $randomInt) are generated on
+// every use, $env.NAME reads the process environment. Unknown
+// placeholders are left as is and returned in missing.
+func Substitute(s string, vars map[string]string) (result string, missing []string) {
+	result = placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		if value, ok := dynamic(name); ok {
+			return value
+		}
+
+		missing = append(missing, name)
+		return m
+	})
+
+	return result, missing
+}
+
+// Apply substitutes variables in the URL, headers, query, body and form
+// fields of request and fills in the path parameters of the URL.
+// The returned list of unresolved names is sorted and has no duplicates.
+func Apply(request models.Request, vars map[string]string) (models.Request, []string) {
+	seen := map[string]bool{}
+	request = transform(request, func(s string) string {
+		result, missing := Substitute(s, vars)
+		for _, name := range missing {
+			seen[name] = true
+		}
+		return result
+	})
+
+	// path parameters take their PathParams value or the variable
+	pathValues := Merge(vars)
+	for _, p := range request.PathParams {
+		if p.Value != "" {
+			pathValues[p.Key] = p.Value
+		}
+	}
+	var missingPath []string
+	request.URL, missingPath = pathparams.Apply(request.URL, pathValues)
+	for _, name := range missingPath {
+		seen[name] = true
+	}
+
+	missing := make([]string, 0, len(seen))
+	for name := range seen {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+
+	return request, missing
+}
+
+// Hide replaces the values of secrets in request with {{name}}
+// placeholders, so that a resolved request can be stored without them.
+// Longer values are replaced first.
+func Hide(request models.Request, secrets map[string]string) models.Request {
+	if len(secrets) == 0 {
+		return request
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name, value := range secrets {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(secrets[names[i]]) > len(secrets[names[j]]) })
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, secrets[name], "{{"+name+"}}")
+	}
+
+	return transform(request, strings.NewReplacer(pairs...).Replace)
+}
+
+// Reveal puts the values of secrets back in place of the placeholders
+// Hide left. Other placeholders are kept.
+func Reveal(request models.Request, secrets map[string]string) models.Request {
+	if len(secrets) == 0 {
+		return request
+	}
+
+	return transform(request, func(s string) string {
+		return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			if value, ok := secrets[placeholder.FindStringSubmatch(m)[1]]; ok {
+				return value
+			}
+			return m
+		})
+	})
+}
+
+// transform applies f to the URL, headers, query, path parameter values,
+// body, form fields and auth of request.
+func transform(request models.Request, f func(string) string) models.Request {
+	request.URL = f(request.URL)
+	request.Body = f(request.Body)
+	request.ContentType = f(request.ContentType)
+	request.BodyFile = f(request.BodyFile)
+
+	hdrs := make([]models.Header, len(request.Headers))
+	for i, h := range request.Headers {
+		hdrs[i] = models.Header{Key: f(h.Key), Value: f(h.Value)}
+	}
+	request.Headers = hdrs
+
+	query := make([]models.QueryParam, len(request.Query))
+	for i, q := range request.Query {
+		query[i] = models.QueryParam{Key: f(q.Key), Value: f(q.Value), Disabled: q.Disabled}
+	}
+	request.Query = query
+
+	if request.PathParams != nil {
+		params := make([]models.PathParam, len(request.PathParams))
+		for i, p := range request.PathParams {
+			params[i] = models.PathParam{Key: p.Key, Value: f(p.Value)}
+		}
+		request.PathParams = params
+	}
+
+	if request.Form != nil {
+		form := make([]models.FormField, len(request.Form))
+		for i, field := range request.Form {
+			field.Key, field.Value, field.File, field.ContentType = f(field.Key), f(field.Value), f(field.File), f(field.ContentType)
+			form[i] = field
+		}
+		request.Form = form
+	}
+
+	if request.Auth != nil {
+		a := *request.Auth
+		a.Username = f(a.Username)
+		a.Password = f(a.Password)
+		a.Token = f(a.Token)
+		a.Key = f(a.Key)
+		a.Value = f(a.Value)
+		a.TokenURL = f(a.TokenURL)
+		a.AuthURL = f(a.AuthURL)
+		a.ClientID = f(a.ClientID)
+		a.ClientSecret = f(a.ClientSecret)
+		a.Scope = f(a.Scope)
+		request.Auth = &a
+	}
+
+	return request
+}
+
+// Merge combines variable sets, later sets take precedence.
+func Merge(sets ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// Mask replaces the secret values in s with mask, longer values first
+// so that a secret containing another one is masked whole.
+func Mask(s string, secrets map[string]string, mask string) string {
+	if len(secrets) == 0 {
+		return s
+	}
+
+	sorted := slices.Collect(maps.Values(secrets))
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, secret := range sorted {
+		if secret != "" {
+			pairs = append(pairs, secret, mask)
+		}
+	}
+
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+func dynamic(name string) (string, bool) {
+	switch name {
+	case "$guid", "$randomUUID":
+		return uuid(), true
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case "$isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), true
+	case "$randomInt":
+		n, _ := rand.Int(rand.Reader, big.NewInt(1001))
+		return n.String(), true
+	}
+
+	// {{$env.NAME}} reads a process environment variable
+	if key, ok := strings.CutPrefix(name, "$env."); ok {
+		return os.LookupEnv(key)
+	}
+
+	return "", false
+}
+
+func uuid() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
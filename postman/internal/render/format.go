@@ -0,0 +1,456 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+var (
+	tagColor     = color.New(color.FgBlue)
+	attrColor    = color.New(color.FgCyan)
+	commentColor = color.New(color.FgHiBlack)
+)
+
+// indent is the indentation of one nesting level.
+const indent = "  "
+
+// format pretty-prints and colors a body of the given kind. Text and
+// binary bodies and bodies that fail to parse return an error.
+func format(kind BodyKind, body []byte) (string, error) {
+	switch kind {
+	case KindJSON:
+		return formatJSON(body)
+	case KindXML:
+		return formatXML(body)
+	case KindHTML:
+		return formatHTML(body)
+	case KindYAML:
+		return highlightYAML(string(body)), nil
+	case KindForm:
+		return formatForm(body)
+	}
+
+	return "", errors.New("no formatter")
+}
+
+// formatJSON indents JSON without decoding it, so the order of the keys
+// and the digits of big numbers stay as received.
+func formatJSON(body []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", indent); err != nil {
+		return "", err
+	}
+
+	return highlightJSON(buf.String()), nil
+}
+
+// formatXML puts every element on its own line. An element holding only
+// text stays on one line. Namespace prefixes are kept as written.
+func formatXML(body []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	var (
+		tokens []xml.Token
+		// open are the names of the elements not ended yet; RawToken
+		// does not check that end tags match.
+		open []xml.Name
+	)
+	for {
+		token, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			open = append(open, token.Name)
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != token.Name {
+				return "", fmt.Errorf("unexpected end element </%s>", xmlName(token.Name))
+			}
+			open = open[:len(open)-1]
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) == 0 {
+				continue
+			}
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("element <%s> is not closed", xmlName(open[len(open)-1]))
+	}
+
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		switch token := tokens[i].(type) {
+		case xml.StartElement:
+			line(&b, depth)
+			next := func(n int) xml.Token {
+				if i+n < len(tokens) {
+					return tokens[i+n]
+				}
+				return nil
+			}
+			if _, ok := next(1).(xml.EndElement); ok {
+				b.WriteString(xmlStart(token, true))
+				i++
+				break
+			}
+			text, isText := next(1).(xml.CharData)
+			if _, ok := next(2).(xml.EndElement); isText && ok {
+				b.WriteString(xmlStart(token, false) + escapeXML(string(bytes.TrimSpace(text))) + xmlEnd(token.Name))
+				i += 2
+				break
+			}
+			b.WriteString(xmlStart(token, false))
+			depth++
+
+		case xml.EndElement:
+			depth = max(depth-1, 0)
+			line(&b, depth)
+			b.WriteString(xmlEnd(token.Name))
+
+		case xml.CharData:
+			line(&b, depth)
+			b.WriteString(escapeXML(string(bytes.TrimSpace(token))))
+
+		case xml.Comment:
+			line(&b, depth)
+			b.WriteString(commentColor.Sprint("<!--" + string(token) + "-->"))
+
+		case xml.ProcInst:
+			line(&b, depth)
+			b.WriteString(commentColor.Sprint("<?" + token.Target + " " + string(token.Inst) + "?>"))
+
+		case xml.Directive:
+			line(&b, depth)
+			b.WriteString(commentColor.Sprint("<!" + string(token) + ">"))
+		}
+	}
+
+	return b.String(), nil
+}
+
+func xmlStart(el xml.StartElement, empty bool) string {
+	var b strings.Builder
+	b.WriteString(tagColor.Sprint("<" + xmlName(el.Name)))
+	for _, attr := range el.Attr {
+		b.WriteString(" " + attrColor.Sprint(xmlName(attr.Name)) + "=" + stringColor.Sprint(`"`+escapeXML(attr.Value)+`"`))
+	}
+	if empty {
+		b.WriteString(tagColor.Sprint("/>"))
+	} else {
+		b.WriteString(tagColor.Sprint(">"))
+	}
+
+	return b.String()
+}
+
+func xmlEnd(name xml.Name) string {
+	return tagColor.Sprint("</" + xmlName(name) + ">")
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
+
+// voidElements have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// verbatimElements keep their content as written.
+var verbatimElements = map[string]bool{
+	"pre": true, "script": true, "style": true, "textarea": true, "title": true,
+}
+
+// impliedEnd elements are ended by the next element of the same name.
+var impliedEnd = map[string]bool{
+	"li": true, "p": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true, "option": true,
+}
+
+// formatHTML puts every tag and text on its own line. An element holding
+// only text stays on one line. Whitespace in text is collapsed, except in
+// verbatim elements such as pre and script.
+func formatHTML(body []byte) (string, error) {
+	tokens, err := htmlTokens(body)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	// open are the elements started and not ended, their number the depth.
+	var open []string
+	for i := 0; i < len(tokens); i++ {
+		next := func(n int) html.Token {
+			if i+n < len(tokens) {
+				return tokens[i+n]
+			}
+			return html.Token{}
+		}
+
+		token := tokens[i]
+		switch token.Type {
+		case html.TextToken:
+			line(&b, len(open))
+			b.WriteString(html.EscapeString(token.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if n := len(open); n > 0 && open[n-1] == token.Data && impliedEnd[token.Data] {
+				open = open[:n-1]
+			}
+			line(&b, len(open))
+			b.WriteString(htmlStart(token))
+			end := func(n int) bool {
+				return next(n).Type == html.EndTagToken && next(n).Data == token.Data
+			}
+			switch {
+			case token.Type == html.SelfClosingTagToken, voidElements[token.Data]:
+			case end(1):
+				b.WriteString(htmlEnd(token.Data))
+				i++
+			case next(1).Type == html.TextToken && end(2):
+				text := next(1).Data
+				if !verbatimElements[token.Data] {
+					text = html.EscapeString(text)
+				}
+				b.WriteString(text + htmlEnd(token.Data))
+				i += 2
+			default:
+				open = append(open, token.Data)
+			}
+
+		case html.EndTagToken:
+			// an end tag also ends the elements left open inside it
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] == token.Data {
+					open = open[:j]
+					break
+				}
+			}
+			line(&b, len(open))
+			b.WriteString(htmlEnd(token.Data))
+
+		case html.CommentToken:
+			line(&b, len(open))
+			b.WriteString(commentColor.Sprint("<!--" + token.Data + "-->"))
+
+		case html.DoctypeToken:
+			line(&b, len(open))
+			b.WriteString(commentColor.Sprint("<!DOCTYPE " + token.Data + ">"))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// htmlTokens returns the tokens of a document with whitespace collapsed
+// in text. The content of a verbatim element is one text token as
+// written, not unescaped.
+func htmlTokens(body []byte) ([]html.Token, error) {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	var (
+		tokens   []html.Token
+		verbatim string
+		raw      strings.Builder
+	)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return nil, z.Err()
+			}
+			if verbatim != "" {
+				tokens = append(tokens, html.Token{Type: html.TextToken, Data: raw.String()})
+			}
+			return tokens, nil
+		}
+
+		if verbatim != "" {
+			if name, _ := z.TagName(); tt != html.EndTagToken || string(name) != verbatim {
+				raw.Write(z.Raw())
+				continue
+			}
+			tokens = append(tokens,
+				html.Token{Type: html.TextToken, Data: raw.String()},
+				html.Token{Type: html.EndTagToken, Data: verbatim})
+			verbatim = ""
+			raw.Reset()
+			continue
+		}
+
+		token := z.Token()
+		if token.Type == html.TextToken {
+			if token.Data = strings.Join(strings.Fields(token.Data), " "); token.Data == "" {
+				continue
+			}
+		}
+		if token.Type == html.StartTagToken && verbatimElements[token.Data] {
+			verbatim = token.Data
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func htmlEnd(name string) string {
+	return tagColor.Sprint("</" + name + ">")
+}
+
+func htmlStart(token html.Token) string {
+	var b strings.Builder
+	b.WriteString(tagColor.Sprint("<" + token.Data))
+	for _, attr := range token.Attr {
+		b.WriteString(" " + attrColor.Sprint(attr.Key))
+		if attr.Val != "" {
+			b.WriteString("=" + stringColor.Sprint(`"`+html.EscapeString(attr.Val)+`"`))
+		}
+	}
+	if token.Type == html.SelfClosingTagToken {
+		b.WriteString(tagColor.Sprint("/>"))
+	} else {
+		b.WriteString(tagColor.Sprint(">"))
+	}
+
+	return b.String()
+}
+
+// line starts a new line at the given depth, except at the start.
+func line(b *strings.Builder, depth int) {
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Repeat(indent, depth))
+}
+
+var (
+	yamlKey    = regexp.MustCompile(`^((?:- +)*)("(?:[^"\\]|\\.)*"|'[^']*'|[^\s#'"\[\]{},][^#]*?)(:)(\s+|$)(.*)$`)
+	yamlDashes = regexp.MustCompile(`^(?:- +|-$)*`)
+	yamlNumber = regexp.MustCompile(`^[-+]?(\d[\d_]*(\.\d*)?([eE][-+]?\d+)?|\.\d+|0x[0-9a-fA-F]+|\.inf|\.nan)$`)
+)
+
+// highlightYAML colors YAML line by line, leaving the text as written
+// so that comments and anchors are kept.
+func highlightYAML(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	// block is the indentation of the key of a | or > block scalar, -1
+	// outside of one.
+	block := -1
+	for i, l := range lines {
+		content := strings.TrimLeft(l, " ")
+		depth := len(l) - len(content)
+
+		if block >= 0 {
+			if content == "" || depth > block {
+				lines[i] = stringColor.Sprint(l)
+				continue
+			}
+			block = -1
+		}
+
+		switch {
+		case content == "":
+		case strings.HasPrefix(content, "#"), content == "---", content == "...":
+			lines[i] = l[:depth] + commentColor.Sprint(content)
+		default:
+			var value string
+			if m := yamlKey.FindStringSubmatch(content); m != nil {
+				lines[i] = l[:depth] + m[1] + keyColor.Sprint(m[2]) + m[3] + m[4]
+				value = m[5]
+			} else {
+				dashes := yamlDashes.FindString(content)
+				lines[i] = l[:depth] + dashes
+				value = content[len(dashes):]
+			}
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				block = depth
+			}
+			lines[i] += yamlValue(value)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// yamlValue colors a scalar and its trailing comment.
+func yamlValue(s string) string {
+	comment := ""
+	if !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "'") {
+		if i := strings.Index(s, " #"); i >= 0 {
+			s, comment = s[:i], commentColor.Sprint(s[i:])
+		}
+	}
+
+	value := strings.TrimRight(s, " ")
+	space := s[len(value):]
+	switch {
+	case value == "":
+		return space + comment
+	case yamlNumber.MatchString(value):
+		value = numberColor.Sprint(value)
+	case isYAMLLiteral(value), strings.HasPrefix(value, "|"), strings.HasPrefix(value, ">"),
+		strings.HasPrefix(value, "&"), strings.HasPrefix(value, "*"), strings.HasPrefix(value, "!"):
+		value = literalColor.Sprint(value)
+	default:
+		value = stringColor.Sprint(value)
+	}
+
+	return value + space + comment
+}
+
+func isYAMLLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+
+	return false
+}
+
+// formatForm decodes the fields of an urlencoded body, one per line in
+// the order received.
+func formatForm(body []byte) (string, error) {
+	var b strings.Builder
+	for _, pair := range strings.Split(strings.TrimSpace(string(body)), "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return "", err
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return "", err
+		}
+
+		line(&b, 0)
+		b.WriteString(keyColor.Sprint(key) + ": " + stringColor.Sprint(value))
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,251 @@
+package render_test
+
+import (
+	"bytes"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/render"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        render.BodyKind
+	}{
+		{"empty", "application/json", "", render.KindEmpty},
+		{"json", "application/json; charset=utf-8", `{"a":1}`, render.KindJSON},
+		{"json suffix", "application/problem+json", `{"title":"x"}`, render.KindJSON},
+		{"invalid json", "application/json", `{"a":`, render.KindText},
+		{"xml", "application/xml", `<a/>`, render.KindXML},
+		{"text xml", "text/xml", `<a/>`, render.KindXML},
+		{"xml suffix", "application/atom+xml", `<feed/>`, render.KindXML},
+		{"html", "text/html; charset=utf-8", `<p>hi</p>`, render.KindHTML},
+		{"yaml", "application/yaml", "a: 1", render.KindYAML},
+		{"yaml suffix", "application/vnd.api+yaml", "a: 1", render.KindYAML},
+		{"form", "application/x-www-form-urlencoded", "a=1&b=2", render.KindForm},
+		{"text", "text/plain", "hello", render.KindText},
+		{"javascript", "application/javascript", "let a = 1", render.KindText},
+		{"sniffed json", "", `[1, 2]`, render.KindJSON},
+		{"sniffed html", "application/octet-stream", "<!DOCTYPE html><html></html>", render.KindHTML},
+		{"sniffed xml", "", `<?xml version="1.0"?><a/>`, render.KindXML},
+		{"sniffed text", "", "plain words", render.KindText},
+		{"binary", "application/octet-stream", "\x89PNG\r\n\x1a\n\x00\x00", render.KindBinary},
+		{"text with a zero byte", "", "a\x00b", render.KindBinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render.Kind(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("Kind(%q, %q) = %d, want %d", tt.contentType, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		// wantRaw is the output in raw mode, the body as received when
+		// empty.
+		wantRaw string
+	}{
+		{
+			name:        "json keeps key order and big numbers",
+			contentType: "application/json",
+			body:        `{"z":1,"a":[12345678901234567890,1.50,true,null],"m":{}}`,
+			want: `{
+  "z": 1,
+  "a": [
+    12345678901234567890,
+    1.50,
+    true,
+    null
+  ],
+  "m": {}
+}
+`,
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        `<?xml version="1.0"?><users><!-- all --><user id="1"><name>Alice &amp; Bob</name><tags/></user></users>`,
+			want: `<?xml version="1.0"?>
+<users>
+  <!-- all -->
+  <user id="1">
+    <name>Alice &amp; Bob</name>
+    <tags/>
+  </user>
+</users>
+`,
+		},
+		{
+			name:        "xml with namespaces",
+			contentType: "text/xml",
+			body:        `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>ok</soap:Body></soap:Envelope>`,
+			want: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>ok</soap:Body>
+</soap:Envelope>
+`,
+		},
+		{
+			name:        "html",
+			contentType: "text/html",
+			body:        "<!DOCTYPE html><html><body><p>Hello,   <b>world</b></p><br><pre>  a\n  b</pre></body></html>",
+			want: `<!DOCTYPE html>
+<html>
+  <body>
+    <p>
+      Hello,
+      <b>world</b>
+    </p>
+    <br>
+    <pre>  a
+  b</pre>
+  </body>
+</html>
+`,
+		},
+		{
+			name:        "yaml is kept as written",
+			contentType: "application/yaml",
+			body:        "# users\nusers:\n  - name: alice # admin\n    age: 31\n",
+			want:        "# users\nusers:\n  - name: alice # admin\n    age: 31\n",
+		},
+		{
+			name:        "form fields are decoded",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "login=alice&note=a+b%26c&empty=",
+			want:        "login: alice\nnote: a b&c\nempty: \n",
+		},
+		{
+			name:        "invalid json is printed as received",
+			contentType: "application/json",
+			body:        `{"a":`,
+			want:        "{\"a\":\n",
+		},
+		{
+			name:        "mismatched xml is printed as received",
+			contentType: "application/xml",
+			body:        `<a><b></a>`,
+			want:        "<a><b></a>\n",
+		},
+		{
+			name:        "unclosed xml is printed as received",
+			contentType: "application/xml",
+			body:        `<a><b>text</b>`,
+			want:        "<a><b>text</b>\n",
+		},
+		{
+			name:        "text",
+			contentType: "text/plain",
+			body:        "  as is  ",
+			want:        "  as is  \n",
+		},
+		{
+			name: "empty",
+			want: "<empty body>\n",
+		},
+		{
+			name:        "binary summary",
+			contentType: "image/png",
+			body:        "\x89PNG\r\n\x1a\n\x00\x01",
+			want:        "<binary body: 10 B, image/png>\n89 50 4e 47 0d 0a 1a 0a 00 01\n",
+			wantRaw:     "<binary body: 10 B, image/png>\n89 50 4e 47 0d 0a 1a 0a 00 01\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := models.Response{Headers: http.Header{}, Body: []byte(tt.body)}
+			if tt.contentType != "" {
+				resp.Headers.Set("Content-Type", tt.contentType)
+			}
+
+			var buf bytes.Buffer
+			r := render.New(&buf)
+			r.Body(resp)
+			if buf.String() != tt.want {
+				t.Errorf("Body() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+
+			wantRaw := tt.wantRaw
+			if wantRaw == "" {
+				wantRaw = tt.body + "\n"
+				if tt.body == "" {
+					wantRaw = tt.want
+				}
+			}
+			buf.Reset()
+			r.SetRaw(true)
+			r.Body(resp)
+			if buf.String() != wantRaw {
+				t.Errorf("raw Body() =\n%s\nwant\n%s", buf.String(), wantRaw)
+			}
+		})
+	}
+}
+
+func TestBinaryPreviewIsCut(t *testing.T) {
+	body := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 92)...)
+
+	var buf bytes.Buffer
+	render.New(&buf).Body(models.Response{Headers: http.Header{}, Body: body})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "<binary body: 100 B, image/png>" {
+		t.Fatalf("Body() = %q, want a summary and a preview", buf.String())
+	}
+	if got := len(strings.Fields(lines[1])); got != 65 || !strings.HasSuffix(lines[1], " ...") {
+		t.Errorf("preview = %q, want 64 bytes and ...", lines[1])
+	}
+}
+
+func TestBodyHighlighting(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		// colored are parts of the body that must be colored.
+		colored []string
+	}{
+		{"json", "application/json", `{"key":"value","n":12}`, []string{`"key"`, `"value"`, "12"}},
+		{"xml", "application/xml", `<a b="c">text</a>`, []string{"<a", `"c"`}},
+		{"yaml", "application/yaml", "key: value\nn: 12\nok: true", []string{"key", "value", "12", "true"}},
+		{"form", "application/x-www-form-urlencoded", "key=value", []string{"key", "value"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := models.Response{Headers: http.Header{"Content-Type": {tt.contentType}}, Body: []byte(tt.body)}
+
+			var buf bytes.Buffer
+			r := render.New(&buf)
+			r.Body(resp)
+			for _, part := range tt.colored {
+				if !strings.Contains(buf.String(), part+"\x1b[0") {
+					t.Errorf("Body() = %q, want %s colored", buf.String(), part)
+				}
+			}
+
+			buf.Reset()
+			r.SetRaw(true)
+			r.Body(resp)
+			if strings.Contains(buf.String(), "\x1b[") {
+				t.Errorf("raw Body() = %q, want no colors", buf.String())
+			}
+		})
+	}
+}
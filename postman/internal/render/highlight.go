@@ -0,0 +1,70 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	keyColor     = color.New(color.FgBlue, color.Bold)
+	stringColor  = color.New(color.FgGreen)
+	numberColor  = color.New(color.FgYellow)
+	literalColor = color.New(color.FgMagenta)
+)
+
+// highlightJSON colors the keys, strings, numbers and literals of valid
+// JSON text. Everything else is copied as is.
+func highlightJSON(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			end := stringEnd(s, i)
+			if strings.HasPrefix(strings.TrimLeft(s[end:], " \t\r\n"), ":") {
+				b.WriteString(keyColor.Sprint(s[i:end]))
+			} else {
+				b.WriteString(stringColor.Sprint(s[i:end]))
+			}
+			i = end
+
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789+-.eE", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString(numberColor.Sprint(s[i:end]))
+			i = end
+
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			b.WriteString(literalColor.Sprint(s[i : i+4]))
+			i += 4
+
+		case strings.HasPrefix(s[i:], "false"):
+			b.WriteString(literalColor.Sprint(s[i : i+5]))
+			i += 5
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// stringEnd returns the index after the closing quote of the string
+// starting at i.
+func stringEnd(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+
+	return len(s)
+}
@@ -0,0 +1,331 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/httperrors"
+	"postman/internal/lib/jq"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+type BodyKind int
+
+const (
+	KindEmpty BodyKind = iota
+	KindJSON
+	KindXML
+	KindHTML
+	KindYAML
+	KindForm
+	KindText
+	KindBinary
+)
+
+// binaryPreview is how many leading bytes of a binary body are dumped.
+const binaryPreview = 64
+
+type Renderer struct {
+	out io.Writer
+	// raw prints bodies as received instead of pretty-printed.
+	raw bool
+}
+
+func New(out io.Writer) *Renderer {
+	return &Renderer{
+		out: out,
+	}
+}
+
+// SetRaw turns pretty-printing and coloring of bodies off or back on.
+func (r *Renderer) SetRaw(raw bool) {
+	r.raw = raw
+}
+
+// Response prints the status line, headers, timing, size and body.
+func (r *Renderer) Response(resp models.Response) {
+	r.Head(resp)
+	r.Body(resp)
+}
+
+// Head prints what Response prints before the body.
+func (r *Renderer) Head(resp models.Response) {
+	r.StatusLine(resp)
+	r.Headers(resp.Headers)
+	fmt.Fprintln(r.out)
+	r.Summary(resp)
+	r.TLS(resp.TLS)
+	fmt.Fprintln(r.out)
+}
+
+// Filter prints the results of a jq filter applied to a JSON body, each
+// pretty-printed and colored, or compact in raw mode. The results before
+// an error are printed too.
+func (r *Renderer) Filter(query *jq.Query, body []byte) error {
+	doc, err := jq.Decode(body)
+	if err != nil {
+		return fmt.Errorf("body is not JSON: %w", err)
+	}
+
+	results, err := query.Run(doc)
+	for _, v := range results {
+		text := string(jq.Marshal(v))
+		if !r.raw {
+			text, _ = formatJSON([]byte(text))
+		}
+		fmt.Fprintln(r.out, text)
+	}
+
+	return err
+}
+
+func (r *Renderer) StatusLine(resp models.Response) {
+	line := resp.Proto + " " + resp.Status
+	switch {
+	case resp.StatusCode >= 500:
+		line = color.RedString(line)
+	case resp.StatusCode >= 400:
+		line = color.YellowString(line)
+	case resp.StatusCode >= 300:
+		line = color.CyanString(line)
+	default:
+		line = color.GreenString(line)
+	}
+	fmt.Fprintln(r.out, line)
+
+	if resp.StatusCode >= 400 {
+		fmt.Fprintln(r.out, httperrors.Description(resp.StatusCode))
+	}
+}
+
+// Headers prints the headers sorted by name, repeated values on separate lines.
+func (r *Renderer) Headers(header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(r.out, "%s: %s\n", color.CyanString(k), v)
+		}
+	}
+}
+
+func (r *Renderer) Summary(resp models.Response) {
+	contentType := resp.Headers.Get("Content-Type")
+	if contentType == "" {
+		contentType = "-"
+	}
+
+	fmt.Fprintf(r.out, "Time: %s  Size: %s  Content-Type: %s\n",
+		resp.Duration.Round(time.Microsecond),
+		FormatSize(len(resp.Body)),
+		contentType,
+	)
+}
+
+// TLS prints the negotiated version and cipher, the client certificate
+// and the peer certificate chain of an https connection.
+func (r *Renderer) TLS(info *models.TLSInfo) {
+	if info == nil {
+		return
+	}
+
+	fmt.Fprintf(r.out, "TLS: %s  Cipher: %s", info.Version, info.CipherSuite)
+	if info.Protocol != "" {
+		fmt.Fprintf(r.out, "  ALPN: %s", info.Protocol)
+	}
+	if info.ServerName != "" {
+		fmt.Fprintf(r.out, "  SNI: %s", info.ServerName)
+	}
+	fmt.Fprintln(r.out)
+	if info.ClientCertificate != "" {
+		fmt.Fprintln(r.out, "Client certificate:", info.ClientCertificate)
+	}
+
+	for i, cert := range info.PeerCertificates {
+		fmt.Fprintf(r.out, "  %d %s\n", i, color.CyanString(cert.Subject))
+		fmt.Fprintf(r.out, "    issuer: %s\n", cert.Issuer)
+		fmt.Fprintf(r.out, "    valid: %s .. %s\n", cert.NotBefore.Format(time.DateOnly), cert.NotAfter.Format(time.DateOnly))
+		if len(cert.DNSNames) > 0 {
+			fmt.Fprintf(r.out, "    names: %s\n", strings.Join(cert.DNSNames, ", "))
+		}
+	}
+}
+
+// Body prints JSON, XML, HTML, YAML and form bodies pretty-printed and
+// colored, other text as is and a summary of binary data. In raw mode
+// and when a body fails to parse it is printed as received.
+func (r *Renderer) Body(resp models.Response) {
+	switch kind := Kind(resp.Headers.Get("Content-Type"), resp.Body); kind {
+	case KindEmpty:
+		fmt.Fprintln(r.out, "<empty body>")
+
+	case KindBinary:
+		fmt.Fprintf(r.out, "<binary body: %s, %s>\n", FormatSize(len(resp.Body)), http.DetectContentType(resp.Body))
+		preview := resp.Body
+		if len(preview) > binaryPreview {
+			preview = preview[:binaryPreview]
+		}
+		fmt.Fprintf(r.out, "% x", preview)
+		if len(resp.Body) > binaryPreview {
+			fmt.Fprint(r.out, " ...")
+		}
+		fmt.Fprintln(r.out)
+
+	default:
+		if !r.raw {
+			if text, err := format(kind, resp.Body); err == nil {
+				fmt.Fprintln(r.out, text)
+				return
+			}
+		}
+		fmt.Fprintln(r.out, string(resp.Body))
+	}
+}
+
+// Assertions prints a PASS/FAIL line per result and a total.
+func (r *Renderer) Assertions(results []models.AssertionResult) {
+	failed := 0
+	for _, res := range results {
+		mark := color.GreenString("PASS")
+		if !res.Passed {
+			mark = color.RedString("FAIL")
+			failed++
+		}
+		fmt.Fprintf(r.out, "%s  %s", mark, assertions.String(res.Assertion))
+		if !res.Passed {
+			fmt.Fprintf(r.out, "  (got %s)", res.Actual)
+		}
+		fmt.Fprintln(r.out)
+	}
+
+	fmt.Fprintf(r.out, "Assertions: %d passed, %d failed\n", len(results)-failed, failed)
+}
+
+// RunResult prints one line per request of a collection run followed
+// by the failed assertions or the transport error.
+func (r *Renderer) RunResult(res models.RunResult) {
+	var mark string
+	switch res.Outcome {
+	case models.OutcomePassed:
+		mark = color.GreenString("PASS ")
+	case models.OutcomeFailed:
+		mark = color.RedString("FAIL ")
+	default:
+		mark = color.RedString("ERROR")
+	}
+
+	fmt.Fprintf(r.out, "%s  ", mark)
+	if res.Iteration > 0 {
+		fmt.Fprintf(r.out, "#%d  ", res.Iteration)
+	}
+	fmt.Fprintf(r.out, "%s  %s %s", res.Path, res.Request.Method, res.Request.URL)
+	if res.Outcome != models.OutcomeErrored {
+		fmt.Fprintf(r.out, "  %d  %d ms", res.StatusCode, res.Duration.Milliseconds())
+	}
+	fmt.Fprintln(r.out)
+
+	for _, line := range res.Logs {
+		fmt.Fprintf(r.out, "       %s %s\n", color.HiBlackString("log:"), line)
+	}
+	if res.Error != "" {
+		fmt.Fprintf(r.out, "       %s\n", res.Error)
+	}
+	for _, a := range res.Assertions {
+		if !a.Passed {
+			fmt.Fprintf(r.out, "       %s  (got %s)\n", assertions.String(a.Assertion), a.Actual)
+		}
+	}
+}
+
+// RunSummary prints the totals of a collection run.
+func (r *Renderer) RunSummary(report models.RunReport) {
+	fmt.Fprintf(r.out, "\n%d requests: %s, %s, %s in %s\n",
+		len(report.Results),
+		color.GreenString("%d passed", report.Count(models.OutcomePassed)),
+		color.RedString("%d failed", report.Count(models.OutcomeFailed)),
+		color.RedString("%d errored", report.Count(models.OutcomeErrored)),
+		report.Duration.Round(time.Millisecond),
+	)
+	if report.Iterations > 0 {
+		failed := report.FailedIterations()
+		fmt.Fprintf(r.out, "%d iterations: %s, %s\n",
+			report.Iterations,
+			color.GreenString("%d passed", report.Iterations-failed),
+			color.RedString("%d failed", failed),
+		)
+	}
+}
+
+// Kind detects how a body should be displayed from its content type,
+// falling back to sniffing the content.
+func Kind(contentType string, body []byte) BodyKind {
+	if len(body) == 0 {
+		return KindEmpty
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		if json.Valid(body) {
+			return KindJSON
+		}
+		return KindText
+	case mediaType == "text/html":
+		return KindHTML
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return KindXML
+	case mediaType == "application/yaml", mediaType == "application/x-yaml",
+		mediaType == "text/yaml", mediaType == "text/x-yaml", strings.HasSuffix(mediaType, "+yaml"):
+		return KindYAML
+	case mediaType == "application/x-www-form-urlencoded":
+		return KindForm
+	case isTextMediaType(mediaType):
+		return KindText
+	}
+
+	if json.Valid(body) {
+		return KindJSON
+	}
+
+	if utf8.Valid(body) && !bytes.ContainsRune(body, 0) {
+		switch sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(body)); sniffed {
+		case "text/html":
+			return KindHTML
+		case "text/xml":
+			return KindXML
+		}
+		return KindText
+	}
+
+	return KindBinary
+}
+
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/javascript"
+}
+
+// FormatSize formats a byte count as B, KiB or MiB.
+func FormatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,124 @@
+package render_test
+
+import (
+	"bytes"
+	"net/http"
+	"postman/internal/domain/models"
+	"postman/internal/lib/httperrors"
+	"postman/internal/render"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func init() {
+	// compare plain text
+	color.NoColor = true
+}
+
+func TestStatusLine(t *testing.T) {
+	tests := []struct {
+		name string
+		resp models.Response
+		want string
+	}{
+		{
+			name: "success",
+			resp: models.Response{Proto: "HTTP/1.1", StatusCode: 200, Status: "200 OK"},
+			want: "HTTP/1.1 200 OK\n",
+		},
+		{
+			name: "redirect",
+			resp: models.Response{Proto: "HTTP/2.0", StatusCode: 302, Status: "302 Found"},
+			want: "HTTP/2.0 302 Found\n",
+		},
+		{
+			name: "client error with a description",
+			resp: models.Response{Proto: "HTTP/1.1", StatusCode: 404, Status: "404 Not Found"},
+			want: "HTTP/1.1 404 Not Found\n" + httperrors.Description(404) + "\n",
+		},
+		{
+			name: "server error with a description",
+			resp: models.Response{Proto: "HTTP/1.1", StatusCode: 503, Status: "503 Service Unavailable"},
+			want: "HTTP/1.1 503 Service Unavailable\n" + httperrors.Description(503) + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			render.New(&buf).StatusLine(tt.resp)
+			if buf.String() != tt.want {
+				t.Errorf("StatusLine() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	render.New(&buf).Headers(http.Header{
+		"X-B":        {"2"},
+		"Set-Cookie": {"a=1", "b=2"},
+		"Accept":     {"*/*"},
+	})
+
+	want := "Accept: */*\nSet-Cookie: a=1\nSet-Cookie: b=2\nX-B: 2\n"
+	if buf.String() != want {
+		t.Errorf("Headers() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		resp models.Response
+		want string
+	}{
+		{
+			name: "with content type",
+			resp: models.Response{
+				Headers:  http.Header{"Content-Type": {"application/json"}},
+				Body:     []byte(`{"a":1}`),
+				Duration: 1234567 * time.Nanosecond,
+			},
+			want: "Time: 1.235ms  Size: 7 B  Content-Type: application/json\n",
+		},
+		{
+			name: "without content type",
+			resp: models.Response{Duration: 2 * time.Second},
+			want: "Time: 2s  Size: 0 B  Content-Type: -\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			render.New(&buf).Summary(tt.resp)
+			if buf.String() != tt.want {
+				t.Errorf("Summary() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1 << 20, "1.0 MiB"},
+		{5*(1<<20) + 1<<19, "5.5 MiB"},
+	}
+
+	for _, tt := range tests {
+		if got := render.FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
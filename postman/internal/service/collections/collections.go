@@ -0,0 +1,363 @@
+package collectionsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"postman/internal/domain/interfaces/storage"
+	"postman/internal/domain/models"
+	serviceerrors "postman/internal/service"
+	storageerrors "postman/internal/storage"
+	"postman/pkg/lib/logger/sl"
+	"strings"
+)
+
+type CollectionsService struct {
+	log     *slog.Logger
+	storage storage.ICollectionsStorage
+}
+
+func New(log *slog.Logger, storage storage.ICollectionsStorage) *CollectionsService {
+	return &CollectionsService{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// GetCollections implements service.ICollectionsService.
+func (c *CollectionsService) GetCollections(ctx context.Context) ([]models.Collection, error) {
+	const op = "services.GetCollections"
+
+	collections, err := c.storage.GetCollections(ctx)
+	if err != nil {
+		c.log.With("op", op).Error("Error fetching collections", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collections, nil
+}
+
+// GetCollection implements service.ICollectionsService.
+func (c *CollectionsService) GetCollection(ctx context.Context, name string) (models.Collection, error) {
+	const op = "services.GetCollection"
+
+	collection, err := c.storage.GetCollection(ctx, name)
+	if err != nil {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return collection, nil
+}
+
+// CreateCollection implements service.ICollectionsService.
+func (c *CollectionsService) CreateCollection(ctx context.Context, name, description string) (models.Collection, error) {
+	const op = "services.CreateCollection"
+
+	if _, err := c.storage.GetCollection(ctx, name); err == nil {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrAlreadyExists)
+	} else if !errors.Is(err, storageerrors.ErrNotFound) {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	collection := models.Collection{
+		Version:     models.CollectionVersion,
+		Name:        name,
+		Description: description,
+	}
+	if err := c.storage.SaveCollection(ctx, collection); err != nil {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return collection, nil
+}
+
+// UpdateCollection replaces a whole collection, creating it if needed.
+func (c *CollectionsService) UpdateCollection(ctx context.Context, collection models.Collection) error {
+	const op = "services.UpdateCollection"
+
+	if err := c.storage.SaveCollection(ctx, collection); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// DeleteCollection implements service.ICollectionsService.
+func (c *CollectionsService) DeleteCollection(ctx context.Context, name string) error {
+	const op = "services.DeleteCollection"
+
+	if err := c.storage.DeleteCollection(ctx, name); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// GetRequest implements service.ICollectionsService.
+// The path has the form "collection/folder/.../request".
+func (c *CollectionsService) GetRequest(ctx context.Context, path string) (models.SavedRequest, error) {
+	const op = "services.GetRequest"
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return models.SavedRequest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection, err := c.storage.GetCollection(ctx, p.Collection)
+	if err != nil {
+		return models.SavedRequest{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	folder, ok := findFolder(rootFolder(&collection), p.Folders, false)
+	if !ok {
+		return models.SavedRequest{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+
+	i := indexRequest(folder.Requests, p.Name)
+	if i < 0 {
+		return models.SavedRequest{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+
+	return folder.Requests[i], nil
+}
+
+// SaveRequest implements service.ICollectionsService.
+// The collection and folders on the path are created when missing and a
+// request with the same name is replaced.
+func (c *CollectionsService) SaveRequest(ctx context.Context, path string, request models.SavedRequest) error {
+	const op = "services.SaveRequest"
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection, err := c.storage.GetCollection(ctx, p.Collection)
+	if err != nil {
+		if !errors.Is(err, storageerrors.ErrNotFound) {
+			return fmt.Errorf("%s: %w", op, mapStorageError(err))
+		}
+		collection = models.Collection{Version: models.CollectionVersion, Name: p.Collection}
+	}
+
+	root := rootFolder(&collection)
+	folder, _ := findFolder(root, p.Folders, true)
+
+	request.Name = p.Name
+	if i := indexRequest(folder.Requests, p.Name); i >= 0 {
+		folder.Requests[i] = request
+	} else {
+		folder.Requests = append(folder.Requests, request)
+	}
+	setRootFolder(&collection, root)
+
+	if err := c.storage.SaveCollection(ctx, collection); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// DeleteRequest implements service.ICollectionsService.
+// When no request has the name, a folder with that name is deleted.
+func (c *CollectionsService) DeleteRequest(ctx context.Context, path string) error {
+	const op = "services.DeleteRequest"
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection, err := c.storage.GetCollection(ctx, p.Collection)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	root := rootFolder(&collection)
+	folder, ok := findFolder(root, p.Folders, false)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+
+	if i := indexRequest(folder.Requests, p.Name); i >= 0 {
+		folder.Requests = append(folder.Requests[:i], folder.Requests[i+1:]...)
+	} else if i := indexFolder(folder.Folders, p.Name); i >= 0 {
+		folder.Folders = append(folder.Folders[:i], folder.Folders[i+1:]...)
+	} else {
+		return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+	setRootFolder(&collection, root)
+
+	if err := c.storage.SaveCollection(ctx, collection); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// SetAuth implements service.ICollectionsService.
+// The path is a collection name, a folder path or a request path; a
+// folder wins over a request with the same name. A nil auth inherits.
+func (c *CollectionsService) SetAuth(ctx context.Context, path string, auth *models.Auth) error {
+	const op = "services.SetAuth"
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	collection, err := c.storage.GetCollection(ctx, parts[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	if len(parts) == 1 {
+		collection.Auth = auth
+	} else {
+		root := rootFolder(&collection)
+		if folder, ok := findFolder(root, parts[1:], false); ok {
+			folder.Auth = auth
+		} else {
+			p, err := ParsePath(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			parent, ok := findFolder(root, p.Folders, false)
+			if !ok {
+				return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+			}
+			i := indexRequest(parent.Requests, p.Name)
+			if i < 0 {
+				return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+			}
+			parent.Requests[i].Auth = auth
+		}
+		setRootFolder(&collection, root)
+	}
+
+	if err := c.storage.SaveCollection(ctx, collection); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// InheritedAuth implements service.ICollectionsService.
+// It returns the auth of the innermost folder on the request path that
+// does not inherit, or the collection auth. The request's own auth is
+// not considered.
+func (c *CollectionsService) InheritedAuth(ctx context.Context, path string) (*models.Auth, error) {
+	const op = "services.InheritedAuth"
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection, err := c.storage.GetCollection(ctx, p.Collection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	inherited := collection.Auth
+	folder := rootFolder(&collection)
+	for _, name := range p.Folders {
+		i := indexFolder(folder.Folders, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+		}
+		folder = &folder.Folders[i]
+		if !folder.Auth.Inherits() {
+			inherited = folder.Auth
+		}
+	}
+
+	if inherited.Inherits() {
+		return nil, nil
+	}
+
+	return inherited, nil
+}
+
+// Path addresses a request (or folder) inside a collection.
+type Path struct {
+	Collection string
+	Folders    []string
+	Name       string
+}
+
+// ParsePath splits "collection/folder/.../name".
+func ParsePath(path string) (Path, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return Path{}, fmt.Errorf("%w: %q, expected collection/[folder/...]name", serviceerrors.ErrInvalidPath, path)
+	}
+
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return Path{}, fmt.Errorf("%w: %q has an empty element", serviceerrors.ErrInvalidPath, path)
+		}
+	}
+
+	return Path{
+		Collection: parts[0],
+		Folders:    parts[1 : len(parts)-1],
+		Name:       parts[len(parts)-1],
+	}, nil
+}
+
+// rootFolder views the top level of a collection as a folder, so the
+// tree walking code does not need a special case for it.
+func rootFolder(collection *models.Collection) *models.Folder {
+	return &models.Folder{
+		Name:     collection.Name,
+		Folders:  collection.Folders,
+		Requests: collection.Requests,
+	}
+}
+
+func setRootFolder(collection *models.Collection, root *models.Folder) {
+	collection.Folders = root.Folders
+	collection.Requests = root.Requests
+}
+
+func findFolder(folder *models.Folder, names []string, create bool) (*models.Folder, bool) {
+	for _, name := range names {
+		i := indexFolder(folder.Folders, name)
+		if i < 0 {
+			if !create {
+				return nil, false
+			}
+			folder.Folders = append(folder.Folders, models.Folder{Name: name})
+			i = len(folder.Folders) - 1
+		}
+		folder = &folder.Folders[i]
+	}
+
+	return folder, true
+}
+
+func indexFolder(folders []models.Folder, name string) int {
+	for i := range folders {
+		if folders[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func indexRequest(requests []models.SavedRequest, name string) int {
+	for i := range requests {
+		if requests[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func mapStorageError(err error) error {
+	if errors.Is(err, storageerrors.ErrNotFound) {
+		return fmt.Errorf("%w: %w", serviceerrors.ErrNotFound, err)
+	}
+
+	return err
+}
@@ -0,0 +1,184 @@
+package cookiesservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/interfaces/storage"
+	"postman/internal/domain/models"
+	serviceerrors "postman/internal/service"
+	"sort"
+	"strings"
+	"time"
+)
+
+type CookiesService struct {
+	log          *slog.Logger
+	storage      storage.ICookiesStorage
+	environments service.IEnvironmentsService
+}
+
+func New(log *slog.Logger, storage storage.ICookiesStorage, environments service.IEnvironmentsService) *CookiesService {
+	return &CookiesService{
+		log:          log,
+		storage:      storage,
+		environments: environments,
+	}
+}
+
+// GetCookies implements service.ICookiesService.
+// Expired cookies are left out, the rest is sorted by domain, path and name.
+func (c *CookiesService) GetCookies(ctx context.Context, environment string) ([]models.Cookie, error) {
+	const op = "services.GetCookies"
+
+	environment, err := c.environment(ctx, environment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cookies, err := c.storage.GetCookies(ctx, environment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	live := make([]models.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if !cookie.Expired(now) {
+			live = append(live, cookie)
+		}
+	}
+	sort.SliceStable(live, func(i, j int) bool {
+		if live[i].Domain != live[j].Domain {
+			return live[i].Domain < live[j].Domain
+		}
+		if live[i].Path != live[j].Path {
+			return live[i].Path < live[j].Path
+		}
+		return live[i].Name < live[j].Name
+	})
+
+	return live, nil
+}
+
+// SaveCookies implements service.ICookiesService.
+func (c *CookiesService) SaveCookies(ctx context.Context, environment string, cookies []models.Cookie) error {
+	const op = "services.SaveCookies"
+
+	environment, err := c.environment(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := c.storage.SaveCookies(ctx, environment, cookies); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetCookie implements service.ICookiesService.
+func (c *CookiesService) SetCookie(ctx context.Context, environment string, cookie models.Cookie) error {
+	const op = "services.SetCookie"
+
+	cookies, err := c.GetCookies(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	replaced := false
+	for i := range cookies {
+		if cookies[i].SameCookie(cookie) {
+			if cookie.Created.IsZero() {
+				cookie.Created = cookies[i].Created
+			}
+			cookies[i] = cookie
+			replaced = true
+			break
+		}
+	}
+	if cookie.Created.IsZero() {
+		cookie.Created = time.Now()
+	}
+	if !replaced {
+		cookies = append(cookies, cookie)
+	}
+
+	if err := c.SaveCookies(ctx, environment, cookies); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteCookie implements service.ICookiesService.
+// Cookies with the name are deleted from every path of the domain.
+func (c *CookiesService) DeleteCookie(ctx context.Context, environment, domain, name string) error {
+	const op = "services.DeleteCookie"
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	deleted, err := c.remove(ctx, environment, func(cookie models.Cookie) bool {
+		return cookie.Domain == domain && cookie.Name == name
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ClearCookies implements service.ICookiesService.
+// Clearing a domain also clears its subdomains.
+func (c *CookiesService) ClearCookies(ctx context.Context, environment, domain string) error {
+	const op = "services.ClearCookies"
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	_, err := c.remove(ctx, environment, func(cookie models.Cookie) bool {
+		return domain == "" || cookie.Domain == domain || strings.HasSuffix(cookie.Domain, "."+domain)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// remove deletes the matching cookies and returns how many were deleted.
+func (c *CookiesService) remove(ctx context.Context, environment string, match func(models.Cookie) bool) (int, error) {
+	cookies, err := c.GetCookies(ctx, environment)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := cookies[:0]
+	for _, cookie := range cookies {
+		if !match(cookie) {
+			kept = append(kept, cookie)
+		}
+	}
+	deleted := len(cookies) - len(kept)
+
+	if err := c.SaveCookies(ctx, environment, kept); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// environment returns the name of the active environment when none is given.
+func (c *CookiesService) environment(ctx context.Context, name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+
+	active, err := c.environments.ActiveEnvironment(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return active.Name, nil
+}
@@ -0,0 +1,198 @@
+package environmentsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"postman/internal/domain/interfaces/storage"
+	"postman/internal/domain/models"
+	serviceerrors "postman/internal/service"
+	storageerrors "postman/internal/storage"
+	"postman/pkg/lib/logger/sl"
+)
+
+type EnvironmentsService struct {
+	log     *slog.Logger
+	storage storage.IEnvironmentsStorage
+}
+
+func New(log *slog.Logger, storage storage.IEnvironmentsStorage) *EnvironmentsService {
+	return &EnvironmentsService{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// GetEnvironments implements service.IEnvironmentsService.
+func (e *EnvironmentsService) GetEnvironments(ctx context.Context) ([]models.Environment, error) {
+	const op = "services.GetEnvironments"
+
+	environments, err := e.storage.GetEnvironments(ctx)
+	if err != nil {
+		e.log.With("op", op).Error("Error fetching environments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return environments, nil
+}
+
+// GetEnvironment implements service.IEnvironmentsService.
+func (e *EnvironmentsService) GetEnvironment(ctx context.Context, name string) (models.Environment, error) {
+	const op = "services.GetEnvironment"
+
+	environment, err := e.storage.GetEnvironment(ctx, name)
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return environment, nil
+}
+
+// CreateEnvironment implements service.IEnvironmentsService.
+func (e *EnvironmentsService) CreateEnvironment(ctx context.Context, name string) (models.Environment, error) {
+	const op = "services.CreateEnvironment"
+
+	if _, err := e.storage.GetEnvironment(ctx, name); err == nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrAlreadyExists)
+	} else if !errors.Is(err, storageerrors.ErrNotFound) {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	environment := models.Environment{
+		Version: models.EnvironmentVersion,
+		Name:    name,
+	}
+	if err := e.storage.SaveEnvironment(ctx, environment); err != nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return environment, nil
+}
+
+// DeleteEnvironment implements service.IEnvironmentsService.
+// Deleting the active environment deactivates it.
+func (e *EnvironmentsService) DeleteEnvironment(ctx context.Context, name string) error {
+	const op = "services.DeleteEnvironment"
+
+	if err := e.storage.DeleteEnvironment(ctx, name); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	active, err := e.storage.GetActiveEnvironment(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if active == name {
+		if err := e.storage.SetActiveEnvironment(ctx, ""); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// SetVariable implements service.IEnvironmentsService.
+// The environment is created when it does not exist yet.
+func (e *EnvironmentsService) SetVariable(ctx context.Context, name string, variable models.Variable) error {
+	const op = "services.SetVariable"
+
+	environment, err := e.storage.GetEnvironment(ctx, name)
+	if err != nil {
+		if !errors.Is(err, storageerrors.ErrNotFound) {
+			return fmt.Errorf("%s: %w", op, mapStorageError(err))
+		}
+		environment = models.Environment{Version: models.EnvironmentVersion, Name: name}
+	}
+
+	replaced := false
+	for i := range environment.Variables {
+		if environment.Variables[i].Key == variable.Key {
+			environment.Variables[i] = variable
+			replaced = true
+		}
+	}
+	if !replaced {
+		environment.Variables = append(environment.Variables, variable)
+	}
+
+	if err := e.storage.SaveEnvironment(ctx, environment); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// UnsetVariable implements service.IEnvironmentsService.
+func (e *EnvironmentsService) UnsetVariable(ctx context.Context, name, key string) error {
+	const op = "services.UnsetVariable"
+
+	environment, err := e.storage.GetEnvironment(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	kept := environment.Variables[:0]
+	for _, v := range environment.Variables {
+		if v.Key != key {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == len(environment.Variables) {
+		return fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+	}
+	environment.Variables = kept
+
+	if err := e.storage.SaveEnvironment(ctx, environment); err != nil {
+		return fmt.Errorf("%s: %w", op, mapStorageError(err))
+	}
+
+	return nil
+}
+
+// UseEnvironment implements service.IEnvironmentsService.
+// An empty name deactivates the current environment.
+func (e *EnvironmentsService) UseEnvironment(ctx context.Context, name string) error {
+	const op = "services.UseEnvironment"
+
+	if name != "" {
+		if _, err := e.storage.GetEnvironment(ctx, name); err != nil {
+			return fmt.Errorf("%s: %w", op, mapStorageError(err))
+		}
+	}
+
+	if err := e.storage.SetActiveEnvironment(ctx, name); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ActiveEnvironment implements service.IEnvironmentsService.
+// Without an active environment it returns an empty environment.
+func (e *EnvironmentsService) ActiveEnvironment(ctx context.Context) (models.Environment, error) {
+	const op = "services.ActiveEnvironment"
+
+	name, err := e.storage.GetActiveEnvironment(ctx)
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if name == "" {
+		return models.Environment{}, nil
+	}
+
+	environment, err := e.storage.GetEnvironment(ctx, name)
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("%s: active environment %q: %w", op, name, mapStorageError(err))
+	}
+
+	return environment, nil
+}
+
+func mapStorageError(err error) error {
+	if errors.Is(err, storageerrors.ErrNotFound) {
+		return fmt.Errorf("%w: %w", serviceerrors.ErrNotFound, err)
+	}
+
+	return err
+}
@@ -0,0 +1,206 @@
+package historyservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/interfaces/storage"
+	"postman/internal/domain/models"
+	"postman/internal/lib/variables"
+	serviceerrors "postman/internal/service"
+	"strings"
+)
+
+type HistoryService struct {
+	log          *slog.Logger
+	storage      storage.IHistoryStorage
+	environments service.IEnvironmentsService
+	// limit is the number of entries kept, bodyLimit the number of
+	// response body bytes kept per entry.
+	limit     int
+	bodyLimit int
+}
+
+func New(log *slog.Logger, storage storage.IHistoryStorage, environments service.IEnvironmentsService, limit, bodyLimit int) *HistoryService {
+	return &HistoryService{
+		log:          log,
+		storage:      storage,
+		environments: environments,
+		limit:        limit,
+		bodyLimit:    bodyLimit,
+	}
+}
+
+// Record implements service.IExchangeRecorder.
+// The entry is appended to the history, which is rewritten without its
+// oldest entries only once it is a tenth over its limit, so that
+// recording does not read the whole history every time.
+// The values of secret variables of the environment are stored as
+// {{name}} placeholders, and an oauth2 token as the oauth2 auth it
+// came from, so sending the entry again resolves them anew.
+func (h *HistoryService) Record(ctx context.Context, exchange models.Exchange, sendErr error) error {
+	const op = "services.RecordHistory"
+
+	first, last, err := h.storage.IDs(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	entry := h.entry(exchange, sendErr)
+	if source := exchange.Source.Auth; source != nil && source.Type == models.AuthOAuth2 {
+		entry.Request.Auth = source
+	}
+	if exchange.Environment != "" {
+		environment, err := h.environments.GetEnvironment(ctx, exchange.Environment)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		entry.Request = variables.Hide(entry.Request, environment.Secrets())
+	}
+	entry.ID = last + 1
+	if err := h.storage.AddEntry(ctx, entry); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if first == 0 {
+		first = entry.ID
+	}
+
+	if h.limit > 0 && entry.ID-first+1 > h.limit+max(h.limit/10, 1) {
+		entries, err := h.storage.GetEntries(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := h.storage.SaveEntries(ctx, h.kept(entries)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// kept drops the entries over the limit, which stay in the file until
+// the history is trimmed.
+func (h *HistoryService) kept(entries []models.HistoryEntry) []models.HistoryEntry {
+	if h.limit > 0 && len(entries) > h.limit {
+		return entries[len(entries)-h.limit:]
+	}
+
+	return entries
+}
+
+func (h *HistoryService) entry(exchange models.Exchange, sendErr error) models.HistoryEntry {
+	resp := exchange.Response
+	entry := models.HistoryEntry{
+		Time:        exchange.StartedAt,
+		Environment: exchange.Environment,
+		Request:     exchange.Request,
+		Source:      exchange.Source,
+		Status:      resp.StatusCode,
+		Duration:    resp.Duration,
+	}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+		return entry
+	}
+
+	snapshot := &models.ResponseSnapshot{
+		Proto:   resp.Proto,
+		Status:  resp.Status,
+		Headers: resp.Headers,
+		Body:    resp.Body,
+		Size:    len(resp.Body),
+	}
+	if h.bodyLimit > 0 && len(resp.Body) > h.bodyLimit {
+		snapshot.Body = resp.Body[:h.bodyLimit]
+		snapshot.Truncated = true
+	}
+	entry.Response = snapshot
+
+	return entry
+}
+
+// GetEntries implements service.IHistoryService.
+func (h *HistoryService) GetEntries(ctx context.Context, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+	const op = "services.GetHistory"
+
+	entries, err := h.storage.GetEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entries = h.kept(entries)
+
+	var matched []models.HistoryEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(matched) == filter.Limit {
+			break
+		}
+		if matches(entries[i], filter) {
+			matched = append(matched, entries[i])
+		}
+	}
+
+	return matched, nil
+}
+
+// GetEntry implements service.IHistoryService.
+func (h *HistoryService) GetEntry(ctx context.Context, id int) (models.HistoryEntry, error) {
+	const op = "services.GetHistoryEntry"
+
+	entries, err := h.storage.GetEntries(ctx)
+	if err != nil {
+		return models.HistoryEntry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, entry := range h.kept(entries) {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+
+	return models.HistoryEntry{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+}
+
+// ClearHistory implements service.IHistoryService.
+func (h *HistoryService) ClearHistory(ctx context.Context) error {
+	const op = "services.ClearHistory"
+
+	if err := h.storage.SaveEntries(ctx, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func matches(entry models.HistoryEntry, filter models.HistoryFilter) bool {
+	if filter.Method != "" && !strings.EqualFold(entry.Request.Method, filter.Method) {
+		return false
+	}
+
+	if filter.Host != "" {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || !strings.Contains(strings.ToLower(u.Hostname()), strings.ToLower(filter.Host)) {
+			return false
+		}
+	}
+
+	if filter.Failed && entry.Error == "" {
+		return false
+	}
+	if filter.StatusMin > 0 && entry.Status < filter.StatusMin {
+		return false
+	}
+	if filter.StatusMax > 0 && (entry.Status == 0 || entry.Status > filter.StatusMax) {
+		return false
+	}
+
+	if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && entry.Time.After(filter.Until) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,402 @@
+package oauth2service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"postman/internal/client"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/interfaces/storage"
+	"postman/internal/domain/models"
+	"postman/pkg/lib/logger/sl"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshBefore is how long before expiry a cached token is renewed.
+	refreshBefore = 30 * time.Second
+	// authorizationTimeout bounds the wait for the browser redirect.
+	authorizationTimeout = 5 * time.Minute
+	callbackPath         = "/callback"
+	maxTokenResponse     = 1 << 20
+)
+
+var (
+	ErrTokenEndpoint = errors.New("token endpoint error")
+	ErrAuthorization = errors.New("authorization failed")
+)
+
+type OAuth2Service struct {
+	log          *slog.Logger
+	storage      storage.ITokensStorage
+	environments service.IEnvironmentsService
+	// client sends the token requests with the transport options of the
+	// request that needs the token.
+	client *client.Client
+	// out receives the authorization URL of the authorization code grant.
+	out io.Writer
+	mu  sync.Mutex
+}
+
+func New(log *slog.Logger, storage storage.ITokensStorage, environments service.IEnvironmentsService, client *client.Client, out io.Writer) *OAuth2Service {
+	return &OAuth2Service{
+		log:          log,
+		storage:      storage,
+		environments: environments,
+		client:       client,
+		out:          out,
+	}
+}
+
+// SetOutput sets where the authorization URL of the authorization code
+// grant is shown, e.g. in the full-screen UI instead of stderr.
+func (o *OAuth2Service) SetOutput(out io.Writer) {
+	o.out = out
+}
+
+// Token implements service.IOAuth2Service.
+// A cached token is used until shortly before it expires, then renewed
+// with its refresh token or, failing that, with the configured grant.
+func (o *OAuth2Service) Token(ctx context.Context, auth models.Auth, options models.Options, environment string) (models.Token, error) {
+	const op = "services.Token"
+	log := o.log.With(
+		"op", op,
+	)
+
+	environment, err := o.environment(ctx, environment)
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	tokens, err := o.storage.GetTokens(ctx, environment)
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := cacheKey(auth)
+	cached, ok := tokens[key]
+	if ok && cached.ValidFor(refreshBefore) {
+		return cached, nil
+	}
+
+	httpClient, err := o.client.HTTPClient(options)
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var token models.Token
+	if ok && cached.RefreshToken != "" {
+		token, err = o.refresh(ctx, httpClient, auth, cached.RefreshToken)
+		if err != nil {
+			log.Warn("Error refreshing token, requesting a new one", sl.Err(err))
+		}
+	}
+	if token.AccessToken == "" {
+		token, err = o.acquire(ctx, httpClient, auth)
+		if err != nil {
+			return models.Token{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	tokens[key] = token
+	if err := o.storage.SaveTokens(ctx, environment, tokens); err != nil {
+		log.Warn("Error caching token", sl.Err(err))
+	}
+
+	return token, nil
+}
+
+// ClearTokens implements service.IOAuth2Service.
+func (o *OAuth2Service) ClearTokens(ctx context.Context, environment string) error {
+	const op = "services.ClearTokens"
+
+	environment, err := o.environment(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.storage.DeleteTokens(ctx, environment); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// environment returns the name of the active environment when none is given.
+func (o *OAuth2Service) environment(ctx context.Context, name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+
+	active, err := o.environments.ActiveEnvironment(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return active.Name, nil
+}
+
+func (o *OAuth2Service) acquire(ctx context.Context, httpClient *http.Client, auth models.Auth) (models.Token, error) {
+	form := url.Values{}
+	switch auth.GrantType {
+	case models.GrantClientCredentials:
+		form.Set("grant_type", "client_credentials")
+	case models.GrantPassword:
+		form.Set("grant_type", "password")
+		form.Set("username", auth.Username)
+		form.Set("password", auth.Password)
+	case models.GrantAuthorizationCode:
+		return o.authorize(ctx, httpClient, auth)
+	default:
+		return models.Token{}, fmt.Errorf("%w: unsupported grant %q", ErrAuthorization, auth.GrantType)
+	}
+	if auth.Scope != "" {
+		form.Set("scope", auth.Scope)
+	}
+
+	return o.requestToken(ctx, httpClient, auth, form)
+}
+
+func (o *OAuth2Service) refresh(ctx context.Context, httpClient *http.Client, auth models.Auth, refreshToken string) (models.Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if auth.Scope != "" {
+		form.Set("scope", auth.Scope)
+	}
+
+	token, err := o.requestToken(ctx, httpClient, auth, form)
+	if err != nil {
+		return models.Token{}, err
+	}
+
+	// The server may keep the old refresh token valid without sending it again.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+// authorize runs the authorization code grant with PKCE: the user opens
+// the printed URL and the browser is redirected to a loopback listener.
+func (o *OAuth2Service) authorize(ctx context.Context, httpClient *http.Client, auth models.Auth) (models.Token, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return models.Token{}, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return models.Token{}, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(auth.RedirectPort)))
+	if err != nil {
+		return models.Token{}, fmt.Errorf("listening for the redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath)
+
+	authURL, err := url.Parse(auth.AuthURL)
+	if err != nil {
+		listener.Close()
+		return models.Token{}, fmt.Errorf("%w: authorization URL: %w", ErrAuthorization, err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", auth.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	if auth.Scope != "" {
+		query.Set("scope", auth.Scope)
+	}
+	authURL.RawQuery = query.Encode()
+
+	type callback struct {
+		code string
+		err  error
+	}
+	result := make(chan callback, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res callback
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("%w: %s %s", ErrAuthorization, q.Get("error"), q.Get("error_description"))
+		case q.Get("state") != state:
+			res.err = fmt.Errorf("%w: state mismatch", ErrAuthorization)
+		case q.Get("code") == "":
+			res.err = fmt.Errorf("%w: no code in the redirect", ErrAuthorization)
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		}
+
+		select {
+		case result <- res:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(o.out, "Open this URL in a browser to authorize:\n%s\nWaiting for the redirect to %s\n", authURL, redirectURI)
+
+	ctx, cancel := context.WithTimeout(ctx, authorizationTimeout)
+	defer cancel()
+
+	var res callback
+	select {
+	case <-ctx.Done():
+		return models.Token{}, fmt.Errorf("%w: waiting for the redirect: %w", ErrAuthorization, ctx.Err())
+	case res = <-result:
+	}
+	if res.err != nil {
+		return models.Token{}, res.err
+	}
+
+	return o.requestToken(ctx, httpClient, auth, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+// tokenResponse is the token endpoint response of RFC 6749 section 5.
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	RefreshToken     string      `json:"refresh_token"`
+	Scope            string      `json:"scope"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+// requestToken posts a grant to the token endpoint. A client with a
+// secret authenticates with basic auth, a public client sends its id in
+// the form.
+func (o *OAuth2Service) requestToken(ctx context.Context, httpClient *http.Client, auth models.Auth, form url.Values) (models.Token, error) {
+	if auth.ClientSecret == "" && auth.ClientID != "" {
+		form.Set("client_id", auth.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%w: %w", ErrTokenEndpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%w: %w", ErrTokenEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponse))
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%w: %w", ErrTokenEndpoint, err)
+	}
+
+	parsed, err := parseTokenResponse(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return models.Token{}, fmt.Errorf("%w: %s: %w", ErrTokenEndpoint, resp.Status, err)
+	}
+	if parsed.Error != "" {
+		return models.Token{}, fmt.Errorf("%w: %s: %s %s", ErrTokenEndpoint, resp.Status, parsed.Error, parsed.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || parsed.AccessToken == "" {
+		return models.Token{}, fmt.Errorf("%w: %s: no access token in the response", ErrTokenEndpoint, resp.Status)
+	}
+
+	token := models.Token{
+		AccessToken:  parsed.AccessToken,
+		TokenType:    parsed.TokenType,
+		RefreshToken: parsed.RefreshToken,
+		Scope:        parsed.Scope,
+	}
+	if seconds, err := parsed.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+
+	return token, nil
+}
+
+// parseTokenResponse accepts JSON and, for servers that ignore Accept,
+// a form encoded body.
+func parseTokenResponse(contentType string, body []byte) (tokenResponse, error) {
+	var parsed tokenResponse
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "text/plain" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return tokenResponse{}, err
+		}
+		parsed.AccessToken = values.Get("access_token")
+		parsed.TokenType = values.Get("token_type")
+		parsed.RefreshToken = values.Get("refresh_token")
+		parsed.Scope = values.Get("scope")
+		parsed.ExpiresIn = json.Number(values.Get("expires_in"))
+		parsed.Error = values.Get("error")
+		parsed.ErrorDescription = values.Get("error_description")
+		return parsed, nil
+	}
+
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return tokenResponse{}, err
+	}
+
+	return parsed, nil
+}
+
+// cacheKey identifies the tokens of one client and grant.
+func cacheKey(auth models.Auth) string {
+	return strings.Join([]string{auth.GrantType, auth.TokenURL, auth.ClientID, auth.Username, auth.Scope}, "|")
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
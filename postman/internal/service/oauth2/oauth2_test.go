@@ -0,0 +1,292 @@
+package oauth2service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	oauth2service "postman/internal/service/oauth2"
+	"postman/internal/storage/jsonfile"
+	"sync"
+	"testing"
+)
+
+const environment = "test"
+
+// tokenEndpoint is a stub token endpoint that records the grants it
+// receives and answers with the next of its responses.
+type tokenEndpoint struct {
+	mu        sync.Mutex
+	requests  []tokenRequest
+	responses []map[string]any
+}
+
+type tokenRequest struct {
+	form              map[string]string
+	user, password    string
+	basicAuth, isForm bool
+}
+
+func (e *tokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := tokenRequest{
+		form:   map[string]string{},
+		isForm: r.Header.Get("Content-Type") == "application/x-www-form-urlencoded",
+	}
+	for key := range r.PostForm {
+		req.form[key] = r.PostForm.Get(key)
+	}
+	req.user, req.password, req.basicAuth = r.BasicAuth()
+	e.requests = append(e.requests, req)
+
+	resp := e.responses[0]
+	if len(e.responses) > 1 {
+		e.responses = e.responses[1:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, ok := resp["error"]; ok {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newService(t *testing.T) *oauth2service.OAuth2Service {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := jsonfile.NewTokensStorage(logger, t.TempDir())
+
+	return oauth2service.New(logger, tokens, nil, client.New(logger, models.Options{}, nil), io.Discard)
+}
+
+func TestTokenGrants(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     models.Auth
+		wantForm map[string]string
+		// wantUser is the client id sent with basic auth, empty when
+		// the client id is sent in the form.
+		wantUser string
+	}{
+		{
+			name: "client credentials",
+			auth: models.Auth{
+				GrantType:    models.GrantClientCredentials,
+				ClientID:     "cli",
+				ClientSecret: "s3cret",
+				Scope:        "read write",
+			},
+			wantForm: map[string]string{"grant_type": "client_credentials", "scope": "read write"},
+			wantUser: "cli",
+		},
+		{
+			name: "password",
+			auth: models.Auth{
+				GrantType: models.GrantPassword,
+				ClientID:  "public",
+				Username:  "alice",
+				Password:  "pa ss",
+			},
+			wantForm: map[string]string{"grant_type": "password", "username": "alice", "password": "pa ss", "client_id": "public"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &tokenEndpoint{responses: []map[string]any{
+				{"access_token": "at-1", "token_type": "Bearer", "expires_in": 3600},
+			}}
+			server := httptest.NewServer(endpoint)
+			defer server.Close()
+
+			auth := tt.auth
+			auth.Type = models.AuthOAuth2
+			auth.TokenURL = server.URL
+
+			o := newService(t)
+			token, err := o.Token(context.Background(), auth, models.Options{}, environment)
+			if err != nil {
+				t.Fatalf("Token() error = %v", err)
+			}
+			if token.AccessToken != "at-1" || token.TokenType != "Bearer" || token.ExpiresAt.IsZero() {
+				t.Errorf("Token() = %+v, want at-1 Bearer with an expiry", token)
+			}
+
+			if len(endpoint.requests) != 1 {
+				t.Fatalf("token requests = %d, want 1", len(endpoint.requests))
+			}
+			req := endpoint.requests[0]
+			if !req.isForm {
+				t.Error("token request is not form encoded")
+			}
+			for key, want := range tt.wantForm {
+				if got := req.form[key]; got != want {
+					t.Errorf("form %s = %q, want %q", key, got, want)
+				}
+			}
+			if len(req.form) != len(tt.wantForm) {
+				t.Errorf("form = %v, want %v", req.form, tt.wantForm)
+			}
+			if req.basicAuth != (tt.wantUser != "") || req.user != tt.wantUser {
+				t.Errorf("basic auth user = %q (%v), want %q", req.user, req.basicAuth, tt.wantUser)
+			}
+			if tt.wantUser != "" && req.password != tt.auth.ClientSecret {
+				t.Errorf("basic auth password = %q, want %q", req.password, tt.auth.ClientSecret)
+			}
+
+			// the cached token is used while it is valid
+			if _, err := o.Token(context.Background(), auth, models.Options{}, environment); err != nil {
+				t.Fatalf("second Token() error = %v", err)
+			}
+			if len(endpoint.requests) != 1 {
+				t.Errorf("token requests = %d after a cached token, want 1", len(endpoint.requests))
+			}
+		})
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	endpoint := &tokenEndpoint{responses: []map[string]any{
+		// expires within refreshBefore, so the next call renews it
+		{"access_token": "at-1", "expires_in": 1, "refresh_token": "rt-1"},
+		// no refresh token: the old one stays valid
+		{"access_token": "at-2", "expires_in": 1},
+		{"access_token": "at-3", "expires_in": 3600},
+	}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	auth := models.Auth{
+		Type:         models.AuthOAuth2,
+		GrantType:    models.GrantClientCredentials,
+		TokenURL:     server.URL,
+		ClientID:     "cli",
+		ClientSecret: "s3cret",
+	}
+	o := newService(t)
+
+	for i, want := range []string{"at-1", "at-2", "at-3"} {
+		token, err := o.Token(context.Background(), auth, models.Options{}, environment)
+		if err != nil {
+			t.Fatalf("Token() #%d error = %v", i+1, err)
+		}
+		if token.AccessToken != want {
+			t.Errorf("Token() #%d = %q, want %q", i+1, token.AccessToken, want)
+		}
+		if token.RefreshToken != "rt-1" && i < 2 {
+			t.Errorf("Token() #%d refresh token = %q, want rt-1", i+1, token.RefreshToken)
+		}
+	}
+
+	wantGrants := []string{"client_credentials", "refresh_token", "refresh_token"}
+	for i, req := range endpoint.requests {
+		if req.form["grant_type"] != wantGrants[i] {
+			t.Errorf("request #%d grant_type = %q, want %q", i+1, req.form["grant_type"], wantGrants[i])
+		}
+		if i > 0 && req.form["refresh_token"] != "rt-1" {
+			t.Errorf("request #%d refresh_token = %q, want rt-1", i+1, req.form["refresh_token"])
+		}
+	}
+	if len(endpoint.requests) != len(wantGrants) {
+		t.Errorf("token requests = %d, want %d", len(endpoint.requests), len(wantGrants))
+	}
+}
+
+func TestTokenRefreshFailureAcquiresNewToken(t *testing.T) {
+	endpoint := &tokenEndpoint{responses: []map[string]any{
+		{"access_token": "at-1", "expires_in": 1, "refresh_token": "rt-1"},
+		{"error": "invalid_grant", "error_description": "refresh token revoked"},
+		{"access_token": "at-2", "expires_in": 3600},
+	}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	auth := models.Auth{
+		Type:      models.AuthOAuth2,
+		GrantType: models.GrantPassword,
+		TokenURL:  server.URL,
+		Username:  "alice",
+		Password:  "secret",
+	}
+	o := newService(t)
+
+	if _, err := o.Token(context.Background(), auth, models.Options{}, environment); err != nil {
+		t.Fatalf("first Token() error = %v", err)
+	}
+	token, err := o.Token(context.Background(), auth, models.Options{}, environment)
+	if err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if token.AccessToken != "at-2" {
+		t.Errorf("Token() = %q, want at-2", token.AccessToken)
+	}
+	if grant := endpoint.requests[2].form["grant_type"]; grant != "password" {
+		t.Errorf("grant after a failed refresh = %q, want password", grant)
+	}
+}
+
+func TestTokenEndpointError(t *testing.T) {
+	endpoint := &tokenEndpoint{responses: []map[string]any{
+		{"error": "invalid_client", "error_description": "unknown client"},
+	}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	auth := models.Auth{
+		Type:      models.AuthOAuth2,
+		GrantType: models.GrantClientCredentials,
+		TokenURL:  server.URL,
+		ClientID:  "cli",
+	}
+
+	_, err := newService(t).Token(context.Background(), auth, models.Options{}, environment)
+	if !errors.Is(err, oauth2service.ErrTokenEndpoint) {
+		t.Fatalf("Token() error = %v, want ErrTokenEndpoint", err)
+	}
+}
+
+// TestTokenTransportOptions checks that the token endpoint is reached with
+// the TLS settings of the request, like the request itself.
+func TestTokenTransportOptions(t *testing.T) {
+	endpoint := &tokenEndpoint{responses: []map[string]any{
+		{"access_token": "at-1"},
+	}}
+	server := httptest.NewUnstartedServer(endpoint)
+	// the failed handshake of the first call is expected
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	auth := models.Auth{
+		Type:      models.AuthOAuth2,
+		GrantType: models.GrantClientCredentials,
+		TokenURL:  server.URL,
+		ClientID:  "cli",
+	}
+	o := newService(t)
+
+	if _, err := o.Token(context.Background(), auth, models.Options{}, environment); err == nil {
+		t.Fatal("Token() with an untrusted certificate succeeded")
+	}
+
+	token, err := o.Token(context.Background(), auth, models.Options{InsecureSkipVerify: true}, environment)
+	if err != nil {
+		t.Fatalf("Token() with InsecureSkipVerify error = %v", err)
+	}
+	if token.AccessToken != "at-1" {
+		t.Errorf("Token() = %q, want at-1", token.AccessToken)
+	}
+}
@@ -0,0 +1,149 @@
+package requestsservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"postman/internal/client"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/cookies"
+	"postman/internal/lib/variables"
+	"postman/pkg/lib/logger/sl"
+	"slices"
+	"time"
+)
+
+type RequestsService struct {
+	log          *slog.Logger
+	client       *client.Client
+	environments service.IEnvironmentsService
+	oauth2       service.IOAuth2Service
+	cookies      service.ICookiesService
+	recorders    []service.IExchangeRecorder
+}
+
+func New(log *slog.Logger, client *client.Client, environments service.IEnvironmentsService, oauth2 service.IOAuth2Service, cookies service.ICookiesService) *RequestsService {
+	return &RequestsService{
+		log:          log,
+		client:       client,
+		environments: environments,
+		oauth2:       oauth2,
+		cookies:      cookies,
+	}
+}
+
+// Send implements service.IRequestsService.
+// On a transport error the returned exchange still holds the resolved request.
+// An oauth2 auth is replaced by a bearer auth with the current token.
+// Cookies come from the jar of the environment and responses update it.
+func (r *RequestsService) Send(ctx context.Context, request models.Request, scope models.Scope) (models.Exchange, error) {
+	const op = "services.Send"
+
+	resolved, unresolved, err := r.Resolve(ctx, request, scope)
+	if err != nil {
+		return models.Exchange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if resolved.Auth != nil && resolved.Auth.Type == models.AuthOAuth2 {
+		token, err := r.oauth2.Token(ctx, *resolved.Auth, resolved.Options, scope.Environment)
+		if err != nil {
+			return models.Exchange{}, fmt.Errorf("%s: %w", op, err)
+		}
+		resolved.Auth = &models.Auth{Type: models.AuthBearer, Token: token.AccessToken}
+	}
+
+	exchange := models.Exchange{
+		StartedAt:   time.Now(),
+		Environment: scope.Environment,
+		Source:      request,
+		Request:     resolved,
+		Unresolved:  unresolved,
+	}
+	if exchange.Environment == "" {
+		if active, err := r.environments.ActiveEnvironment(ctx); err == nil {
+			exchange.Environment = active.Name
+		}
+	}
+
+	stored, err := r.cookies.GetCookies(ctx, scope.Environment)
+	if err != nil {
+		return models.Exchange{}, fmt.Errorf("%s: %w", op, err)
+	}
+	jar := cookies.NewJar(stored)
+
+	resp, err := r.client.Do(ctx, resolved, jar)
+	if jar.Changed() {
+		if err := r.cookies.SaveCookies(ctx, scope.Environment, jar.All()); err != nil {
+			r.log.With("op", op).Warn("Error saving cookies", sl.Err(err))
+		}
+	}
+	exchange.Response = resp
+	r.record(ctx, exchange, err)
+	if err != nil {
+		return exchange, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exchange, nil
+}
+
+// AddRecorder implements service.IRequestsService.
+func (r *RequestsService) AddRecorder(recorder service.IExchangeRecorder) {
+	r.recorders = append(r.recorders, recorder)
+}
+
+// RemoveRecorder implements service.IRequestsService.
+func (r *RequestsService) RemoveRecorder(recorder service.IExchangeRecorder) {
+	if i := slices.Index(r.recorders, recorder); i >= 0 {
+		r.recorders = slices.Delete(r.recorders, i, i+1)
+	}
+}
+
+// record notifies the recorders. A failing recorder is logged and does
+// not fail the request.
+func (r *RequestsService) record(ctx context.Context, exchange models.Exchange, sendErr error) {
+	const op = "services.record"
+
+	for _, recorder := range r.recorders {
+		if err := recorder.Record(ctx, exchange, sendErr); err != nil {
+			r.log.With("op", op).Warn("Error recording exchange", sl.Err(err))
+		}
+	}
+}
+
+// Resolve implements service.IRequestsService.
+// It substitutes {{var}} placeholders using the scope variables and the
+// scope or active environment, and returns the names left unresolved.
+func (r *RequestsService) Resolve(ctx context.Context, request models.Request, scope models.Scope) (models.Request, []string, error) {
+	const op = "services.Resolve"
+
+	vars, err := r.Variables(ctx, scope)
+	if err != nil {
+		return models.Request{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resolved, unresolved := variables.Apply(request, vars)
+	resolved.Method = client.NormalizeMethod(resolved.Method)
+
+	return resolved, unresolved, nil
+}
+
+// Variables implements service.IRequestsService.
+func (r *RequestsService) Variables(ctx context.Context, scope models.Scope) (map[string]string, error) {
+	const op = "services.Variables"
+
+	var (
+		environment models.Environment
+		err         error
+	)
+	if scope.Environment != "" {
+		environment, err = r.environments.GetEnvironment(ctx, scope.Environment)
+	} else {
+		environment, err = r.environments.ActiveEnvironment(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variables.Merge(scope.Defaults, environment.Map(), scope.Variables), nil
+}
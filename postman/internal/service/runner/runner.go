@@ -0,0 +1,259 @@
+package runnerservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/extract"
+	"postman/internal/lib/variables"
+	serviceerrors "postman/internal/service"
+	"strings"
+	"time"
+)
+
+// defaultAssertion is checked for requests without assertions of their own.
+var defaultAssertion = models.Assertion{Subject: models.AssertStatus, Op: "in", Value: "100..399"}
+
+type RunnerService struct {
+	log         *slog.Logger
+	requests    service.IRequestsService
+	collections service.ICollectionsService
+	scripts     service.IScriptsService
+}
+
+func New(
+	log *slog.Logger,
+	requests service.IRequestsService,
+	collections service.ICollectionsService,
+	scripts service.IScriptsService,
+) *RunnerService {
+	return &RunnerService{
+		log:         log,
+		requests:    requests,
+		collections: collections,
+		scripts:     scripts,
+	}
+}
+
+// Run implements service.IRunnerService.
+// The path is a collection name, a folder path or a single request path.
+// Within a folder its requests run first, then its subfolders, in the
+// order they are stored. The collection variables are the scope defaults.
+// Data row values override the environment, scope.Variables override
+// the data. Variables set by extractors and scripts are visible to the
+// later requests of the same iteration.
+func (r *RunnerService) Run(ctx context.Context, path string, scope models.Scope, data []map[string]string, progress func(models.RunResult)) (models.RunReport, error) {
+	const op = "services.Run"
+
+	collection, items, err := r.items(ctx, path)
+	if err != nil {
+		return models.RunReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if scope.Defaults == nil {
+		scope.Defaults = collection.VariablesMap()
+	}
+	if collection.Options != nil {
+		for i := range items {
+			items[i].request.Options = items[i].request.Options.Inherit(*collection.Options)
+		}
+	}
+
+	report := models.RunReport{
+		Name:       strings.Trim(path, "/"),
+		StartedAt:  time.Now(),
+		Iterations: len(data),
+	}
+
+	rows := data
+	if len(rows) == 0 {
+		rows = []map[string]string{nil}
+	}
+	for i, row := range rows {
+		iteration := scope
+		iteration.Variables = variables.Merge(row, scope.Variables)
+
+		for _, it := range items {
+			if err := ctx.Err(); err != nil {
+				return report, fmt.Errorf("%s: %w", op, err)
+			}
+
+			result := r.runRequest(ctx, it, iteration)
+			if len(data) > 0 {
+				result.Iteration = i + 1
+			}
+			report.Results = append(report.Results, result)
+			if progress != nil {
+				progress(result)
+			}
+		}
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	return report, nil
+}
+
+type item struct {
+	path    string
+	request models.SavedRequest
+}
+
+func (r *RunnerService) runRequest(ctx context.Context, it item, scope models.Scope) models.RunResult {
+	result := models.RunResult{Path: it.path}
+
+	request := it.request.Request
+	if it.request.PreRequest != "" {
+		res, err := r.scripts.PreRequest(ctx, it.path+" pre-request", it.request.PreRequest, &request, scope)
+		result.Logs = append(result.Logs, res.Logs...)
+		mergeInto(scope.Variables, res.Variables)
+		if err != nil {
+			result.Request = request
+			result.Error = err.Error()
+			result.Outcome = models.OutcomeErrored
+			return result
+		}
+	}
+
+	exchange, err := r.requests.Send(ctx, request, scope)
+	result.Request = exchange.Request
+	if err != nil {
+		result.Error = err.Error()
+		result.Outcome = models.OutcomeErrored
+		return result
+	}
+
+	result.StatusCode = exchange.Response.StatusCode
+	result.Duration = exchange.Response.Duration
+
+	checks := it.request.Assertions
+	if len(checks) == 0 && it.request.PostResponse == "" {
+		checks = []models.Assertion{defaultAssertion}
+	}
+	vars, err := r.requests.Variables(ctx, scope)
+	if err != nil {
+		result.Error = err.Error()
+		result.Outcome = models.OutcomeErrored
+		return result
+	}
+	result.Assertions = assertions.Check(checks, exchange.Response, vars)
+
+	extracted, failures := extract.Apply(it.request.Extractors, exchange.Response)
+	result.Assertions = append(result.Assertions, failures...)
+	for _, e := range it.request.Extractors {
+		if value, ok := extracted[e.Variable]; ok {
+			result.Logs = append(result.Logs, e.Variable+" = "+value)
+		}
+	}
+	mergeInto(scope.Variables, extracted)
+
+	if it.request.PostResponse != "" {
+		res, err := r.scripts.PostResponse(ctx, it.path+" post-response", it.request.PostResponse, exchange, scope)
+		result.Logs = append(result.Logs, res.Logs...)
+		result.Assertions = append(result.Assertions, res.Tests...)
+		mergeInto(scope.Variables, res.Variables)
+		if err != nil {
+			result.Error = err.Error()
+			result.Outcome = models.OutcomeErrored
+			return result
+		}
+	}
+
+	result.Outcome = models.OutcomePassed
+	if !assertions.Passed(result.Assertions) {
+		result.Outcome = models.OutcomeFailed
+	}
+
+	return result
+}
+
+func mergeInto(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// items lists the saved requests under path in run order.
+func (r *RunnerService) items(ctx context.Context, path string) (models.Collection, []item, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	collection, err := r.collections.GetCollection(ctx, parts[0])
+	if err != nil {
+		return models.Collection{}, nil, err
+	}
+
+	folder := models.Folder{
+		Name:     collection.Name,
+		Auth:     collection.Auth,
+		Folders:  collection.Folders,
+		Requests: collection.Requests,
+	}
+	prefix := collection.Name
+	for i, name := range parts[1:] {
+		next, ok := subfolder(folder, name)
+		if ok {
+			if next.Auth.Inherits() {
+				next.Auth = folder.Auth
+			}
+			folder = next
+			prefix += "/" + name
+			continue
+		}
+
+		// the last element may name a single request
+		if i == len(parts)-2 {
+			for _, request := range folder.Requests {
+				if request.Name == name {
+					return collection, []item{{path: prefix + "/" + name, request: withAuth(request, folder.Auth)}}, nil
+				}
+			}
+		}
+
+		return models.Collection{}, nil, fmt.Errorf("%w: %q", serviceerrors.ErrNotFound, path)
+	}
+
+	list := walk(prefix, folder, nil)
+	if len(list) == 0 {
+		return models.Collection{}, nil, fmt.Errorf("%w: no requests in %q", serviceerrors.ErrNotFound, path)
+	}
+
+	return collection, list, nil
+}
+
+// walk lists the requests of the folder and its subfolders, resolving
+// inherited auth on the way down.
+func walk(prefix string, folder models.Folder, list []item) []item {
+	for _, request := range folder.Requests {
+		list = append(list, item{path: prefix + "/" + request.Name, request: withAuth(request, folder.Auth)})
+	}
+	for _, f := range folder.Folders {
+		if f.Auth.Inherits() {
+			f.Auth = folder.Auth
+		}
+		list = walk(prefix+"/"+f.Name, f, list)
+	}
+
+	return list
+}
+
+func withAuth(request models.SavedRequest, inherited *models.Auth) models.SavedRequest {
+	if request.Auth.Inherits() {
+		request.Auth = inherited
+	}
+	if request.Auth != nil && request.Auth.Type == models.AuthNone {
+		request.Auth = nil
+	}
+
+	return request
+}
+
+func subfolder(folder models.Folder, name string) (models.Folder, bool) {
+	for _, f := range folder.Folders {
+		if f.Name == name {
+			return f, true
+		}
+	}
+
+	return models.Folder{}, false
+}
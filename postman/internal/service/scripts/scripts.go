@@ -0,0 +1,113 @@
+package scriptsservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/script"
+	"postman/pkg/lib/logger/sl"
+)
+
+type ScriptsService struct {
+	log          *slog.Logger
+	requests     service.IRequestsService
+	environments service.IEnvironmentsService
+}
+
+func New(log *slog.Logger, requests service.IRequestsService, environments service.IEnvironmentsService) *ScriptsService {
+	return &ScriptsService{
+		log:          log,
+		requests:     requests,
+		environments: environments,
+	}
+}
+
+// PreRequest implements service.IScriptsService.
+func (s *ScriptsService) PreRequest(ctx context.Context, name, src string, request *models.Request, scope models.Scope) (models.ScriptResult, error) {
+	const op = "services.PreRequest"
+
+	vars, err := s.requests.Variables(ctx, scope)
+	if err != nil {
+		return models.ScriptResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := script.PreRequest(ctx, name, src, request, vars)
+	s.saveEnvironment(ctx, scope, &result)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// PostResponse implements service.IScriptsService.
+func (s *ScriptsService) PostResponse(ctx context.Context, name, src string, exchange models.Exchange, scope models.Scope) (models.ScriptResult, error) {
+	const op = "services.PostResponse"
+
+	vars, err := s.requests.Variables(ctx, scope)
+	if err != nil {
+		return models.ScriptResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := script.PostResponse(ctx, name, src, exchange, vars)
+	s.saveEnvironment(ctx, scope, &result)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// saveEnvironment stores the env.set values in the scope environment or
+// the active one. Without an environment they are kept as run variables.
+// They are also copied to the run variables, so they win over values
+// given on the command line for the rest of the run.
+func (s *ScriptsService) saveEnvironment(ctx context.Context, scope models.Scope, result *models.ScriptResult) {
+	const op = "services.saveEnvironment"
+
+	if len(result.Environment) == 0 {
+		return
+	}
+
+	name := scope.Environment
+	if name == "" {
+		active, err := s.environments.ActiveEnvironment(ctx)
+		if err != nil {
+			s.log.With("op", op).Warn("Error getting active environment", sl.Err(err))
+		}
+		name = active.Name
+	}
+
+	// existing variables keep their other fields, e.g. Secret
+	existing := map[string]models.Variable{}
+	if name != "" {
+		environment, err := s.environments.GetEnvironment(ctx, name)
+		if err != nil {
+			s.log.With("op", op).Warn("Error getting environment", sl.Err(err))
+		}
+		for _, v := range environment.Variables {
+			existing[v.Key] = v
+		}
+	}
+
+	for key, value := range result.Environment {
+		result.Variables[key] = value
+		if name == "" {
+			continue
+		}
+		variable, ok := existing[key]
+		if !ok {
+			variable = models.Variable{Key: key}
+		}
+		variable.Value = value
+		if err := s.environments.SetVariable(ctx, name, variable); err != nil {
+			s.log.With("op", op).Warn("Error saving environment variable", sl.Err(err))
+		}
+	}
+
+	if name == "" {
+		result.Logs = append(result.Logs, "no active environment, env.set values are kept for this run only")
+	}
+}
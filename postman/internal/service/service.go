@@ -0,0 +1,9 @@
+package serviceerrors
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("resource not found")
+	ErrAlreadyExists = errors.New("resource already exists")
+	ErrInvalidPath   = errors.New("invalid path")
+)
@@ -0,0 +1,154 @@
+package jsonfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+	storageerrors "postman/internal/storage"
+	"postman/pkg/lib/logger/sl"
+	"sort"
+	"strings"
+)
+
+// CollectionsStorage keeps every collection in its own JSON file.
+type CollectionsStorage struct {
+	log *slog.Logger
+	dir string
+}
+
+func NewCollectionsStorage(log *slog.Logger, dir string) *CollectionsStorage {
+	return &CollectionsStorage{
+		log: log,
+		dir: dir,
+	}
+}
+
+// GetCollections implements storage.ICollectionsStorage.
+func (c *CollectionsStorage) GetCollections(ctx context.Context) ([]models.Collection, error) {
+	const op = "storage.GetCollections"
+	log := c.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	collections := make([]models.Collection, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		collection, err := c.read(filepath.Join(c.dir, e.Name()))
+		if err != nil {
+			log.Warn("Skipping unreadable collection", "file", e.Name(), sl.Err(err))
+			continue
+		}
+		collections = append(collections, collection)
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].Name < collections[j].Name
+	})
+
+	return collections, nil
+}
+
+// GetCollection implements storage.ICollectionsStorage.
+func (c *CollectionsStorage) GetCollection(ctx context.Context, name string) (models.Collection, error) {
+	const op = "storage.GetCollection"
+
+	select {
+	case <-ctx.Done():
+		return models.Collection{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(name)
+	if err != nil {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection, err := c.read(filepath.Join(c.dir, file))
+	if err != nil {
+		return models.Collection{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collection, nil
+}
+
+// SaveCollection implements storage.ICollectionsStorage.
+func (c *CollectionsStorage) SaveCollection(ctx context.Context, collection models.Collection) error {
+	const op = "storage.SaveCollection"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(collection.Name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	collection.Version = models.CollectionVersion
+	if err := writeJSON(filepath.Join(c.dir, file), collection); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteCollection implements storage.ICollectionsStorage.
+func (c *CollectionsStorage) DeleteCollection(ctx context.Context, name string) error {
+	const op = "storage.DeleteCollection"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(filepath.Join(c.dir, file)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *CollectionsStorage) read(path string) (models.Collection, error) {
+	var collection models.Collection
+	if err := readJSON(path, &collection); err != nil {
+		return models.Collection{}, err
+	}
+
+	if collection.Version < 1 || collection.Version > models.CollectionVersion {
+		return models.Collection{}, fmt.Errorf("%w: %d", storageerrors.ErrUnsupportedVersion, collection.Version)
+	}
+
+	return collection, nil
+}
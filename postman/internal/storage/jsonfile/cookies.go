@@ -0,0 +1,71 @@
+package jsonfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"postman/internal/domain/models"
+	storageerrors "postman/internal/storage"
+)
+
+// CookiesStorage keeps the cookie jar of every environment in its
+// own JSON file.
+type CookiesStorage struct {
+	log *slog.Logger
+	dir string
+}
+
+func NewCookiesStorage(log *slog.Logger, dir string) *CookiesStorage {
+	return &CookiesStorage{
+		log: log,
+		dir: dir,
+	}
+}
+
+// GetCookies implements storage.ICookiesStorage.
+// It returns no cookies when the jar was never saved.
+func (c *CookiesStorage) GetCookies(ctx context.Context, environment string) ([]models.Cookie, error) {
+	const op = "storage.GetCookies"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := environmentFileName(environment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var cookies []models.Cookie
+	if err := readJSON(filepath.Join(c.dir, file), &cookies); err != nil && !errors.Is(err, storageerrors.ErrNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cookies, nil
+}
+
+// SaveCookies implements storage.ICookiesStorage.
+func (c *CookiesStorage) SaveCookies(ctx context.Context, environment string, cookies []models.Cookie) error {
+	const op = "storage.SaveCookies"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := environmentFileName(environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := writeJSON(filepath.Join(c.dir, file), cookies); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
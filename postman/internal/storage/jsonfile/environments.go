@@ -0,0 +1,208 @@
+package jsonfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+	storageerrors "postman/internal/storage"
+	"postman/pkg/lib/logger/sl"
+	"sort"
+	"strings"
+)
+
+// activeFile holds the name of the active environment.
+const activeFile = ".active"
+
+// EnvironmentsStorage keeps every environment in its own JSON file.
+type EnvironmentsStorage struct {
+	log *slog.Logger
+	dir string
+}
+
+func NewEnvironmentsStorage(log *slog.Logger, dir string) *EnvironmentsStorage {
+	return &EnvironmentsStorage{
+		log: log,
+		dir: dir,
+	}
+}
+
+// GetEnvironments implements storage.IEnvironmentsStorage.
+func (e *EnvironmentsStorage) GetEnvironments(ctx context.Context) ([]models.Environment, error) {
+	const op = "storage.GetEnvironments"
+	log := e.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	environments := make([]models.Environment, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		environment, err := e.read(filepath.Join(e.dir, entry.Name()))
+		if err != nil {
+			log.Warn("Skipping unreadable environment", "file", entry.Name(), sl.Err(err))
+			continue
+		}
+		environments = append(environments, environment)
+	}
+
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+
+	return environments, nil
+}
+
+// GetEnvironment implements storage.IEnvironmentsStorage.
+func (e *EnvironmentsStorage) GetEnvironment(ctx context.Context, name string) (models.Environment, error) {
+	const op = "storage.GetEnvironment"
+
+	select {
+	case <-ctx.Done():
+		return models.Environment{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(name)
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	environment, err := e.read(filepath.Join(e.dir, file))
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return environment, nil
+}
+
+// SaveEnvironment implements storage.IEnvironmentsStorage.
+func (e *EnvironmentsStorage) SaveEnvironment(ctx context.Context, environment models.Environment) error {
+	const op = "storage.SaveEnvironment"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(environment.Name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	environment.Version = models.EnvironmentVersion
+	if err := writeJSON(filepath.Join(e.dir, file), environment); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteEnvironment implements storage.IEnvironmentsStorage.
+func (e *EnvironmentsStorage) DeleteEnvironment(ctx context.Context, name string) error {
+	const op = "storage.DeleteEnvironment"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := fileName(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(filepath.Join(e.dir, file)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetActiveEnvironment implements storage.IEnvironmentsStorage.
+// It returns an empty name when no environment is active.
+func (e *EnvironmentsStorage) GetActiveEnvironment(ctx context.Context) (string, error) {
+	const op = "storage.GetActiveEnvironment"
+
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	b, err := os.ReadFile(filepath.Join(e.dir, activeFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SetActiveEnvironment implements storage.IEnvironmentsStorage.
+// An empty name deactivates the current environment.
+func (e *EnvironmentsStorage) SetActiveEnvironment(ctx context.Context, name string) error {
+	const op = "storage.SetActiveEnvironment"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	path := filepath.Join(e.dir, activeFile)
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(e.dir, 0o700); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0o600); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (e *EnvironmentsStorage) read(path string) (models.Environment, error) {
+	var environment models.Environment
+	if err := readJSON(path, &environment); err != nil {
+		return models.Environment{}, err
+	}
+
+	if environment.Version < 1 || environment.Version > models.EnvironmentVersion {
+		return models.Environment{}, fmt.Errorf("%w: %d", storageerrors.ErrUnsupportedVersion, environment.Version)
+	}
+
+	return environment, nil
+}
@@ -0,0 +1,224 @@
+package jsonfile
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+)
+
+// HistoryStorage keeps the history in a JSON Lines file, one entry per
+// line, so that recording a request appends to the file. The file may
+// hold secrets from resolved variables and is readable by the owner only.
+type HistoryStorage struct {
+	log  *slog.Logger
+	path string
+}
+
+func NewHistoryStorage(log *slog.Logger, path string) *HistoryStorage {
+	return &HistoryStorage{
+		log:  log,
+		path: path,
+	}
+}
+
+// GetEntries implements storage.IHistoryStorage.
+// Unreadable lines, e.g. from an interrupted write, are logged and skipped.
+func (h *HistoryStorage) GetEntries(ctx context.Context) ([]models.HistoryEntry, error) {
+	const op = "storage.GetEntries"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	b, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var entries []models.HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, len(b)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry models.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			h.log.With("op", op).Warn("Skipping unreadable history entry", "line", line)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// IDs implements storage.IHistoryStorage.
+// The first entry is read from the start of the file and the last one
+// from its end, skipping unreadable lines like GetEntries.
+func (h *HistoryStorage) IDs(ctx context.Context) (int, int, error) {
+	const op = "storage.IDs"
+
+	select {
+	case <-ctx.Done():
+		return 0, 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	first, err := firstID(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if first == 0 {
+		return 0, 0, nil
+	}
+	last, err := lastID(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return first, last, nil
+}
+
+// entryID returns the ID of a history line, zero when it is unreadable.
+func entryID(line []byte) int {
+	var entry struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return 0
+	}
+
+	return entry.ID
+}
+
+func firstID(f *os.File) (int, error) {
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if id := entryID(line); id != 0 {
+			return id, nil
+		}
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// lastID reads the file backwards in chunks until it holds a whole
+// readable line.
+func lastID(f *os.File) (int, error) {
+	const chunk = 4096
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var tail []byte
+	for end := info.Size(); end > 0; {
+		start := max(end-chunk, 0)
+		buf := make([]byte, end-start)
+		if _, err := f.ReadAt(buf, start); err != nil {
+			return 0, err
+		}
+		tail = append(buf, tail...)
+		end = start
+
+		lines := bytes.Split(tail, []byte("\n"))
+		if start > 0 {
+			// the first line may be cut
+			lines = lines[1:]
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			if id := entryID(lines[i]); id != 0 {
+				return id, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// AddEntry implements storage.IHistoryStorage.
+func (h *HistoryStorage) AddEntry(ctx context.Context, entry models.HistoryEntry) error {
+	const op = "storage.AddEntry"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = f.Write(append(b, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveEntries implements storage.IHistoryStorage.
+func (h *HistoryStorage) SaveEntries(ctx context.Context, entries []models.HistoryEntry) error {
+	const op = "storage.SaveEntries"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		buf.Write(append(b, '\n'))
+	}
+
+	if err := writeFile(h.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package jsonfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	storageerrors "postman/internal/storage"
+	"strings"
+)
+
+// fileName validates a user supplied name and returns the file name for it.
+func fileName(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w: %q", storageerrors.ErrInvalidName, name)
+	}
+
+	return name + ".json", nil
+}
+
+func readJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return storageerrors.ErrNotFound
+		}
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// writeJSON writes v to a temporary file and renames it over path, so a
+// crash never leaves a half written file behind.
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, append(b, '\n'))
+}
+
+// writeFile replaces the file at path atomically, see writeJSON.
+func writeFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,108 @@
+package jsonfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"postman/internal/domain/models"
+	storageerrors "postman/internal/storage"
+)
+
+// noEnvironment names the file for data kept without an environment. It
+// starts with a dot, so it is never listed as an environment.
+const noEnvironment = ".none"
+
+// TokensStorage keeps the OAuth 2.0 tokens of every environment in its
+// own JSON file.
+type TokensStorage struct {
+	log *slog.Logger
+	dir string
+}
+
+func NewTokensStorage(log *slog.Logger, dir string) *TokensStorage {
+	return &TokensStorage{
+		log: log,
+		dir: dir,
+	}
+}
+
+// GetTokens implements storage.ITokensStorage.
+// It returns an empty map when nothing is cached.
+func (t *TokensStorage) GetTokens(ctx context.Context, environment string) (map[string]models.Token, error) {
+	const op = "storage.GetTokens"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := environmentFileName(environment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens := map[string]models.Token{}
+	if err := readJSON(filepath.Join(t.dir, file), &tokens); err != nil && !errors.Is(err, storageerrors.ErrNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// SaveTokens implements storage.ITokensStorage.
+func (t *TokensStorage) SaveTokens(ctx context.Context, environment string, tokens map[string]models.Token) error {
+	const op = "storage.SaveTokens"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := environmentFileName(environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := writeJSON(filepath.Join(t.dir, file), tokens); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteTokens implements storage.ITokensStorage.
+func (t *TokensStorage) DeleteTokens(ctx context.Context, environment string) error {
+	const op = "storage.DeleteTokens"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	file, err := environmentFileName(environment)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(filepath.Join(t.dir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// environmentFileName is fileName for per environment data, with the
+// empty name standing for no environment.
+func environmentFileName(environment string) (string, error) {
+	if environment == "" {
+		environment = noEnvironment
+	}
+
+	return fileName(environment)
+}
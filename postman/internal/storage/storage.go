@@ -0,0 +1,10 @@
+package storageerrors
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("resource not found")
+	ErrAlreadyExists      = errors.New("resource already exists")
+	ErrInvalidName        = errors.New("invalid name")
+	ErrUnsupportedVersion = errors.New("unsupported file version")
+)
@@ -0,0 +1,500 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/auth"
+	"postman/internal/lib/bodies"
+	"postman/internal/lib/headers"
+	"postman/internal/lib/pathparams"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var tabs = []string{"Params", "Headers", "Body", "Auth"}
+
+// bodyModes are the options of the body mode list, raw first.
+var bodyModes = []string{models.BodyRaw, models.BodyURLEncoded, models.BodyFormData, models.BodyBinary}
+
+var bodyPlaceholders = map[string]string{
+	models.BodyRaw:        "JSON, XML or text, {{variables}} are substituted",
+	models.BodyURLEncoded: "key=value per line, # in front disables a field",
+	models.BodyFormData:   bodies.Syntax + " per line, # in front disables a field",
+	models.BodyBinary:     "path of the file to send",
+}
+
+// authFields are the fields shown for each auth type, in order.
+var authFields = map[string][]string{
+	models.AuthBasic:  {"username", "password"},
+	models.AuthDigest: {"username", "password"},
+	models.AuthBearer: {"token"},
+	models.AuthAPIKey: {"key", "value", "in"},
+	models.AuthOAuth2: {"grant_type", "token_url", "auth_url", "client_id", "client_secret", "scope", "username", "password", "redirect_port"},
+}
+
+// editor edits a request as text: query and path parameters, headers,
+// form fields and auth fields are one per line.
+type editor struct {
+	root        *tview.Flex
+	method      *tview.InputField
+	url         *tview.InputField
+	tabBar      *tview.TextView
+	pages       *tview.Pages
+	params      *tview.TextArea
+	headers     *tview.TextArea
+	bodyMode    *tview.DropDown
+	contentType *tview.InputField
+	body        *tview.TextArea
+	authType    *tview.DropDown
+	auth        *tview.TextArea
+	tab         int
+
+	// loading is set while SetRequest fills the fields, so that the
+	// list handlers don't rewrite them.
+	loading bool
+}
+
+func newEditor() *editor {
+	e := &editor{
+		method:      tview.NewInputField(),
+		url:         tview.NewInputField(),
+		tabBar:      tview.NewTextView(),
+		pages:       tview.NewPages(),
+		params:      textArea("key=value per line, # in front disables a parameter, :name=value sets a path parameter"),
+		headers:     textArea("Key: Value per line"),
+		bodyMode:    tview.NewDropDown(),
+		contentType: tview.NewInputField(),
+		body:        textArea(bodyPlaceholders[models.BodyRaw]),
+		authType:    tview.NewDropDown(),
+		auth:        textArea("the request uses the auth of its folder or collection"),
+	}
+
+	e.method.SetFieldWidth(9).
+		SetAutocompleteFunc(completeMethod)
+	e.url.SetLabel(" ").
+		SetPlaceholder("https://example.com/api/{{version}}/users/:id").
+		SetBlurFunc(e.syncURL)
+
+	e.tabBar.SetRegions(true).
+		SetDynamicColors(true).
+		SetWrap(false)
+	for i, name := range tabs {
+		fmt.Fprintf(e.tabBar, `["%d"] %s [""] `, i, name)
+	}
+
+	e.bodyMode.SetLabel("Mode ").
+		SetOptions(bodyModes, func(mode string, _ int) {
+			e.body.SetPlaceholder(bodyPlaceholders[mode])
+		}).
+		SetCurrentOption(0)
+	e.contentType.SetLabel("  Content-Type ").
+		SetPlaceholder("application/json")
+	bodyBar := tview.NewFlex().
+		AddItem(e.bodyMode, 18, 0, false).
+		AddItem(e.contentType, 0, 1, false)
+	bodyPage := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(bodyBar, 1, 0, false).
+		AddItem(e.body, 0, 1, true)
+
+	e.authType.SetLabel("Type ").
+		SetOptions(auth.Types, func(authType string, _ int) {
+			if !e.loading {
+				e.auth.SetText(authTemplate(authType, authValues(e.auth.GetText())), false)
+			}
+		})
+	authPage := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(e.authType, 1, 0, false).
+		AddItem(e.auth, 0, 1, true)
+
+	e.pages.AddPage(tabs[0], e.params, true, true).
+		AddPage(tabs[1], e.headers, true, false).
+		AddPage(tabs[2], bodyPage, true, false).
+		AddPage(tabs[3], authPage, true, false)
+
+	top := tview.NewFlex().
+		AddItem(e.method, 9, 0, false).
+		AddItem(e.url, 0, 1, true)
+	e.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(top, 1, 0, true).
+		AddItem(e.tabBar, 1, 0, false).
+		AddItem(e.pages, 0, 1, false)
+
+	e.selectTab(0)
+	e.SetRequest(models.Request{Method: "GET"})
+
+	return e
+}
+
+func textArea(placeholder string) *tview.TextArea {
+	return tview.NewTextArea().
+		SetPlaceholder(placeholder).
+		SetWrap(false)
+}
+
+func completeMethod(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	var matches []string
+	for _, m := range []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"} {
+		if strings.HasPrefix(m, strings.ToUpper(text)) {
+			matches = append(matches, m)
+		}
+	}
+
+	return matches
+}
+
+func (e *editor) selectTab(i int) {
+	e.tab = i
+	e.pages.SwitchToPage(tabs[i])
+	e.tabBar.Highlight(strconv.Itoa(i))
+}
+
+// focusables returns the fields of the editor in focus order.
+func (e *editor) focusables() []tview.Primitive {
+	fields := []tview.Primitive{e.method, e.url}
+	switch e.tab {
+	case 0:
+		return append(fields, e.params)
+	case 1:
+		return append(fields, e.headers)
+	case 2:
+		return append(fields, e.bodyMode, e.contentType, e.body)
+	}
+
+	return append(fields, e.authType, e.auth)
+}
+
+// SetRequest fills the editor with a request.
+func (e *editor) SetRequest(r models.Request) {
+	e.loading = true
+	defer func() { e.loading = false }()
+
+	e.method.SetText(r.Method)
+	e.url.SetText(r.URL)
+	e.params.SetText(paramsText(r), false)
+
+	lines := make([]string, 0, len(r.Headers))
+	for _, h := range r.Headers {
+		lines = append(lines, headers.String(h))
+	}
+	e.headers.SetText(strings.Join(lines, "\n"), false)
+
+	mode := r.BodyMode
+	if mode == "" {
+		mode = models.BodyRaw
+	}
+	e.bodyMode.SetCurrentOption(slices.Index(bodyModes, mode))
+	e.contentType.SetText(r.ContentType)
+	switch mode {
+	case models.BodyURLEncoded, models.BodyFormData:
+		e.body.SetText(fieldsText(r.Form), false)
+	case models.BodyBinary:
+		e.body.SetText(r.BodyFile, false)
+	default:
+		e.body.SetText(r.Body, false)
+	}
+
+	authType := models.AuthInherit
+	if !r.Auth.Inherits() {
+		authType = r.Auth.Type
+	}
+	e.authType.SetCurrentOption(slices.Index(auth.Types, authType))
+	e.auth.SetText(authTemplate(authType, authValuesOf(r.Auth)), false)
+}
+
+// Request parses the fields of the editor. A query string typed in the
+// URL is added to the query parameters.
+func (e *editor) Request() (models.Request, error) {
+	var r models.Request
+	r.Method = client.NormalizeMethod(e.method.GetText())
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+	if !client.ValidMethod(r.Method) {
+		return r, fmt.Errorf("invalid method %q", r.Method)
+	}
+
+	var query []models.QueryParam
+	r.URL, query = client.SplitQuery(strings.TrimSpace(e.url.GetText()))
+	if r.URL == "" {
+		return r, errors.New("the URL is empty")
+	}
+
+	params, pathParams, err := parseParams(e.params.GetText())
+	if err != nil {
+		return r, fmt.Errorf("params: %w", err)
+	}
+	r.Query = append(params, query...)
+	r.PathParams = pathParams
+
+	if r.Headers, err = parseHeaders(e.headers.GetText()); err != nil {
+		return r, fmt.Errorf("headers: %w", err)
+	}
+
+	_, mode := e.bodyMode.GetCurrentOption()
+	r.ContentType = strings.TrimSpace(e.contentType.GetText())
+	switch mode {
+	case models.BodyURLEncoded, models.BodyFormData:
+		r.BodyMode = mode
+		if r.Form, err = parseFields(e.body.GetText(), mode); err != nil {
+			return r, fmt.Errorf("body: %w", err)
+		}
+	case models.BodyBinary:
+		r.BodyMode = mode
+		r.BodyFile = strings.TrimSpace(e.body.GetText())
+	default:
+		r.Body = e.body.GetText()
+	}
+	if r.HasBody() {
+		if err := bodies.Validate(r); err != nil {
+			return r, fmt.Errorf("body: %w", err)
+		}
+	}
+
+	_, authType := e.authType.GetCurrentOption()
+	if r.Auth, err = parseAuth(authType, e.auth.GetText()); err != nil {
+		return r, fmt.Errorf("auth: %w", err)
+	}
+
+	return r, nil
+}
+
+// syncURL moves a query string typed in the URL to the parameters and
+// adds lines for new path parameters.
+func (e *editor) syncURL() {
+	base, query := client.SplitQuery(strings.TrimSpace(e.url.GetText()))
+	params, pathParams, err := parseParams(e.params.GetText())
+	if err != nil {
+		return
+	}
+
+	e.url.SetText(base)
+	e.params.SetText(paramsText(models.Request{
+		URL:        base,
+		Query:      append(params, query...),
+		PathParams: pathParams,
+	}), false)
+}
+
+// paramsText lists the query parameters, then the path parameters of
+// the URL with their values.
+func paramsText(r models.Request) string {
+	var lines []string
+	for _, q := range r.Query {
+		line := q.Key + "=" + q.Value
+		if q.Disabled {
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+
+	for _, name := range pathparams.Names(r.URL) {
+		value := ""
+		for _, p := range r.PathParams {
+			if p.Key == name {
+				value = p.Value
+			}
+		}
+		lines = append(lines, ":"+name+"="+value)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func parseParams(text string) ([]models.QueryParam, []models.PathParam, error) {
+	var (
+		query      []models.QueryParam
+		pathParams []models.PathParam
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line, disabled := uncomment(line)
+		if line == "" {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, ":"); ok {
+			key, value, _ := strings.Cut(name, "=")
+			if !disabled && value != "" {
+				pathParams = pathparams.Set(pathParams, key, value)
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		if key == "" {
+			return nil, nil, fmt.Errorf("expected key=value, got %q", line)
+		}
+		query = append(query, models.QueryParam{Key: key, Value: value, Disabled: disabled})
+	}
+
+	return query, pathParams, nil
+}
+
+func parseHeaders(text string) ([]models.Header, error) {
+	var list []models.Header
+	for _, line := range strings.Split(text, "\n") {
+		line, disabled := uncomment(line)
+		if line == "" || disabled {
+			continue
+		}
+
+		header, err := headers.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, header)
+	}
+
+	return list, nil
+}
+
+func fieldsText(fields []models.FormField) string {
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		line := bodies.FieldString(f)
+		if f.Disabled {
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func parseFields(text, mode string) ([]models.FormField, error) {
+	var fields []models.FormField
+	for _, line := range strings.Split(text, "\n") {
+		line, disabled := uncomment(line)
+		if line == "" {
+			continue
+		}
+
+		var field models.FormField
+		if mode == models.BodyFormData {
+			var err error
+			if field, err = bodies.ParseField(line); err != nil {
+				return nil, err
+			}
+		} else {
+			field.Key, field.Value, _ = strings.Cut(line, "=")
+		}
+		field.Disabled = disabled
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// uncomment trims a line and reports whether it starts with #.
+func uncomment(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if rest, ok := strings.CutPrefix(line, "#"); ok {
+		return strings.TrimSpace(rest), true
+	}
+
+	return line, false
+}
+
+// authTemplate lists the fields of an auth type with the given values,
+// empty ones included so that they can be filled in.
+func authTemplate(authType string, values map[string]string) string {
+	fields := authFields[authType]
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		lines = append(lines, f+": "+values[f])
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// authValuesOf returns the fields of an auth by their JSON names.
+func authValuesOf(a *models.Auth) map[string]string {
+	values := map[string]string{}
+	if a == nil {
+		return values
+	}
+
+	b, _ := json.Marshal(a)
+	var m map[string]any
+	_ = json.Unmarshal(b, &m)
+	for k, v := range m {
+		values[k] = fmt.Sprint(v)
+	}
+
+	return values
+}
+
+// authValues reads "field: value" lines, skipping anything else.
+func authValues(text string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(value) != "" {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
+
+// parseAuth builds an auth from the fields of its type. The inherit
+// type returns nil, as a request without auth inherits it.
+func parseAuth(authType, text string) (*models.Auth, error) {
+	if authType == models.AuthInherit {
+		return nil, nil
+	}
+
+	m := map[string]any{"type": authType}
+	for _, line := range strings.Split(text, "\n") {
+		line, disabled := uncomment(line)
+		if line == "" || disabled {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || !slices.Contains(authFields[authType], key) {
+			return nil, fmt.Errorf("%s has no field %q", authType, key)
+		}
+		if value == "" {
+			continue
+		}
+
+		if key == "redirect_port" {
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redirect_port %q", value)
+			}
+			m[key] = port
+			continue
+		}
+		m[key] = value
+	}
+
+	if authType == models.AuthOAuth2 && m["grant_type"] == nil {
+		m["grant_type"] = models.GrantClientCredentials
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var a models.Auth
+	if err := json.Unmarshal(b, &a); err != nil {
+		return nil, err
+	}
+	if err := auth.Validate(&a); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"postman/internal/domain/models"
+	"postman/internal/lib/variables"
+	"postman/pkg/lib/logger/sl"
+	"time"
+)
+
+const (
+	// historySize is the number of entries shown in the history pane.
+	historySize = 200
+	// secretMask replaces the values of secret variables.
+	secretMask = "******"
+)
+
+func (t *TUI) loadHistory() error {
+	entries, err := t.history.GetEntries(context.Background(), models.HistoryFilter{Limit: historySize})
+	if err != nil {
+		return err
+	}
+
+	t.historyEntries = entries
+	t.entries.Clear()
+	secrets := map[string]map[string]string{}
+	for _, e := range entries {
+		if _, ok := secrets[e.Environment]; !ok {
+			secrets[e.Environment] = t.secrets(e.Environment)
+		}
+		status := "ERR"
+		if e.Response != nil {
+			status = fmt.Sprint(e.Status)
+		}
+		t.entries.AddItem(fmt.Sprintf("%s %s %s %s",
+			e.Time.Local().Format(time.TimeOnly), status, e.Request.Method, variables.Mask(variables.Reveal(e.Request, secrets[e.Environment]).URL, secrets[e.Environment], secretMask)), "", 0, nil)
+	}
+
+	return nil
+}
+
+// secrets returns the secret variables of the environment an entry was
+// sent with, masked in the history pane.
+func (t *TUI) secrets(name string) map[string]string {
+	if name == "" {
+		return nil
+	}
+
+	environment, err := t.environments.GetEnvironment(context.Background(), name)
+	if err != nil {
+		t.log.Debug("Error reading environment secrets", "environment", name, sl.Err(err))
+		return nil
+	}
+
+	return environment.Secrets()
+}
+
+// openEntry puts the request of a history entry as written into the
+// editor and shows the response it got.
+func (t *TUI) openEntry(i int) {
+	if i < 0 || i >= len(t.historyEntries) {
+		return
+	}
+	entry := t.historyEntries[i]
+
+	t.open("", models.SavedRequest{Request: entry.Source})
+
+	var out output
+	fmt.Fprintf(&out, "#%d sent %s", entry.ID, entry.Time.Local().Format(time.DateTime))
+	if entry.Environment != "" {
+		fmt.Fprintf(&out, " with environment %s", entry.Environment)
+	}
+	fmt.Fprintln(&out)
+	fmt.Fprintln(&out)
+
+	if entry.Response == nil {
+		fmt.Fprintln(&out, "No response:", entry.Error)
+	} else {
+		out.Response(models.Response{
+			Proto:      entry.Response.Proto,
+			StatusCode: entry.Status,
+			Status:     entry.Response.Status,
+			Headers:    entry.Response.Headers,
+			Body:       entry.Response.Body,
+			Duration:   entry.Duration,
+		})
+		if entry.Response.Truncated {
+			fmt.Fprintf(&out, "\n(body cut at %d of %d bytes)\n", len(entry.Response.Body), entry.Response.Size)
+		}
+	}
+
+	t.showOutput(&out)
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"postman/internal/client"
+	"postman/internal/domain/models"
+	"postman/internal/lib/assertions"
+	"postman/internal/lib/extract"
+	"postman/internal/lib/jq"
+	"postman/internal/lib/variables"
+	"postman/internal/render"
+	"postman/pkg/lib/logger/sl"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// ansiSequence matches the color codes written by the renderer.
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// send sends the request in the editor without blocking the UI.
+func (t *TUI) send() {
+	request, err := t.editor.Request()
+	if err != nil {
+		t.showError(err)
+		return
+	}
+
+	saved := t.saved
+	saved.Request = request
+	path := t.path
+
+	target := request.URL
+	if u, err := client.URL(request); err == nil {
+		target = u.String()
+	}
+	t.setStatus(fmt.Sprintf("Sending %s %s", request.Method, tview.Escape(target)))
+	t.response.SetText("[gray]Sending...[-]")
+
+	go func() {
+		out, status := t.do(path, saved)
+		t.app.QueueUpdateDraw(func() {
+			t.closeMessage()
+			t.showOutput(out)
+			if err := t.loadHistory(); err != nil {
+				t.log.Warn("Error loading history", sl.Err(err))
+			}
+			t.setStatus(status)
+		})
+	}()
+}
+
+// do runs the scripts of a saved request around sending it, checks its
+// assertions and returns the result and a status message. A request from
+// a collection gets the collection variables, auth and transport options.
+func (t *TUI) do(path string, saved models.SavedRequest) (*output, string) {
+	ctx := context.Background()
+	var (
+		out   output
+		scope models.Scope
+	)
+
+	if path != "" {
+		if err := t.inherit(ctx, path, &saved, &scope); err != nil {
+			out.err = err
+			return &out, "Error"
+		}
+	}
+
+	request := saved.Request
+	if saved.PreRequest != "" {
+		res, err := t.scripts.PreRequest(ctx, "pre-request", saved.PreRequest, &request, scope)
+		writeLogs(&out, res.Logs)
+		if err != nil {
+			out.err = fmt.Errorf("pre-request script: %w", err)
+			return &out, "Error"
+		}
+		scope.Variables = variables.Merge(scope.Variables, res.Variables)
+	}
+
+	exchange, err := t.requests.Send(ctx, request, scope)
+	if len(exchange.Unresolved) > 0 {
+		fmt.Fprintln(&out, "Warning: unresolved variables:", strings.Join(exchange.Unresolved, ", "))
+	}
+	if err != nil {
+		out.err = err
+		return &out, "Error sending request"
+	}
+
+	resp := exchange.Response
+	out.Response(resp)
+
+	vars, err := t.requests.Variables(ctx, scope)
+	if err != nil {
+		out.err = err
+		return &out, "Error"
+	}
+	results := assertions.Check(saved.Assertions, resp, vars)
+	extracted, failures := extract.Apply(saved.Extractors, resp)
+	results = append(results, failures...)
+	scope.Variables = variables.Merge(scope.Variables, extracted)
+	if saved.PostResponse != "" {
+		res, err := t.scripts.PostResponse(ctx, "post-response", saved.PostResponse, exchange, scope)
+		writeLogs(&out, res.Logs)
+		results = append(results, res.Tests...)
+		if err != nil {
+			out.err = fmt.Errorf("post-response script: %w", err)
+			return &out, "Error"
+		}
+	}
+	if len(results) > 0 {
+		fmt.Fprintln(&out)
+		render.New(&out).Assertions(results)
+	}
+
+	return &out, fmt.Sprintf("%s in %s", resp.Status, resp.Duration.Round(time.Millisecond))
+}
+
+// inherit applies the auth and options of the collection of a saved
+// request and its variables to the scope.
+func (t *TUI) inherit(ctx context.Context, path string, saved *models.SavedRequest, scope *models.Scope) error {
+	if saved.Auth.Inherits() {
+		inherited, err := t.collections.InheritedAuth(ctx, path)
+		if err != nil {
+			return err
+		}
+		saved.Auth = inherited
+	}
+
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	collection, err := t.collections.GetCollection(ctx, name)
+	if err != nil {
+		return err
+	}
+	if collection.Options != nil {
+		saved.Options = saved.Options.Inherit(*collection.Options)
+	}
+	scope.Defaults = collection.VariablesMap()
+
+	return nil
+}
+
+// output is what the response pane shows: the text written before and
+// after the response and the response itself, rendered when shown so
+// that Ctrl+P and the filter apply without sending the request again.
+type output struct {
+	before, after bytes.Buffer
+	resp          *models.Response
+	// err is shown after the text.
+	err error
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	if o.resp == nil {
+		return o.before.Write(p)
+	}
+
+	return o.after.Write(p)
+}
+
+// Response keeps the response, rendered by text.
+func (o *output) Response(resp models.Response) {
+	o.resp = &resp
+}
+
+// text returns the output as tview text, the body printed as received
+// with raw and replaced by the results of the filter when there is one.
+func (o *output) text(raw bool, filter *jq.Query) string {
+	var b strings.Builder
+	b.WriteString(ansiText(o.before.String()))
+
+	if o.resp != nil {
+		var buf bytes.Buffer
+		r := render.New(&buf)
+		r.SetRaw(raw)
+		var err error
+		if filter == nil {
+			r.Response(*o.resp)
+		} else {
+			r.Head(*o.resp)
+			err = r.Filter(filter, o.resp.Body)
+		}
+		b.WriteString(ansiText(buf.String()))
+		if err != nil {
+			b.WriteString(errorText(fmt.Errorf("filter: %w", err)) + "\n")
+		}
+	}
+
+	b.WriteString(ansiText(o.after.String()))
+	if o.err != nil {
+		b.WriteString(errorText(o.err))
+	}
+
+	return b.String()
+}
+
+func writeLogs(w io.Writer, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, "log:", line)
+	}
+}
+
+func errorText(err error) string {
+	return "[red]Error:[-] " + tview.Escape(err.Error())
+}
+
+// ansiText converts the output of the renderer to tview text: the text
+// between color codes is escaped, then the codes become color tags.
+func ansiText(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range ansiSequence.FindAllStringIndex(s, -1) {
+		b.WriteString(tview.Escape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(tview.Escape(s[last:]))
+
+	return tview.TranslateANSI(b.String())
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"context"
+	"postman/internal/domain/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// loadTree lists the collections with their folders and requests. The
+// nodes expanded before stay expanded.
+func (t *TUI) loadTree() error {
+	collections, err := t.collections.GetCollections(context.Background())
+	if err != nil {
+		return err
+	}
+
+	expanded := map[string]bool{}
+	if root := t.tree.GetRoot(); root != nil {
+		root.Walk(func(node, _ *tview.TreeNode) bool {
+			if path, ok := node.GetReference().(string); ok && node.IsExpanded() {
+				expanded[path] = true
+			}
+			return true
+		})
+	}
+
+	root := tview.NewTreeNode("Collections")
+	for _, c := range collections {
+		node := folderNode(c.Name, c.Name, expanded).
+			SetColor(tcell.ColorYellow)
+		addRequests(node, c.Name, c.Folders, c.Requests, expanded)
+		root.AddChild(node)
+	}
+	if len(collections) == 0 {
+		root.AddChild(tview.NewTreeNode("no collections, Ctrl+S saves the request").
+			SetSelectable(false))
+	}
+
+	t.tree.SetRoot(root).
+		SetTopLevel(1)
+	if children := root.GetChildren(); len(children) > 0 {
+		t.tree.SetCurrentNode(children[0])
+	}
+
+	return nil
+}
+
+func addRequests(parent *tview.TreeNode, path string, folders []models.Folder, requests []models.SavedRequest, expanded map[string]bool) {
+	for _, f := range folders {
+		node := folderNode(f.Name+"/", path+"/"+f.Name, expanded)
+		addRequests(node, path+"/"+f.Name, f.Folders, f.Requests, expanded)
+		parent.AddChild(node)
+	}
+
+	for _, r := range requests {
+		node := tview.NewTreeNode(r.Method + " " + r.Name).
+			SetReference(request(path + "/" + r.Name)).
+			SetColor(methodColor(r.Method))
+		parent.AddChild(node)
+	}
+}
+
+// folderNode is a collection or folder node, its reference the path.
+func folderNode(text, path string, expanded map[string]bool) *tview.TreeNode {
+	return tview.NewTreeNode(text).
+		SetReference(path).
+		SetExpanded(expanded[path])
+}
+
+// request is the reference of a request node, told apart from folders.
+type request string
+
+// selectNode opens a request or expands a folder.
+func (t *TUI) selectNode(node *tview.TreeNode) {
+	path, ok := node.GetReference().(request)
+	if !ok {
+		node.SetExpanded(!node.IsExpanded())
+		return
+	}
+
+	saved, err := t.collections.GetRequest(context.Background(), string(path))
+	if err != nil {
+		t.showError(err)
+		return
+	}
+	t.open(string(path), saved)
+	t.app.SetFocus(t.editor.url)
+}
+
+func methodColor(method string) tcell.Color {
+	switch method {
+	case "GET":
+		return tcell.ColorGreen
+	case "POST":
+		return tcell.ColorYellow
+	case "PUT", "PATCH":
+		return tcell.ColorBlue
+	case "DELETE":
+		return tcell.ColorRed
+	}
+
+	return tcell.ColorWhite
+}
@@ -0,0 +1,458 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"postman/internal/domain/interfaces/service"
+	"postman/internal/domain/models"
+	"postman/internal/lib/jq"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	mainPage    = "main"
+	messagePage = "message"
+)
+
+const help = `[::b]Keys[::-]
+
+  Ctrl+R, F5        send the request
+  Ctrl+S            save the request into a collection
+  Ctrl+N            new request
+  Ctrl+E            choose the environment
+  Ctrl+T            next editor tab: Params, Headers, Body, Auth
+  Tab, Shift+Tab    next or previous pane
+  Ctrl+L            reload collections and history
+  Ctrl+P            pretty-printed or raw response body
+  Ctrl+F            filter the JSON response with jq, e.g. .[] | .login
+  F1                this help
+  Ctrl+Q, Ctrl+C    quit
+
+  Enter in the collection tree opens a request or a folder,
+  Enter in the history opens a past request with its response.
+  The response scrolls with the arrows, PgUp, PgDn, g and G.
+  The filter applies to the responses that follow until cleared.
+
+[::b]Editor[::-]
+
+  Params    key=value per line, :name=value sets a path parameter
+  Headers   Key: Value per line
+  Body      raw text, key=value form fields or the path of a file
+  Auth      field: value per line for the chosen type
+
+  A # in front of a line disables it. {{variables}} are substituted
+  when sending.`
+
+// TUI is the full-screen interactive mode: the collection tree and the
+// history on the left, the request editor and the response on the right.
+type TUI struct {
+	log          *slog.Logger
+	requests     service.IRequestsService
+	collections  service.ICollectionsService
+	environments service.IEnvironmentsService
+	scripts      service.IScriptsService
+	history      service.IHistoryService
+
+	app      *tview.Application
+	pages    *tview.Pages
+	tree     *tview.TreeView
+	entries  *tview.List
+	editor   *editor
+	response *tview.TextView
+	filter   *tview.InputField
+	status   *tview.TextView
+
+	// historyEntries are the entries listed in the history pane.
+	historyEntries []models.HistoryEntry
+	// path is the collection path of the request in the editor, empty
+	// when it isn't saved. saved keeps its assertions and scripts.
+	path  string
+	saved models.SavedRequest
+	// focus is restored when a dialog closes.
+	focus tview.Primitive
+	// shown is the output in the response pane, nil for an error. raw
+	// shows its bodies as received.
+	shown *output
+	raw   bool
+	// query is the filter applied to the responses, nil for none.
+	query *jq.Query
+}
+
+func New(
+	log *slog.Logger,
+	requests service.IRequestsService,
+	collections service.ICollectionsService,
+	environments service.IEnvironmentsService,
+	scripts service.IScriptsService,
+	history service.IHistoryService,
+) *TUI {
+	t := &TUI{
+		log:          log,
+		requests:     requests,
+		collections:  collections,
+		environments: environments,
+		scripts:      scripts,
+		history:      history,
+		app:          tview.NewApplication(),
+		pages:        tview.NewPages(),
+		tree:         tview.NewTreeView(),
+		entries:      tview.NewList(),
+		editor:       newEditor(),
+		response:     tview.NewTextView(),
+		filter:       tview.NewInputField(),
+		status:       tview.NewTextView(),
+	}
+
+	t.tree.SetSelectedFunc(t.selectNode).
+		SetBorder(true).
+		SetTitle(" Collections ")
+
+	t.entries.ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedFunc(func(i int, _, _ string, _ rune) { t.openEntry(i) }).
+		SetBorder(true).
+		SetTitle(" History ")
+
+	t.editor.root.SetBorder(true).
+		SetTitle(" Request ")
+
+	t.response.SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false).
+		SetBorder(true).
+		SetTitle(" Response ")
+
+	t.filter.SetLabel("jq ").
+		SetPlaceholder("filter, e.g. .[] | select(.id > 10) | .login, Enter applies").
+		SetDoneFunc(t.applyFilter)
+
+	t.status.SetDynamicColors(true)
+
+	left := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(t.tree, 0, 3, true).
+		AddItem(t.entries, 0, 2, false)
+	right := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(t.editor.root, 0, 2, false).
+		AddItem(t.response, 0, 3, false).
+		AddItem(t.filter, 1, 0, false)
+	body := tview.NewFlex().
+		AddItem(left, 0, 1, true).
+		AddItem(right, 0, 3, false)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(t.status, 1, 0, false)
+
+	t.pages.AddPage(mainPage, root, true, true)
+	t.app.SetRoot(t.pages, true).
+		EnableMouse(true).
+		SetInputCapture(t.keys)
+
+	return t
+}
+
+// Run shows the UI until the user quits.
+func (t *TUI) Run() error {
+	t.reload()
+	t.setStatus("F1 for help")
+
+	return t.app.Run()
+}
+
+// keys handles the shortcuts of the main page. Dialogs get every key.
+func (t *TUI) keys(event *tcell.EventKey) *tcell.EventKey {
+	if front, _ := t.pages.GetFrontPage(); front != mainPage {
+		return event
+	}
+
+	switch event.Key() {
+	case tcell.KeyCtrlR, tcell.KeyF5:
+		t.send()
+	case tcell.KeyCtrlS:
+		t.showSave()
+	case tcell.KeyCtrlN:
+		t.open("", models.SavedRequest{Request: models.Request{Method: "GET"}})
+		t.app.SetFocus(t.editor.url)
+	case tcell.KeyCtrlE:
+		t.showEnvironments()
+	case tcell.KeyCtrlT:
+		t.editor.selectTab((t.editor.tab + 1) % len(tabs))
+		t.app.SetFocus(t.editor.pages)
+	case tcell.KeyTab:
+		t.cycleFocus(1)
+	case tcell.KeyBacktab:
+		t.cycleFocus(-1)
+	case tcell.KeyCtrlL:
+		t.reload()
+	case tcell.KeyCtrlP:
+		t.toggleRaw()
+	case tcell.KeyCtrlF:
+		t.app.SetFocus(t.filter)
+	case tcell.KeyF1:
+		t.showHelp()
+	case tcell.KeyCtrlQ:
+		t.app.Stop()
+	default:
+		return event
+	}
+
+	return nil
+}
+
+// cycleFocus moves the focus to the next or previous pane, going
+// through the fields of the editor.
+func (t *TUI) cycleFocus(delta int) {
+	panes := []tview.Primitive{t.tree, t.entries}
+	panes = append(panes, t.editor.focusables()...)
+	panes = append(panes, t.response, t.filter)
+
+	current := 0
+	for i, p := range panes {
+		if p.HasFocus() {
+			current = i
+			break
+		}
+	}
+
+	t.app.SetFocus(panes[(current+delta+len(panes))%len(panes)])
+}
+
+// reload reads the collections and the history again.
+func (t *TUI) reload() {
+	if err := t.loadTree(); err != nil {
+		t.showError(err)
+	}
+	if err := t.loadHistory(); err != nil {
+		t.showError(err)
+	}
+}
+
+func (t *TUI) setStatus(message string) {
+	environment := "no environment"
+	if active, err := t.environments.ActiveEnvironment(context.Background()); err == nil && active.Name != "" {
+		environment = active.Name
+	}
+
+	path := t.path
+	if path == "" {
+		path = "unsaved request"
+	}
+
+	fmt.Fprintf(t.status.Clear(), "[black:aqua] %s [-:-] %s  %s", tview.Escape(environment), tview.Escape(path), message)
+}
+
+func (t *TUI) showError(err error) {
+	t.shown = nil
+	t.response.SetText(errorText(err)).
+		ScrollToBeginning()
+}
+
+func (t *TUI) showOutput(out *output) {
+	t.shown = out
+	t.response.SetText(out.text(t.raw, t.query)).
+		ScrollToBeginning()
+}
+
+// toggleRaw switches the response body between pretty-printed and as
+// received.
+func (t *TUI) toggleRaw() {
+	t.raw = !t.raw
+	if t.shown != nil {
+		row, column := t.response.GetScrollOffset()
+		t.response.SetText(t.shown.text(t.raw, t.query)).
+			ScrollTo(row, column)
+	}
+
+	if t.raw {
+		t.setStatus("Raw body")
+	} else {
+		t.setStatus("Pretty-printed body")
+	}
+}
+
+// open puts a request into the editor.
+func (t *TUI) open(path string, saved models.SavedRequest) {
+	t.path, t.saved = path, saved
+	t.editor.SetRequest(saved.Request)
+	t.shown = nil
+	t.response.Clear()
+	t.setStatus("")
+}
+
+// applyFilter sets the filter typed into the filter field, or clears it
+// when empty, and shows the response again with it.
+func (t *TUI) applyFilter(key tcell.Key) {
+	if key != tcell.KeyEnter {
+		t.app.SetFocus(t.response)
+		return
+	}
+
+	t.query = nil
+	if text := strings.TrimSpace(t.filter.GetText()); text != "" {
+		query, err := jq.Compile(text)
+		if err != nil {
+			t.setStatus("[red]" + tview.Escape(err.Error()) + "[-]")
+			return
+		}
+		t.query = query
+	}
+
+	if t.shown != nil {
+		t.showOutput(t.shown)
+	}
+	if t.query == nil {
+		t.setStatus("Filter cleared")
+	} else {
+		t.setStatus("Filtered")
+	}
+}
+
+// Output returns a writer that shows what services print for the user
+// while a request is sent, e.g. the OAuth 2.0 authorization URL, in a
+// dialog. The dialog closes when the response arrives.
+func (t *TUI) Output() io.Writer {
+	return messageWriter{t}
+}
+
+type messageWriter struct {
+	t *TUI
+}
+
+func (w messageWriter) Write(p []byte) (int, error) {
+	message := string(p)
+	w.t.app.QueueUpdateDraw(func() {
+		w.t.showMessage(message)
+	})
+
+	return len(p), nil
+}
+
+// showMessage shows a message with the text wrapped, so that long URLs
+// can be read and selected whole.
+func (t *TUI) showMessage(message string) {
+	t.closeMessage()
+
+	text := tview.NewTextView().
+		SetWrap(true).
+		SetText(message)
+	text.SetDoneFunc(func(tcell.Key) { t.closeMessage() }).
+		SetBorder(true).
+		SetTitle(" Message, Esc to close ")
+
+	t.showModal(messagePage, text, 80, 12)
+}
+
+func (t *TUI) closeMessage() {
+	if t.pages.HasPage(messagePage) {
+		t.closeModal(messagePage)
+	}
+}
+
+// showModal shows a dialog of the given size in the middle of the screen.
+func (t *TUI) showModal(name string, p tview.Primitive, width, height int) {
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	t.focus = t.app.GetFocus()
+	t.pages.AddPage(name, modal, true, true)
+	t.app.SetFocus(p)
+}
+
+func (t *TUI) closeModal(name string) {
+	t.pages.RemovePage(name)
+	if t.focus != nil {
+		t.app.SetFocus(t.focus)
+	}
+}
+
+func (t *TUI) showHelp() {
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(help)
+	text.SetDoneFunc(func(tcell.Key) { t.closeModal("help") }).
+		SetBorder(true).
+		SetTitle(" Help, Esc to close ")
+
+	t.showModal("help", text, 76, strings.Count(help, "\n")+3)
+}
+
+// showSave asks for the collection path and saves the request there.
+func (t *TUI) showSave() {
+	request, err := t.editor.Request()
+	if err != nil {
+		t.showError(err)
+		return
+	}
+
+	input := tview.NewInputField().
+		SetLabel("Path ").
+		SetPlaceholder("collection/[folder/...]name").
+		SetText(t.path)
+	input.SetDoneFunc(func(key tcell.Key) {
+		t.closeModal("save")
+		path := strings.TrimSpace(input.GetText())
+		if key != tcell.KeyEnter || path == "" {
+			return
+		}
+
+		saved := t.saved
+		saved.Request = request
+		if err := t.collections.SaveRequest(context.Background(), path, saved); err != nil {
+			t.showError(err)
+			return
+		}
+		t.path, t.saved = path, saved
+		if err := t.loadTree(); err != nil {
+			t.showError(err)
+		}
+		t.setStatus("Saved")
+	})
+	input.SetBorder(true).
+		SetTitle(" Save as, Esc to cancel ")
+
+	t.showModal("save", input, 60, 3)
+}
+
+// showEnvironments lets the user choose the active environment.
+func (t *TUI) showEnvironments() {
+	ctx := context.Background()
+	environments, err := t.environments.GetEnvironments(ctx)
+	if err != nil {
+		t.showError(err)
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	use := func(name string) {
+		t.closeModal("environments")
+		if err := t.environments.UseEnvironment(ctx, name); err != nil {
+			t.showError(err)
+		}
+		t.setStatus("")
+	}
+	list.AddItem("no environment", "", 0, func() { use("") })
+	for _, e := range environments {
+		list.AddItem(tview.Escape(e.Name), "", 0, func() { use(e.Name) })
+	}
+	if active, err := t.environments.ActiveEnvironment(ctx); err == nil {
+		for i, e := range environments {
+			if e.Name == active.Name {
+				list.SetCurrentItem(i + 1)
+			}
+		}
+	}
+	list.SetDoneFunc(func() { t.closeModal("environments") }).
+		SetBorder(true).
+		SetTitle(" Environment ")
+
+	t.showModal("environments", list, 40, min(len(environments)+3, 20))
+}
@@ -3,19 +3,70 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env  string `yaml:"env" env-default:"local"`
-	Port int    `yaml:"port"`
+	Env     string        `yaml:"env" env-default:"local"`
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout" env:"POSTMAN_TIMEOUT" env-default:"5s"`
+	// StoragePath is the directory for collections and other saved data.
+	// Defaults to "postman" in the user config directory.
+	StoragePath string `yaml:"storage_path" env:"POSTMAN_STORAGE_PATH"`
+	// UI is the interactive mode: the full-screen UI, or the line prompt
+	// which is also used when stdin or stdout is not a terminal.
+	UI string `yaml:"ui" env:"POSTMAN_UI" env-default:"tui"`
+	// HARPath is a HAR 1.2 file every sent request is appended to.
+	// Recording is off when empty.
+	HARPath string `yaml:"har_path" env:"POSTMAN_HAR"`
+	// Every sent request is kept in the history unless disabled. The
+	// oldest entries are dropped past HistoryLimit, and response bodies
+	// are cut at HistoryBodyLimit bytes.
+	DisableHistory   bool `yaml:"disable_history"`
+	HistoryLimit     int  `yaml:"history_limit" env-default:"1000"`
+	HistoryBodyLimit int  `yaml:"history_body_limit" env-default:"65536"`
+
+	// The transport defaults below are overridden by collections and
+	// requests. Redirects are followed and HTTP/2 is used unless disabled.
+	// MaxRedirects 0 means the default of 10, not unlimited. Requests to
+	// localhost and loopback addresses bypass the proxy.
+	NoFollowRedirects  bool   `yaml:"no_follow_redirects"`
+	MaxRedirects       int    `yaml:"max_redirects" env-default:"10"`
+	Proxy              string `yaml:"proxy" env:"POSTMAN_PROXY"`
+	NoProxy            string `yaml:"no_proxy" env:"POSTMAN_NO_PROXY"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CABundle           string `yaml:"ca_bundle" env:"POSTMAN_CA_BUNDLE"`
+	DisableHTTP2       bool   `yaml:"disable_http2"`
+
+	ClientCertificates []ClientCertificate `yaml:"client_certificates"`
 }
 
+// ClientCertificate maps host patterns to a PEM certificate and key or
+// a PKCS#12 bundle. PassphraseEnv names an environment variable holding
+// the passphrase, so it does not have to be written in the file.
+type ClientCertificate struct {
+	Hosts         []string `yaml:"hosts"`
+	Cert          string   `yaml:"cert"`
+	Key           string   `yaml:"key"`
+	PKCS12        string   `yaml:"pkcs12"`
+	Passphrase    string   `yaml:"passphrase"`
+	PassphraseEnv string   `yaml:"passphrase_env"`
+}
+
+// MustLoad loads the config file given by --config or CONFIG_PATH.
+// Without a config file the defaults and environment variables are used.
 func MustLoad() *Config {
 	configPath := fetchConfigPath()
 	if configPath == "" {
-		panic("config path is empty")
+		var cfg Config
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			panic("cannot read config from env: " + err.Error())
+		}
+
+		return withDefaults(&cfg)
 	}
 
 	return MustLoadPath(configPath)
@@ -33,7 +84,19 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
-	return &cfg
+	return withDefaults(&cfg)
+}
+
+func withDefaults(cfg *Config) *Config {
+	if cfg.StoragePath == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			dir = "."
+		}
+		cfg.StoragePath = filepath.Join(dir, "postman")
+	}
+
+	return cfg
 }
 
 // fetchConfigPath fetches config path from command line flag or environment variable.
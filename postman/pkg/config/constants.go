@@ -5,3 +5,9 @@ const (
 	EnvDev   = "dev"
 	EnvProd  = "prod"
 )
+
+// Interactive modes.
+const (
+	UIFullScreen = "tui"
+	UIPrompt     = "prompt"
+)
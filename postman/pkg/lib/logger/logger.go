@@ -16,11 +16,11 @@ func SetupLogger(env string) *slog.Logger {
 		log = setupPrettySlog()
 	case constants.EnvDev:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
 		)
 	case constants.EnvProd:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
 	}
 
@@ -34,7 +34,7 @@ func setupPrettySlog() *slog.Logger {
 		},
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
+	handler := opts.NewPrettyHandler(os.Stderr)
 
 	return slog.New(handler)
 }